@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/btraven00/hapiq/pkg/auth"
+)
+
+var (
+	authToken string
+	authLogin string
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage credentials for restricted sources",
+	Long: `Auth manages the credentials file used for sources that accept tokens or
+API keys (zenodo, figshare, vcp, and geo for an NCBI API key).
+
+Credentials are looked up per source, first match wins:
+  1. environment variables (ZENODO_TOKEN, FIGSHARE_TOKEN, VCP_TOKEN, NCBI_API_KEY)
+  2. auth.<source>.token or auth.<source>.token_file in the config file
+  3. the credentials file (` + "`hapiq auth set`" + `), mode 0600, by default
+     ~/.config/hapiq/credentials.yaml (override with auth.credentials_file)
+  4. ~/.netrc (or $NETRC) machine entries for the source's hosts
+
+Examples:
+  hapiq auth set zenodo                # prompts for the token
+  echo "$TOKEN" | hapiq auth set figshare
+  hapiq auth list
+  hapiq auth remove zenodo`,
+}
+
+var authSetCmd = &cobra.Command{
+	Use:   "set <source>",
+	Short: "Store a token for a source",
+	Long: `Set stores a token for <source> in the credentials file. Without --token the
+token is read from standard input, which keeps it out of shell history.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		store, err := auth.OpenStore(auth.CredentialsPath())
+		if err != nil {
+			return err
+		}
+		token := authToken
+		if token == "" {
+			if token, err = readToken(os.Stdin, auth.Canonical(args[0])); err != nil {
+				return err
+			}
+		}
+		store.Set(args[0], auth.Credential{Login: authLogin, Token: token})
+		if err := store.Save(); err != nil {
+			return err
+		}
+		if !quiet {
+			fmt.Printf("Stored credentials for %s in %s\n", auth.Canonical(args[0]), store.Path())
+		}
+		return nil
+	},
+}
+
+var authRemoveCmd = &cobra.Command{
+	Use:     "remove <source>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a source's stored token",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		store, err := auth.OpenStore(auth.CredentialsPath())
+		if err != nil {
+			return err
+		}
+		if !store.Remove(args[0]) {
+			return fmt.Errorf("no stored credentials for %s in %s", auth.Canonical(args[0]), store.Path())
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+		if !quiet {
+			fmt.Printf("Removed credentials for %s from %s\n", auth.Canonical(args[0]), store.Path())
+		}
+		return nil
+	},
+}
+
+// authListEntry is one row of `hapiq auth list`.
+type authListEntry struct {
+	Source string `json:"source"`
+	Origin string `json:"origin,omitempty"`
+	Token  string `json:"token,omitempty"` // masked
+	Error  string `json:"error,omitempty"`
+}
+
+var authListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show which sources have credentials and where they come from",
+	Long: `List shows, for every source that accepts credentials and every source in
+the credentials file, where its credential was found. Tokens are masked.`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		resolver, err := auth.NewResolver()
+		if err != nil {
+			return err
+		}
+
+		names := map[string]bool{}
+		for _, s := range auth.Sources {
+			names[s.Name] = true
+		}
+		for _, name := range resolver.Store.Sources() {
+			names[name] = true
+		}
+		sorted := make([]string, 0, len(names))
+		for name := range names {
+			sorted = append(sorted, name)
+		}
+		sort.Strings(sorted)
+
+		entries := make([]authListEntry, 0, len(sorted))
+		for _, name := range sorted {
+			e := authListEntry{Source: name}
+			c, err := resolver.Lookup(name)
+			switch {
+			case err != nil:
+				e.Error = err.Error()
+			case !c.IsZero():
+				e.Origin, e.Token = c.Origin, c.Masked()
+			}
+			entries = append(entries, e)
+		}
+
+		if output == outputFormatJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(entries)
+		}
+		fmt.Printf("Credentials file: %s\n\n", resolver.Store.Path())
+		for _, e := range entries {
+			switch {
+			case e.Error != "":
+				fmt.Printf("  %-10s ❌ %s\n", e.Source, e.Error)
+			case e.Origin == "":
+				fmt.Printf("  %-10s -\n", e.Source)
+			default:
+				fmt.Printf("  %-10s %s (%s)\n", e.Source, e.Token, e.Origin)
+			}
+		}
+		return nil
+	},
+}
+
+// readToken reads a token from the first line of r, prompting when r is a
+// terminal.
+func readToken(r io.Reader, source string) (string, error) {
+	if f, ok := r.(*os.File); ok {
+		if fi, err := f.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+			_, _ = fmt.Fprintf(os.Stderr, "Token for %s: ", source)
+		}
+	}
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("read token: %w", err)
+	}
+	token := strings.TrimSpace(line)
+	if token == "" {
+		return "", fmt.Errorf("no token given for %s", source)
+	}
+	return token, nil
+}
+
+// credentials is the Provider downloaders are wired from; see credentialFor.
+var credentials auth.Provider
+
+// credentialFor returns the token configured for source, or "" for
+// anonymous access. Lookup problems (an unreadable credentials or token
+// file) are reported as warnings and treated as no credential.
+func credentialFor(source string) string {
+	if credentials == nil {
+		r, err := auth.NewResolver()
+		if err != nil && !quiet {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: %v; ignoring the credentials file\n", err)
+		}
+		credentials = r
+	}
+	c, err := credentials.Lookup(source)
+	if err != nil {
+		if !quiet {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: %v; continuing without %s credentials\n", err, source)
+		}
+		return ""
+	}
+	return c.Token
+}
+
+func init() {
+	authSetCmd.Flags().StringVar(&authToken, "token", "", "token to store (default: read from stdin)")
+	authSetCmd.Flags().StringVar(&authLogin, "login", "", "user name, for sources that need one")
+
+	authCmd.AddCommand(authSetCmd, authListCmd, authRemoveCmd)
+	rootCmd.AddCommand(authCmd)
+}
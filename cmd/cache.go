@@ -3,9 +3,14 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -53,6 +58,38 @@ var cacheInfoCmd = &cobra.Command{
 		} else {
 			fmt.Printf("Quota:       none\n")
 		}
+		policy := c.Policy()
+		if policy == cache.PolicyTTL && cfg.TTL > 0 {
+			policy += " (" + cfg.TTL.String() + ")"
+		}
+		fmt.Printf("Policy:      %s\n", policy)
+		if n, size, err := c.PinnedStats(ctx); err == nil && n > 0 {
+			fmt.Printf("Pinned:      %d blobs, %s\n", n, common.FormatBytes(size))
+		}
+		if n, original, stored, err := c.CompressedStats(ctx); err == nil && n > 0 {
+			fmt.Printf("Compressed:  %d blobs, %s stored as %s\n",
+				n, common.FormatBytes(original), common.FormatBytes(stored))
+		}
+
+		if len(cfg.Tiers) > 0 {
+			tiers, err := c.Tiers(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Tiers (lookup order):\n")
+			for i, t := range tiers {
+				mode := "writable"
+				if t.ReadOnly {
+					mode = "read-only"
+				}
+				fmt.Printf("  %d. %-9s  %s\n", i+1, mode, t.Dir)
+				fmt.Printf("     %d blobs, %s; %d hits served", t.Blobs, common.FormatBytes(t.Size), t.Hits)
+				if t.Hits > 0 {
+					fmt.Printf(", last %s", t.LastHit.Format("2006-01-02 15:04"))
+				}
+				fmt.Println()
+			}
+		}
 		return nil
 	},
 }
@@ -66,7 +103,7 @@ var cacheListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List cached blobs",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		c, _, err := openCacheForCmd()
+		c, cfg, err := openCacheForCmd()
 		if err != nil {
 			return err
 		}
@@ -82,6 +119,7 @@ var cacheListCmd = &cobra.Command{
 		}
 
 		fmt.Printf("%-64s  %10s  %-20s  %s\n", "SHA256", "SIZE", "LAST USED", "URL(s)")
+		indent := strings.Repeat(" ", 64+10+20+4)
 		for _, b := range blobs {
 			url := ""
 			if len(b.URLs) > 0 {
@@ -94,7 +132,21 @@ var cacheListCmd = &cobra.Command{
 				url,
 			)
 			for _, u := range b.URLs[1:] {
-				fmt.Printf("%s  %s\n", strings.Repeat(" ", 64+10+20+4), u)
+				fmt.Printf("%s  %s\n", indent, u)
+			}
+			if b.Pinned {
+				labels := b.Pins
+				if len(labels) == 0 {
+					labels = []string{""}
+				}
+				fmt.Printf("%s  pinned: %s\n", indent, formatPinLabels(labels))
+			}
+			if len(cfg.Tiers) > 0 {
+				tier := b.Tier
+				if b.ReadOnly {
+					tier += " (read-only)"
+				}
+				fmt.Printf("%s  tier: %s\n", indent, tier)
 			}
 		}
 		return nil
@@ -135,8 +187,12 @@ var cacheVerifyCmd = &cobra.Command{
 			return err
 		}
 
-		corrupt := 0
+		corrupt, verified := 0, 0
 		for _, b := range blobs {
+			if b.ReadOnly {
+				continue // not ours to evict
+			}
+			verified++
 			ok, err := c.VerifyBlob(ctx, b.SHA256)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "error verifying %s: %v\n", b.SHA256[:16], err)
@@ -147,21 +203,39 @@ var cacheVerifyCmd = &cobra.Command{
 				corrupt++
 			}
 		}
-		fmt.Printf("Verified %d blobs; %d corrupt.\n", len(blobs), corrupt)
+		fmt.Printf("Verified %d blobs; %d corrupt.\n", verified, corrupt)
 		return nil
 	},
 }
 
 var (
-	cacheGCDryRun bool
-	cacheGCKeep   string
+	cacheGCDryRun      bool
+	cacheGCKeep        string
+	cacheGCOrphansOnly bool
 )
 
 var cacheGCCmd = &cobra.Command{
 	Use:   "gc",
-	Short: "Evict least-recently-used blobs until under quota",
+	Short: "Evict blobs by the quota policy until under quota",
+	Long: `GC evicts blobs until the cache is under max_size. cache.quota_policy
+chooses which ones:
+
+  lru       least recently used first (default)
+  lfu       fewest cache hits first
+  size-lru  largest size × time since last use first
+  ttl       everything older than cache.ttl, then the oldest while over quota
+  never     nothing; gc fails while the cache is over quota
+
+Blobs whose every materialized copy has been deleted (orphans, see 'hapiq
+cache refs') go first under every policy; --orphans-only evicts just those,
+quota or not. Blobs that output directories still hardlink or symlink to
+are skipped. With --dry-run, each blob that would go is listed with the
+reason.
+
+When cache.compress_after is set, gc then compresses blobs unused for that
+long, as 'hapiq cache compact' does.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		c, _, err := openCacheForCmd()
+		c, cfg, err := openCacheForCmd()
 		if err != nil {
 			return err
 		}
@@ -169,32 +243,112 @@ var cacheGCCmd = &cobra.Command{
 
 		var keep time.Duration
 		if cacheGCKeep != "" {
-			keep, err = time.ParseDuration(cacheGCKeep)
+			keep, err = cache.ParseAge(cacheGCKeep)
 			if err != nil {
 				return fmt.Errorf("invalid --keep value: %w", err)
 			}
 		}
 
-		result, err := c.GC(context.Background(), cacheGCDryRun, keep)
+		var result cache.GCResult
+		if cacheGCOrphansOnly {
+			result, err = c.GCOrphans(context.Background(), cacheGCDryRun)
+		} else {
+			result, err = c.GC(context.Background(), cacheGCDryRun, keep)
+		}
 		if err != nil {
 			return err
 		}
 
 		if cacheGCDryRun {
-			fmt.Printf("Dry-run: would evict %d blobs, freeing %s",
-				result.Evicted, common.FormatBytes(result.Freed))
+			for _, e := range result.Candidates {
+				fmt.Printf("%-64s  %10s  %s\n", e.SHA256, common.FormatBytes(e.Size), e.Reason)
+			}
+			fmt.Printf("Dry-run (%s): would evict %d blobs, freeing %s",
+				result.Policy, result.Evicted, common.FormatBytes(result.Freed))
 		} else {
 			fmt.Printf("Evicted %d blobs, freed %s",
 				result.Evicted, common.FormatBytes(result.Freed))
 		}
 		if result.Skipped > 0 {
-			fmt.Printf(" (%d skipped — live hardlinks)", result.Skipped)
+			fmt.Printf(" (%d skipped — live links)", result.Skipped)
 		}
 		fmt.Println()
+
+		if cfg.CompressAfter > 0 && !cacheGCOrphansOnly {
+			if cfg.LinkStrategy == cache.StrategySymlink {
+				fmt.Fprintf(os.Stderr, "warning: cache.compress_after is ignored with link_strategy = \"symlink\"\n")
+				return nil
+			}
+			compacted, err := c.Compact(context.Background(), cfg.CompressAfter, cacheGCDryRun)
+			if err != nil {
+				return err
+			}
+			printCompactResult(compacted)
+		}
 		return nil
 	},
 }
 
+var (
+	cacheCompactDryRun    bool
+	cacheCompactOlderThan string
+)
+
+var cacheCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Compress blobs that have not been used for a while",
+	Long: `Compact compresses cold blobs in place to save disk space. A blob keeps
+its sha256 (the hash of the original bytes); it is decompressed into a
+private copy when next materialized, so compressed blobs are never
+hardlinked or reflinked into output directories.
+
+Blobs already in a compressed format are sampled first and left alone, as
+are blobs hardlinked from output directories. --older-than defaults to
+cache.compress_after, or 90d when that is unset. Compaction is unavailable
+with link_strategy = "symlink".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, cfg, err := openCacheForCmd()
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		olderThan := cfg.CompressAfter
+		if cacheCompactOlderThan != "" {
+			olderThan, err = cache.ParseAge(cacheCompactOlderThan)
+			if err != nil {
+				return fmt.Errorf("invalid --older-than value: %w", err)
+			}
+		} else if olderThan == 0 {
+			olderThan = 90 * 24 * time.Hour
+		}
+
+		result, err := c.Compact(context.Background(), olderThan, cacheCompactDryRun)
+		if err != nil {
+			return err
+		}
+		printCompactResult(result)
+		return nil
+	},
+}
+
+func printCompactResult(r cache.CompactResult) {
+	if r.DryRun {
+		fmt.Printf("Dry-run: would compress up to %d blobs (%s)",
+			r.Compacted, common.FormatBytes(r.Before))
+	} else {
+		fmt.Printf("Compressed %d blobs, %s → %s",
+			r.Compacted, common.FormatBytes(r.Before), common.FormatBytes(r.After))
+		if r.Incompressible > 0 {
+			fmt.Printf("; %d incompressible", r.Incompressible)
+		}
+	}
+	if r.Skipped > 0 {
+		fmt.Printf(" (%d skipped — live hardlinks)", r.Skipped)
+	}
+	fmt.Println()
+}
+
 var cacheEvictCmd = &cobra.Command{
 	Use:   "evict <sha256>",
 	Short: "Remove a specific blob and its URL mappings",
@@ -207,13 +361,20 @@ var cacheEvictCmd = &cobra.Command{
 		defer c.Close()
 
 		sha256hex := args[0]
+		ctx := context.Background()
+		if labels, err := c.Pins(ctx, sha256hex); err != nil {
+			return err
+		} else if len(labels) > 0 {
+			return fmt.Errorf("blob %s is pinned (%s); run 'hapiq cache unpin %s' first",
+				sha256hex[:16], formatPinLabels(labels), sha256hex)
+		}
 		if c.IsPinned(sha256hex) {
 			fmt.Fprintf(os.Stderr, "warning: blob %s has live hardlinks from output directories;\n"+
 				"  the index entry will be removed but the file data remains accessible\n"+
 				"  via those hardlinks until the output files are deleted.\n", sha256hex[:16])
 		}
 
-		if err := c.Evict(context.Background(), sha256hex); err != nil {
+		if err := c.Evict(ctx, sha256hex); err != nil {
 			return err
 		}
 		fmt.Printf("Evicted: %s\n", sha256hex)
@@ -221,6 +382,167 @@ var cacheEvictCmd = &cobra.Command{
 	},
 }
 
+var (
+	cachePinURL   string
+	cachePinDir   string
+	cachePinLabel string
+)
+
+var cachePinCmd = &cobra.Command{
+	Use:   "pin [sha256] [--url URL | --dir DIR] [--label LABEL]",
+	Short: "Protect blobs from gc, optionally under a label",
+	Long: `Pin marks blobs that gc must never evict, e.g. the inputs of a published
+analysis:
+
+  hapiq cache pin --dir results/GSE133344 --label "paper-2026 reproducibility"
+
+Select blobs by hash, by the URL they were fetched from (--url), or by the
+files in an output directory (--dir, hashed and matched against the cache).
+Pinned blobs count against max_size but gc never evicts them. Unlike the hardlink check gc
+also makes, a pin holds when outputs were copied, symlinked or written to
+another filesystem.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, _, err := openCacheForCmd()
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		ctx := context.Background()
+		hashes, err := pinTargets(ctx, c, args)
+		if err != nil {
+			return err
+		}
+		for _, h := range hashes {
+			if err := c.Pin(ctx, h, cachePinLabel); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Pinned %d blobs", len(hashes))
+		if cachePinLabel != "" {
+			fmt.Printf(" as %q", cachePinLabel)
+		}
+		fmt.Println()
+		return nil
+	},
+}
+
+var cacheUnpinCmd = &cobra.Command{
+	Use:   "unpin [sha256] [--url URL | --dir DIR] [--label LABEL]",
+	Short: "Remove pins; with --label only that pin",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, _, err := openCacheForCmd()
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		ctx := context.Background()
+		hashes, err := pinTargets(ctx, c, args)
+		if err != nil {
+			return err
+		}
+		removed := 0
+		for _, h := range hashes {
+			n, err := c.Unpin(ctx, h, cachePinLabel)
+			if err != nil {
+				return err
+			}
+			removed += n
+		}
+		fmt.Printf("Removed %d pins from %d blobs\n", removed, len(hashes))
+		return nil
+	},
+}
+
+// pinTargets resolves the blobs selected by a sha256 argument, --url or --dir.
+func pinTargets(ctx context.Context, c *cache.Cache, args []string) ([]string, error) {
+	selectors := 0
+	for _, set := range []bool{len(args) == 1, cachePinURL != "", cachePinDir != ""} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		return nil, errors.New("give exactly one of a sha256, --url or --dir")
+	}
+
+	switch {
+	case cachePinURL != "":
+		hash, _, hit, err := c.Get(ctx, cachePinURL)
+		if err != nil {
+			return nil, err
+		}
+		if !hit {
+			return nil, fmt.Errorf("%s is not in the cache", cachePinURL)
+		}
+		return []string{hash}, nil
+	case cachePinDir != "":
+		hashes, missing, err := c.BlobsUnder(ctx, cachePinDir)
+		if err != nil {
+			return nil, err
+		}
+		if missing > 0 {
+			fmt.Fprintf(os.Stderr, "warning: %d files under %s are not in the cache\n", missing, cachePinDir)
+		}
+		return hashes, nil
+	}
+	return []string{strings.ToLower(args[0])}, nil
+}
+
+// formatPinLabels renders pin labels for display; an unlabelled pin shows as
+// "unlabelled".
+func formatPinLabels(labels []string) string {
+	out := make([]string, len(labels))
+	for i, l := range labels {
+		if l == "" {
+			l = "unlabelled"
+		} else {
+			l = strconv.Quote(l)
+		}
+		out[i] = l
+	}
+	return strings.Join(out, ", ")
+}
+
+var cacheRefsCmd = &cobra.Command{
+	Use:   "refs <sha256>",
+	Short: "List the files a blob has been materialized to",
+	Long: `Refs lists every output file the cache has linked or copied a blob to,
+and whether it still exists. A blob whose files are all gone is an orphan;
+'hapiq cache gc --orphans-only' evicts those.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, _, err := openCacheForCmd()
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		refs, err := c.Refs(context.Background(), strings.ToLower(args[0]))
+		if err != nil {
+			return err
+		}
+		if len(refs) == 0 {
+			fmt.Println("No recorded references.")
+			return nil
+		}
+		live := 0
+		for _, r := range refs {
+			status := "missing"
+			if r.Live {
+				status = "ok"
+				live++
+			}
+			fmt.Printf("%-7s  %s  %s\n", status, r.Created.Format("2006-01-02 15:04"), r.Path)
+		}
+		fmt.Printf("%d references, %d live.\n", len(refs), live)
+		return nil
+	},
+}
+
 var cachePruneURLsCmd = &cobra.Command{
 	Use:   "prune-urls",
 	Short: "Remove URL index entries whose blobs are missing",
@@ -240,6 +562,66 @@ var cachePruneURLsCmd = &cobra.Command{
 	},
 }
 
+var cacheServeListen string
+
+var cacheServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve cached blobs to other hapiq instances over HTTP",
+	Long: `Start a REST server exposing this cache to peers:
+
+  GET|HEAD /v1/blob/{sha256}   blob bytes (ETag "sha256:<hex>")
+  GET      /v1/resolve?url=    {"sha256", "size"} for a cached URL
+  GET      /v1/healthz         liveness
+
+If cache.server.token is set, clients must send "Authorization: Bearer <token>".
+There is no TLS; put a reverse proxy in front if exposing beyond the LAN.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, cfg, err := openCacheForCmd()
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		listen := cfg.Server.Listen
+		if cacheServeListen != "" {
+			listen = cacheServeListen
+		}
+
+		srv := &http.Server{
+			Addr:              listen,
+			Handler:           cache.NewServer(c, cfg.Server.Token).Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- srv.ListenAndServe() }()
+
+		if !quiet {
+			auth := "no auth"
+			if cfg.Server.Token != "" {
+				auth = "bearer token required"
+			}
+			fmt.Fprintf(os.Stderr, "Serving cache %s on http://%s (%s)\n", cfg.Dir, listen, auth)
+		}
+
+		select {
+		case err := <-errCh:
+			if !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		case <-ctx.Done():
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	},
+}
+
 func init() {
 	cacheCmd.PersistentFlags().StringVar(&cacheDirFlag, "cache-dir", "", "override cache directory")
 
@@ -250,8 +632,20 @@ func init() {
 
 	cacheGCCmd.Flags().BoolVar(&cacheGCDryRun, "dry-run", false, "show what would be evicted without removing")
 	cacheGCCmd.Flags().StringVar(&cacheGCKeep, "keep", "", "spare blobs accessed within this duration (e.g. 7d, 24h)")
+	cacheGCCmd.Flags().BoolVar(&cacheGCOrphansOnly, "orphans-only", false, "only evict blobs whose materialized files are all gone")
 
-	cacheCmd.AddCommand(cacheInfoCmd, cacheListCmd, cacheVerifyCmd, cacheGCCmd, cacheEvictCmd, cachePruneURLsCmd, cacheConfigCmd)
+	cacheCompactCmd.Flags().BoolVar(&cacheCompactDryRun, "dry-run", false, "show what would be compressed without changing anything")
+	cacheCompactCmd.Flags().StringVar(&cacheCompactOlderThan, "older-than", "", "compress blobs unused for this long (e.g. 30d; default cache.compress_after or 90d)")
+
+	for _, c := range []*cobra.Command{cachePinCmd, cacheUnpinCmd} {
+		c.Flags().StringVar(&cachePinURL, "url", "", "select the blob cached for this URL")
+		c.Flags().StringVar(&cachePinDir, "dir", "", "select the cached blobs of every file under this directory")
+		c.Flags().StringVar(&cachePinLabel, "label", "", "pin label, e.g. \"paper-2026 reproducibility\"")
+	}
+
+	cacheServeCmd.Flags().StringVar(&cacheServeListen, "listen", "", "address to listen on (default cache.server.listen)")
+
+	cacheCmd.AddCommand(cacheInfoCmd, cacheListCmd, cacheVerifyCmd, cacheGCCmd, cacheCompactCmd, cacheEvictCmd, cachePinCmd, cacheUnpinCmd, cacheRefsCmd, cachePruneURLsCmd, cacheConfigCmd, cacheServeCmd)
 	rootCmd.AddCommand(cacheCmd)
 }
 
@@ -283,6 +677,30 @@ var cacheConfigCmd = &cobra.Command{
 		}
 		fmt.Printf("cache.min_free_disk: %s\n", common.FormatBytes(cfg.MinFreeDisk))
 		fmt.Printf("cache.quota_policy:  %s\n", cfg.QuotaPolicy)
+		if cfg.TTL > 0 {
+			fmt.Printf("cache.ttl:           %s\n", cfg.TTL)
+		}
+		if cfg.CompressAfter > 0 {
+			fmt.Printf("cache.compress_after: %s\n", cfg.CompressAfter)
+		}
+		for i, t := range cfg.Tiers {
+			mode := "writable"
+			if t.ReadOnly {
+				mode = "read-only"
+			}
+			fmt.Printf("cache.tiers[%d]:      %s (%s)\n", i, t.Dir, mode)
+		}
+		fmt.Printf("cache.server.listen: %s\n", cfg.Server.Listen)
+		if cfg.Server.Token != "" {
+			fmt.Printf("cache.server.token:  (set)\n")
+		} else {
+			fmt.Printf("cache.server.token:  (none)\n")
+		}
+		if len(cfg.Server.Peers) > 0 {
+			fmt.Printf("cache.server.peers:  %s\n", strings.Join(cfg.Server.Peers, ", "))
+		} else {
+			fmt.Printf("cache.server.peers:  (none)\n")
+		}
 		return nil
 	},
 }
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/btraven00/hapiq/pkg/cache"
+	"github.com/btraven00/hapiq/pkg/downloaders"
+	"github.com/btraven00/hapiq/pkg/downloaders/common"
+	"github.com/btraven00/hapiq/pkg/manifest"
+)
+
+var (
+	cacheImportCopy   bool
+	cacheImportDryRun bool
+)
+
+var cacheImportCmd = &cobra.Command{
+	Use:   "import <dir>...",
+	Short: "Seed the cache from existing downloads and their hapiq.json files",
+	Long: `Import walks each directory for hapiq.json witness files and adds every
+file they record to the cache under its source URL, so downloading those URLs
+again is an instant cache hit.
+
+Each file is checked against the checksum in its witness first; files
+without one, or that no longer match, are skipped. Files are reflinked into
+the store where the filesystem supports it, otherwise hardlinked, so an
+import takes no extra space. Files on another filesystem than the cache
+are skipped unless --copy is given.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, _, err := openCacheForCmd()
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		ctx := context.Background()
+		var st importStats
+		for _, root := range args {
+			err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() || d.Name() != "hapiq.json" {
+					return nil
+				}
+				importWitness(ctx, c, path, &st)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if cacheImportDryRun {
+			fmt.Printf("Dry-run: %d files from %d witness files verified (%s)",
+				st.imported, st.witnesses, common.FormatBytes(st.bytes))
+		} else {
+			fmt.Printf("Imported %d files from %d witness files (%d new blobs, %s)",
+				st.imported, st.witnesses, st.added, common.FormatBytes(st.bytes))
+		}
+		if st.skipped > 0 {
+			fmt.Printf("; %d skipped", st.skipped)
+		}
+		fmt.Println()
+		return nil
+	},
+}
+
+type importStats struct {
+	witnesses, imported, added, skipped int
+	bytes                               int64
+}
+
+// importWitness imports the files recorded in one hapiq.json, reporting
+// files it skips on stderr.
+func importWitness(ctx context.Context, c *cache.Cache, witnessPath string, st *importStats) {
+	data, err := os.ReadFile(filepath.Clean(witnessPath)) // #nosec G304 -- user-specified directory
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "skip %s: %v\n", witnessPath, err)
+		return
+	}
+	var w downloaders.WitnessFile
+	if err := json.Unmarshal(data, &w); err != nil {
+		fmt.Fprintf(os.Stderr, "skip %s: parse witness: %v\n", witnessPath, err)
+		return
+	}
+	st.witnesses++
+
+	dir := filepath.Dir(witnessPath)
+	anchor := witnessAnchor(dir, w.Files)
+	for _, f := range w.Files {
+		path, found := resolveWitnessPath(dir, f.Path, anchor)
+		if found && inNestedWitness(dir, path) {
+			// Imported with the subdirectory's own witness.
+			continue
+		}
+		if err := importFile(ctx, c, path, found, f, st); err != nil {
+			fmt.Fprintf(os.Stderr, "skip %s: %v\n", path, err)
+			st.skipped++
+		}
+	}
+}
+
+func importFile(ctx context.Context, c *cache.Cache, path string, found bool, f downloaders.FileWitness, st *importStats) error {
+	if f.SourceURL == "" {
+		return errors.New("witness records no source_url")
+	}
+	if !found {
+		return errors.New("file not found next to its witness")
+	}
+	spec := checksumSpec(f)
+	if spec == "" {
+		return errors.New("witness records no checksum to verify")
+	}
+	sum, ok := common.ParseChecksum(spec)
+	if !ok {
+		return fmt.Errorf("unsupported checksum %q", spec)
+	}
+
+	// sha256 is checked by Import while hashing; other digests up front.
+	var want string
+	if sum.Type == "sha256" && !cacheImportDryRun {
+		want = sum.Value
+	} else if err := manifest.VerifyFile(path, spec); err != nil {
+		return err
+	}
+
+	if cacheImportDryRun {
+		st.imported++
+		st.bytes += fileSize(path)
+		return nil
+	}
+	hash, added, err := c.Import(ctx, f.SourceURL, path, want, cacheImportCopy)
+	if errors.Is(err, cache.ErrImportNeedsCopy) {
+		return fmt.Errorf("%w; rerun with --copy", err)
+	} else if err != nil {
+		return err
+	}
+	_ = c.AddDigest(ctx, hash, sum.Type, sum.Value)
+	if f.OriginalName != "" {
+		_ = c.RecordFilename(ctx, f.SourceURL, f.OriginalName)
+	}
+	st.imported++
+	if added {
+		st.added++
+		st.bytes += fileSize(path)
+	}
+	return nil
+}
+
+// inNestedWitness reports whether path lies in a subdirectory of dir that
+// has its own hapiq.json, i.e. belongs to a separate download (see
+// verifyTree).
+func inNestedWitness(dir, path string) bool {
+	rel, err := filepath.Rel(dir, filepath.Dir(path))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	sub := dir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		sub = filepath.Join(sub, part)
+		if fileExists(filepath.Join(sub, "hapiq.json")) {
+			return true
+		}
+	}
+	return false
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func init() {
+	cacheImportCmd.Flags().BoolVar(&cacheImportCopy, "copy", false, "copy files that cannot be reflinked or hardlinked (e.g. on another filesystem)")
+	cacheImportCmd.Flags().BoolVar(&cacheImportDryRun, "dry-run", false, "verify and count files without importing them")
+	cacheCmd.AddCommand(cacheImportCmd)
+}
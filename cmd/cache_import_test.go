@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btraven00/hapiq/pkg/cache"
+	"github.com/btraven00/hapiq/pkg/downloaders"
+	"github.com/btraven00/hapiq/pkg/downloaders/common"
+)
+
+// TestImportWitness checks that files are located the way verify locates
+// them and that every checksum form a witness may record is accepted.
+func TestImportWitness(t *testing.T) {
+	c, err := cache.Open(cache.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	sum := sha256.Sum256([]byte("bravo"))
+	files := []downloaders.FileWitness{
+		// Already in "algo:hex" form, with the type repeated.
+		{Path: write("run1/a.txt", "alpha"), SourceURL: "https://example.org/a", Checksum: "md5:" + md5Hex([]byte("alpha")), ChecksumType: "md5"},
+		// No type: sha256, as verify assumes.
+		{Path: write("run1/b.txt", "bravo"), SourceURL: "https://example.org/b", Checksum: hex.EncodeToString(sum[:])},
+		// Deleted; the same-named file at the top must not stand in for it.
+		{Path: write("run2/a.txt", "alpha"), SourceURL: "https://example.org/gone", Checksum: md5Hex([]byte("alpha")), ChecksumType: "md5"},
+		// Belongs to the nested witness below.
+		{Path: write("v2/c.txt", "charlie"), SourceURL: "https://example.org/c", Checksum: md5Hex([]byte("charlie")), ChecksumType: "md5"},
+	}
+	write("a.txt", "alpha")
+	if err := common.WriteWitnessFile(dir, &downloaders.WitnessFile{Source: "zenodo", OriginalID: "123", Files: files}); err != nil {
+		t.Fatal(err)
+	}
+	if err := common.WriteWitnessFile(filepath.Join(dir, "v2"), &downloaders.WitnessFile{Source: "zenodo", OriginalID: "124", Files: files[3:]}); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Remove(filepath.Join(dir, "run2", "a.txt"))
+
+	var st importStats
+	importWitness(ctx, c, filepath.Join(dir, "hapiq.json"), &st)
+	if st.imported != 2 || st.skipped != 1 {
+		t.Errorf("imported %d, skipped %d; want 2 and 1", st.imported, st.skipped)
+	}
+	for url, want := range map[string]bool{
+		"https://example.org/a":    true,
+		"https://example.org/b":    true,
+		"https://example.org/gone": false,
+		"https://example.org/c":    false,
+	} {
+		if _, hit, err := c.Lookup(ctx, url); err != nil || hit != want {
+			t.Errorf("Lookup(%s) = %v, %v; want %v", url, hit, err, want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -20,11 +21,13 @@ import (
 	"github.com/btraven00/hapiq/pkg/cache"
 	"github.com/btraven00/hapiq/pkg/downloaders"
 	"github.com/btraven00/hapiq/pkg/downloaders/biostudies"
+	"github.com/btraven00/hapiq/pkg/downloaders/cellxgene"
 	"github.com/btraven00/hapiq/pkg/downloaders/common"
 	"github.com/btraven00/hapiq/pkg/downloaders/ensembl"
 	"github.com/btraven00/hapiq/pkg/downloaders/experimenthub"
 	"github.com/btraven00/hapiq/pkg/downloaders/figshare"
 	"github.com/btraven00/hapiq/pkg/downloaders/geo"
+	"github.com/btraven00/hapiq/pkg/downloaders/gsa"
 	"github.com/btraven00/hapiq/pkg/downloaders/hca"
 	"github.com/btraven00/hapiq/pkg/downloaders/scanpy"
 	"github.com/btraven00/hapiq/pkg/downloaders/scperturb"
@@ -57,11 +60,12 @@ var (
 	includeSRA           bool
 	expectedHash         string
 	forceOverwrite       bool
+	datasetVersion       string
 )
 
 // downloadCmd represents the download command.
 var downloadCmd = &cobra.Command{
-	Use:   "download <source> <id>",
+	Use:   "download [source] <id>",
 	Short: "Download datasets from scientific data repositories",
 	Long: `Download datasets from various scientific data repositories with comprehensive
 metadata tracking and provenance information.
@@ -69,6 +73,7 @@ metadata tracking and provenance information.
 Supported sources:
   geo         - NCBI Gene Expression Omnibus (GSE, GSM, GPL, GDS)
   sra         - Raw FASTQ via ENA HTTPS mirror (PRJNA, SRR, ERR, DRR, SRX)
+  gsa         - NGDC Genome Sequence Archive (PRJCA, CRA, CRX, CRR)
   figshare    - Figshare articles, collections, and projects
   zenodo      - Zenodo research data repository (DOIs, record IDs)
   ensembl     - Ensembl Genomes databases (bacteria, fungi, metazoa, plants, protists)
@@ -77,10 +82,19 @@ Supported sources:
   experimenthub - Bioconductor ExperimentHub (EH<digits>)
   biostudies  - EBI BioStudies (S-<COLL><digits>, E-<TYPE>-<digits>)
   hca         - Human Cell Atlas Data Portal (project UUID)
+  cellxgene   - CZ CELLxGENE Discover (collection or dataset UUID)
   scanpy      - Curated scanpy.datasets entries (e.g. pbmc3k, paul15, visium_sge/<sample_id>, ebi_expression_atlas/<accession>)
   url         - Direct HTTP/HTTPS download (URL is the ID)
 
+When the source is omitted it is detected from the ID. Accessions such as
+GSE133344 or SRR000001 go to the source serving that database; otherwise
+each source is asked whether it accepts the ID, and a direct URL is only
+used when no repository claims it. If several sources accept the ID the
+candidates are listed and the source must be given explicitly.
+
 Examples:
+  hapiq download GSE123456 --out ./datasets
+  hapiq download 10.5281/zenodo.123456 --out ./data
   hapiq download geo GSE123456 --out ./datasets
   hapiq download geo GSE123456 --out ./data --parallel 4
   hapiq download figshare 12345678 --out ./datasets
@@ -88,19 +102,24 @@ Examples:
   hapiq download ensembl bacteria:47:pep --out ./datasets
   hapiq download ensembl fungi:47:gff3:saccharomyces_cerevisiae --out ./data
   hapiq download zenodo 10.5281/zenodo.123456 --out ./data --quiet
+  hapiq download zenodo 123455 --version all --out ./data
   hapiq download biostudies S-BSST1502 --out ./data
   hapiq download biostudies E-MTAB-8077 --out ./data --include-ext .mtx,.h5,.h5ad
   hapiq download hca cc95ff89-2e68-4a08-a234-480eca21ce79 --out ./data --include-ext .h5,.h5ad
   hapiq download url https://example.com/data.h5ad --out ./data
   hapiq download scanpy pbmc3k --out ./data
   hapiq download scanpy visium_sge/V1_Human_Heart --out ./data`,
-	Args: cobra.ExactArgs(requiredArgsCount),
+	Args: cobra.RangeArgs(1, requiredArgsCount),
 	RunE: runDownload,
 }
 
 func runDownload(_ *cobra.Command, args []string) error {
-	sourceType := args[0]
-	id := args[1]
+	var sourceType, id string
+	if len(args) == requiredArgsCount {
+		sourceType, id = args[0], args[1]
+	} else {
+		id = args[0]
+	}
 
 	if err := validateAndPrepareDownload(); err != nil {
 		return err
@@ -122,10 +141,26 @@ func runDownload(_ *cobra.Command, args []string) error {
 		}
 	}
 
+	var validationResult *downloaders.ValidationResult
+	if sourceType == "" {
+		var err error
+		sourceType, validationResult, err = detectSource(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+
 	printDownloadInfo(sourceType, id)
 
-	validationResult, err := validateSourceAndID(ctx, sourceType, id)
-	if err != nil {
+	if validationResult == nil {
+		var err error
+		validationResult, err = validateSourceAndID(ctx, sourceType, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := checkVersionSupported(sourceType); err != nil {
 		return err
 	}
 
@@ -201,6 +236,32 @@ func printDownloadInfo(sourceType, id string) {
 	_, _ = fmt.Fprintf(os.Stderr, "Timeout: %ds\n", downloadTimeout)
 }
 
+// detectSource picks the source for a bare ID via downloaders.AutoDetect.
+// When several sources accept the ID the candidates are printed so the user
+// can re-run with one of them.
+func detectSource(ctx context.Context, id string) (string, *downloaders.ValidationResult, error) {
+	sourceType, result, err := downloaders.AutoDetect(ctx, id)
+	if err != nil {
+		var ambiguous *downloaders.AmbiguousIDError
+		if errors.As(err, &ambiguous) {
+			_, _ = fmt.Fprintf(os.Stderr, "❓ '%s' is ambiguous; it is accepted by:\n", id)
+			for _, c := range ambiguous.Candidates {
+				_, _ = fmt.Fprintf(os.Stderr, "   hapiq download %s %s\n", c, id)
+			}
+		}
+		return "", nil, err
+	}
+
+	if !quiet {
+		_, _ = fmt.Fprintf(os.Stderr, "🔎 Detected source: %s\n", sourceType)
+	}
+	for _, warning := range result.Warnings {
+		_, _ = fmt.Fprintf(os.Stderr, "⚠️  %s\n", warning)
+	}
+
+	return sourceType, result, nil
+}
+
 func validateSourceAndID(ctx context.Context, sourceType, id string) (*downloaders.ValidationResult, error) {
 	validationResult, err := downloaders.Validate(ctx, sourceType, id)
 	if err != nil {
@@ -283,6 +344,22 @@ func displayMetadataSummary(metadata *downloaders.Metadata) {
 	}
 }
 
+// checkVersionSupported rejects --version for sources that cannot select a
+// dataset version; only Zenodo reads it.
+func checkVersionSupported(sourceType string) error {
+	if datasetVersion == "" {
+		return nil
+	}
+	d, err := downloaders.Get(sourceType)
+	if err != nil {
+		return err
+	}
+	if d.GetSourceType() != "zenodo" {
+		return fmt.Errorf("--version is only supported for Zenodo downloads, not %s", d.GetSourceType())
+	}
+	return nil
+}
+
 func createDownloadRequest(
 	validationResult *downloaders.ValidationResult,
 	metadata *downloaders.Metadata,
@@ -307,6 +384,7 @@ func createDownloadRequest(
 		DryRun:               dryRun,
 		LimitFiles:           limitFiles,
 		IncludeSRA:           includeSRA,
+		Version:              datasetVersion,
 	}
 
 	return &downloaders.DownloadRequest{
@@ -478,8 +556,9 @@ func displayFileList(result *downloaders.DownloadResult) {
 
 // initializeDownloaders registers all available downloaders.
 func initializeDownloaders() error {
-	// Get NCBI API key from environment variable if available
-	apiKey := os.Getenv("NCBI_API_KEY")
+	// Credentials for every source come from one place: env, config,
+	// the `hapiq auth` credentials file and .netrc (see pkg/auth).
+	apiKey := credentialFor("geo")
 
 	// Register GEO downloader
 	geoOptions := []geo.Option{
@@ -494,7 +573,7 @@ func initializeDownloaders() error {
 
 		geoOptions = append(geoOptions, geo.WithAPIKey(apiKey))
 	} else if !quiet {
-		_, _ = fmt.Fprintf(os.Stderr, "No NCBI API key found. Set NCBI_API_KEY environment variable for higher rate limits\n")
+		_, _ = fmt.Fprintf(os.Stderr, "No NCBI API key found. Set NCBI_API_KEY or run 'hapiq auth set geo' for higher rate limits\n")
 	}
 
 	geoDownloader := geo.NewGEODownloader(geoOptions...)
@@ -506,6 +585,7 @@ func initializeDownloaders() error {
 	figshareDownloader := figshare.NewFigshareDownloader(
 		figshare.WithVerbose(!quiet),
 		figshare.WithTimeout(time.Duration(downloadTimeout)*time.Second),
+		figshare.WithToken(credentialFor("figshare")),
 	)
 	if err := downloaders.Register(figshareDownloader); err != nil {
 		return fmt.Errorf("failed to register Figshare downloader: %w", err)
@@ -515,6 +595,7 @@ func initializeDownloaders() error {
 	zenodoDownloader := zenodo.NewZenodoDownloader(
 		zenodo.WithVerbose(!quiet),
 		zenodo.WithTimeout(time.Duration(downloadTimeout)*time.Second),
+		zenodo.WithAccessToken(credentialFor("zenodo")),
 	)
 	if err := downloaders.Register(zenodoDownloader); err != nil {
 		return fmt.Errorf("failed to register Zenodo downloader: %w", err)
@@ -537,12 +618,20 @@ func initializeDownloaders() error {
 		return fmt.Errorf("failed to register SRA downloader: %w", err)
 	}
 
+	// Register GSA downloader (NGDC Genome Sequence Archive)
+	gsaDownloader := gsa.NewGSADownloader(
+		gsa.WithVerbose(!quiet),
+		gsa.WithTimeout(time.Duration(downloadTimeout)*time.Second),
+	)
+	if err := downloaders.Register(gsaDownloader); err != nil {
+		return fmt.Errorf("failed to register GSA downloader: %w", err)
+	}
+
 	// Register CZI downloader (Virtual Cell Platform)
-	vcpToken := os.Getenv("VCP_TOKEN")
 	cziDownloader := vcp.NewVCPDownloader(
 		vcp.WithVerbose(!quiet),
 		vcp.WithTimeout(time.Duration(downloadTimeout)*time.Second),
-		vcp.WithToken(vcpToken),
+		vcp.WithToken(credentialFor("vcp")),
 	)
 	if err := downloaders.Register(cziDownloader); err != nil {
 		return fmt.Errorf("failed to register CZI downloader: %w", err)
@@ -557,6 +646,15 @@ func initializeDownloaders() error {
 		return fmt.Errorf("failed to register HCA downloader: %w", err)
 	}
 
+	// Register CELLxGENE downloader (CZ CELLxGENE Discover)
+	cxgDownloader := cellxgene.NewCellxgeneDownloader(
+		cellxgene.WithVerbose(!quiet),
+		cellxgene.WithTimeout(time.Duration(downloadTimeout)*time.Second),
+	)
+	if err := downloaders.Register(cxgDownloader); err != nil {
+		return fmt.Errorf("failed to register CELLxGENE downloader: %w", err)
+	}
+
 	// Register BioStudies downloader (EBI BioStudies)
 	bsDownloader := biostudies.NewBioStudiesDownloader(
 		biostudies.WithVerbose(!quiet),
@@ -598,7 +696,7 @@ func initializeDownloaders() error {
 		urldownloader.WithVerbose(!quiet),
 		urldownloader.WithTimeout(time.Duration(downloadTimeout)*time.Second),
 	)
-	if err := downloaders.Register(urlDL); err != nil {
+	if err := downloaders.RegisterFallback(urlDL); err != nil {
 		return fmt.Errorf("failed to register URL downloader: %w", err)
 	}
 
@@ -785,6 +883,8 @@ func init() {
 		"stop after downloading this many files — useful for testing (0 = no limit)")
 	downloadCmd.Flags().BoolVar(&includeSRA, "raw", false,
 		"also download raw FASTQ files via ENA/SRA (prompts for confirmation, use -y to skip)")
+	downloadCmd.Flags().StringVar(&datasetVersion, "version", "",
+		"dataset version to download: latest (default), a version or record ID, or 'all' into per-version subdirectories (Zenodo)")
 
 	// Legacy custom filters flag (kept for backward compatibility)
 	downloadCmd.Flags().StringToStringVar(&customFilters, "filter", map[string]string{},
@@ -31,7 +31,11 @@ extraction and download capabilities with provenance tracking.
 Examples:
   hapiq downloaders                       # List all available downloaders
   hapiq downloaders --verbose             # Show detailed information
-  hapiq downloaders --output json         # Output as JSON`,
+  hapiq downloaders --output json         # Output as JSON
+
+Sources marked "auth" accept credentials (tokens for restricted records, or
+an API key for higher rate limits). See 'hapiq auth --help' for where they
+are read from.`,
 	RunE: runDownloaders,
 }
 
@@ -77,6 +81,12 @@ func listAvailableDownloaders() error {
 		if len(aliases) > 0 {
 			fmt.Printf(" (aliases: %s)", strings.Join(aliases, ", "))
 		}
+		switch downloaderAuthStatus(sourceType) {
+		case "configured":
+			fmt.Print(" 🔑 auth: configured")
+		case "supported":
+			fmt.Print(" 🔑 auth: supported")
+		}
 		fmt.Println()
 
 		if downloadersVerbose {
@@ -113,6 +123,7 @@ func outputDownloadersJSON() error {
 		Aliases     []string `json:"aliases,omitempty"`
 		Description string   `json:"description,omitempty"`
 		Examples    []string `json:"examples,omitempty"`
+		Auth        string   `json:"auth,omitempty"`
 	}
 
 	var result struct {
@@ -132,6 +143,7 @@ func outputDownloadersJSON() error {
 			Aliases:     aliasMap[sourceType],
 			Description: getDownloaderDescription(sourceType),
 			Examples:    getDownloaderExamples(sourceType),
+			Auth:        downloaderAuthStatus(sourceType),
 		}
 		result.Downloaders = append(result.Downloaders, info)
 	}
@@ -141,6 +153,15 @@ func outputDownloadersJSON() error {
 	return encoder.Encode(result)
 }
 
+// downloaderAuthStatus returns downloaders.AuthStatus for a registered source.
+func downloaderAuthStatus(sourceType string) string {
+	d, err := downloaders.Get(sourceType)
+	if err != nil {
+		return ""
+	}
+	return downloaders.AuthStatus(d)
+}
+
 // getDownloaderDescription returns a description for the given downloader type.
 func getDownloaderDescription(sourceType string) string {
 	descriptions := map[string]string{
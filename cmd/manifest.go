@@ -140,12 +140,23 @@ func runEntry(ctx context.Context, parent string, e *manifest.Entry) error {
 		return err
 	}
 
-	vr, err := downloaders.Validate(ctx, source, id)
-	if err != nil {
-		return fmt.Errorf("validate: %w", err)
-	}
-	if !vr.Valid {
-		return fmt.Errorf("invalid accession")
+	var vr *downloaders.ValidationResult
+	if source == "" {
+		source, vr, err = downloaders.AutoDetect(ctx, id)
+		if err != nil {
+			return fmt.Errorf("detect source: %w", err)
+		}
+		if !quiet {
+			_, _ = fmt.Fprintf(os.Stderr, "🔎 %s: detected source %s\n", e.Identifier, source)
+		}
+	} else {
+		vr, err = downloaders.Validate(ctx, source, id)
+		if err != nil {
+			return fmt.Errorf("validate: %w", err)
+		}
+		if !vr.Valid {
+			return fmt.Errorf("invalid accession")
+		}
 	}
 
 	meta, err := downloaders.GetMetadata(ctx, source, vr.ID)
@@ -190,6 +201,7 @@ func buildEntryOptions(o *manifest.Options) (*downloaders.DownloadOptions, error
 	out.IncludeRaw = !o.ExcludeRaw
 	out.IncludeSRA = o.IncludeSRA
 	out.LimitFiles = o.LimitFiles
+	out.Version = o.Version
 	if o.MaxFileSize != "" {
 		n, err := parseSize(o.MaxFileSize)
 		if err != nil {
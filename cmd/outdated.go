@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/btraven00/hapiq/pkg/downloaders"
+	"github.com/btraven00/hapiq/pkg/downloaders/common"
+)
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated <dir>",
+	Short: "Check whether the datasets in a directory have changed upstream",
+	Long: `Outdated reads <dir>/hapiq.json and fetches fresh metadata for every dataset
+recorded there (the main download and any further datasets downloaded into the
+same directory). It reports what changed upstream since the download: version,
+modification date, ETag, file counts and, where the source lists files in its
+metadata (Zenodo, figshare), added, removed and changed files. A newer Zenodo
+version of the same record is reported too.
+
+Nothing is downloaded. The exit status is nonzero when any dataset changed or
+could not be checked. Use -o json for a machine-readable report.
+
+Examples:
+  hapiq outdated ./GSE123456
+  hapiq outdated ./zenodo-123456 -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runOutdated,
+}
+
+// outdatedEntry is the check result for one recorded dataset.
+type outdatedEntry struct {
+	Source     string                    `json:"source"`
+	ID         string                    `json:"id"`
+	Downloaded time.Time                 `json:"downloaded"`
+	Diff       *downloaders.MetadataDiff `json:"diff,omitempty"`
+	Error      string                    `json:"error,omitempty"`
+	Outdated   bool                      `json:"outdated"`
+}
+
+func runOutdated(_ *cobra.Command, args []string) error {
+	witness, err := common.LoadWitnessFile(args[0])
+	if err != nil {
+		return err
+	}
+	if err := initializeDownloaders(); err != nil {
+		return err
+	}
+
+	entries := checkOutdated(context.Background(), witness, downloaders.CheckMetadata)
+
+	if output == outputFormatJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			return err
+		}
+	} else {
+		printOutdated(entries)
+	}
+
+	failed := 0
+	for _, e := range entries {
+		if e.Outdated || e.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d dataset(s) changed upstream or could not be checked", failed, len(entries))
+	}
+	return nil
+}
+
+// metadataFunc fetches current metadata for a source and ID; it is
+// downloaders.CheckMetadata outside of tests.
+type metadataFunc func(ctx context.Context, source, id string) (*downloaders.Metadata, error)
+
+// checkOutdated re-fetches metadata for every dataset recorded in w and
+// diffs it against what was recorded at download time.
+func checkOutdated(ctx context.Context, w *downloaders.WitnessFile, getMetadata metadataFunc) []outdatedEntry {
+	records := append([]downloaders.DatasetRecord{{
+		Source: w.Source, OriginalID: w.OriginalID, Metadata: w.Metadata, DownloadTime: w.DownloadTime,
+	}}, w.Datasets...)
+
+	entries := make([]outdatedEntry, 0, len(records))
+	for _, r := range records {
+		e := outdatedEntry{Source: r.Source, ID: r.OriginalID, Downloaded: r.DownloadTime}
+
+		cctx, cancel := context.WithTimeout(ctx, defaultCheckTimeoutSec*time.Second)
+		cur, err := getMetadata(cctx, r.Source, r.OriginalID)
+		cancel()
+
+		switch {
+		case err != nil:
+			e.Error = err.Error()
+		case r.Metadata == nil:
+			e.Error = "no metadata recorded at download time"
+		default:
+			d := downloaders.DiffMetadata(r.Metadata, cur)
+			e.Diff, e.Outdated = &d, !d.Empty()
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func printOutdated(entries []outdatedEntry) {
+	for _, e := range entries {
+		name := e.Source + ":" + e.ID
+		switch {
+		case e.Error != "":
+			fmt.Printf("❓ %s: could not check: %s\n", name, e.Error)
+			continue
+		case !e.Outdated:
+			fmt.Printf("✅ %s: up to date\n", name)
+			continue
+		}
+
+		fmt.Printf("⚠️  %s: changed upstream since %s\n", name, e.Downloaded.Format("2006-01-02"))
+		d := e.Diff
+		if d.LatestVersion != "" {
+			fmt.Printf("   newer version available: %s\n", d.LatestVersion)
+		}
+		for _, f := range d.Fields {
+			fmt.Printf("   %s: %s → %s\n", f.Field, f.Old, f.New)
+		}
+		printFileList("+", d.FilesAdded)
+		printFileList("-", d.FilesRemoved)
+		printFileList("~", d.FilesChanged)
+	}
+}
+
+func printFileList(mark string, names []string) {
+	if len(names) > 0 {
+		fmt.Printf("   %s %s\n", mark, strings.Join(names, "\n   "+mark+" "))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(outdatedCmd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/btraven00/hapiq/pkg/downloaders"
+)
+
+func TestCheckOutdated(t *testing.T) {
+	w := &downloaders.WitnessFile{
+		Source: "zenodo", OriginalID: "1",
+		Metadata: &downloaders.Metadata{ID: "1", Version: "v1"},
+		Datasets: []downloaders.DatasetRecord{
+			{Source: "figshare", OriginalID: "2", Metadata: &downloaders.Metadata{ID: "2", Version: "3"}},
+			{Source: "geo", OriginalID: "GSE3", Metadata: &downloaders.Metadata{ID: "GSE3"}},
+		},
+	}
+	current := map[string]*downloaders.Metadata{
+		"zenodo:1":   {ID: "1", Version: "v1"},
+		"figshare:2": {ID: "2", Version: "4"},
+	}
+	get := func(_ context.Context, source, id string) (*downloaders.Metadata, error) {
+		if m, ok := current[source+":"+id]; ok {
+			return m, nil
+		}
+		return nil, errors.New("not found")
+	}
+
+	entries := checkOutdated(context.Background(), w, get)
+	if len(entries) != 3 {
+		t.Fatalf("entries = %d, want 3", len(entries))
+	}
+	if entries[0].Outdated || entries[0].Error != "" {
+		t.Errorf("zenodo entry = %+v, want up to date", entries[0])
+	}
+	if !entries[1].Outdated || entries[1].Diff.Fields[0].Field != "version" {
+		t.Errorf("figshare entry = %+v, want version change", entries[1])
+	}
+	if entries[2].Error == "" {
+		t.Errorf("geo entry = %+v, want error", entries[2])
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/btraven00/hapiq/pkg/cache"
+	"github.com/btraven00/hapiq/pkg/downloaders/common"
 	"github.com/btraven00/hapiq/pkg/downloaders/experimenthub"
 )
 
@@ -56,7 +57,9 @@ func init() {
 //  4. /etc/hapiq/config.toml
 func initConfig() {
 	cache.RegisterDefaults()
+	common.RegisterDefaults()
 	experimenthub.RegisterDefaults()
+	common.InstallRateLimiter()
 
 	viper.AutomaticEnv()
 
@@ -12,6 +12,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/btraven00/hapiq/pkg/downloaders"
+	"github.com/btraven00/hapiq/pkg/downloaders/biostudies"
+	"github.com/btraven00/hapiq/pkg/downloaders/cellxgene"
 	"github.com/btraven00/hapiq/pkg/downloaders/common"
 	"github.com/btraven00/hapiq/pkg/downloaders/experimenthub"
 	"github.com/btraven00/hapiq/pkg/downloaders/geo"
@@ -20,9 +22,10 @@ import (
 )
 
 var (
-	searchLimit    int
-	searchOrganism string
-	searchType     string
+	searchLimit      int
+	searchOrganism   string
+	searchType       string
+	searchCollection string
 )
 
 var searchCmd = &cobra.Command{
@@ -40,13 +43,19 @@ Supported sources:
   geo  - NCBI Gene Expression Omnibus (uses eutils esearch/esummary)
   vcp  - CZI Virtual Cell Platform (VCP); set VCP_TOKEN for private datasets
   experimenthub - Bioconductor ExperimentHub (uses cached metadata sqlite)
+  cellxgene - CZ CELLxGENE Discover collections (filtered locally; --type matches assay)
+  biostudies - EBI BioStudies; --collection arrayexpress for ArrayExpress
+               (alias: arrayexpress), --type filters by study type
 
 Examples:
   hapiq search geo "ATAC-seq human liver" --limit 20
   hapiq search geo "scRNA-seq pancreas" --organism "Mus musculus"
   hapiq search vcp "Perturb-Seq" --limit 10
   hapiq search vcp "Perturb-Seq" --assay "Perturb-Seq" --organism "Homo sapiens"
-  hapiq search vcp "Perturb-Seq" -q | xargs -I{} hapiq download vcp {} --out ./data`,
+  hapiq search vcp "Perturb-Seq" -q | xargs -I{} hapiq download vcp {} --out ./data
+  hapiq search cellxgene "lung" --organism "Homo sapiens" --type "10x 3' v3"
+  hapiq search arrayexpress "liver" --organism "Homo sapiens" --type "RNA-seq of coding RNA"
+  hapiq search biostudies "pancreas" -q | xargs -I{} hapiq download biostudies {} --out ./data`,
 	Args: cobra.ExactArgs(2),
 	RunE: runSearch,
 }
@@ -66,7 +75,7 @@ func runSearch(_ *cobra.Command, args []string) error {
 		if searchType == "" {
 			searchType = "GSE"
 		}
-		apiKey := os.Getenv("NCBI_API_KEY")
+		apiKey := credentialFor("geo")
 		geoOpts := []geo.Option{
 			geo.WithVerbose(false),
 			geo.WithTimeout(time.Duration(defaultCheckTimeoutSec) * time.Second),
@@ -81,7 +90,7 @@ func runSearch(_ *cobra.Command, args []string) error {
 			vcp.WithVerbose(false),
 			vcp.WithTimeout(time.Duration(defaultCheckTimeoutSec) * time.Second),
 		}
-		if token := os.Getenv("VCP_TOKEN"); token != "" {
+		if token := credentialFor("vcp"); token != "" {
 			cziOpts = append(cziOpts, vcp.WithToken(token))
 		}
 		d = vcp.NewVCPDownloader(cziOpts...)
@@ -98,17 +107,34 @@ func runSearch(_ *cobra.Command, args []string) error {
 			experimenthub.WithTimeout(time.Duration(defaultCheckTimeoutSec) * time.Second),
 		)
 
+	case "cellxgene":
+		d = cellxgene.NewCellxgeneDownloader(
+			cellxgene.WithVerbose(false),
+			cellxgene.WithTimeout(time.Duration(defaultCheckTimeoutSec) * time.Second),
+		)
+
+	case "biostudies", "arrayexpress":
+		if src == "arrayexpress" && searchCollection == "" {
+			searchCollection = "arrayexpress"
+		}
+		src = "biostudies"
+		d = biostudies.NewBioStudiesDownloader(
+			biostudies.WithVerbose(false),
+			biostudies.WithTimeout(time.Duration(defaultCheckTimeoutSec) * time.Second),
+		)
+
 	default:
-		return fmt.Errorf("search is supported for 'geo', 'vcp', 'scperturb', 'experimenthub'; got %q", sourceType)
+		return fmt.Errorf("search is supported for 'geo', 'vcp', 'scperturb', 'experimenthub', 'cellxgene', 'biostudies'; got %q", sourceType)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
 	opts := downloaders.SearchOptions{
-		Organism:  searchOrganism,
-		EntryType: searchType,
-		Limit:     searchLimit,
+		Organism:   searchOrganism,
+		EntryType:  searchType,
+		Collection: searchCollection,
+		Limit:      searchLimit,
 	}
 
 	if !quiet {
@@ -119,6 +145,9 @@ func runSearch(_ *cobra.Command, args []string) error {
 		if searchType != "" {
 			_, _ = fmt.Fprintf(os.Stderr, "  Type/assay filter: %s\n", searchType)
 		}
+		if searchCollection != "" {
+			_, _ = fmt.Fprintf(os.Stderr, "  Collection: %s\n", searchCollection)
+		}
 	}
 
 	results, err := d.Search(ctx, query, opts)
@@ -153,11 +182,23 @@ func printSearchQuiet(results []downloaders.SearchResult) error {
 // printSearchTable prints a formatted table to stderr and accessions to stdout.
 // Column layout differs by source:
 //   - geo: ACCESSION  TITLE  ORGANISM  TYPE  SAMPLES  DATE
-//   - vcp: ACCESSION  TITLE  ORGANISM  ASSAY  SIZE
+//   - vcp, scperturb, cellxgene: ACCESSION  TITLE  ORGANISM  ASSAY  SIZE
+//   - biostudies: ACCESSION  TITLE  TYPE  FILES  RELEASED
 func printSearchTable(results []downloaders.SearchResult, src string) error {
 	w := tabwriter.NewWriter(os.Stderr, 0, 0, tabWriterPadding, ' ', 0)
 
-	if src == "vcp" || src == "scperturb" {
+	if src == "biostudies" {
+		_, _ = fmt.Fprintln(w, "ACCESSION\tTITLE\tTYPE\tFILES\tRELEASED")
+		_, _ = fmt.Fprintln(w, "---------\t-----\t----\t-----\t--------")
+		for _, r := range results {
+			title := r.Title
+			if len(title) > maxDescriptionChars {
+				title = title[:maxDescriptionChars-truncationSuffix] + "..."
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n",
+				r.Accession, title, r.EntryType, r.FileCount, r.Date)
+		}
+	} else if src == "vcp" || src == "scperturb" || src == "cellxgene" {
 		_, _ = fmt.Fprintln(w, "ACCESSION\tTITLE\tORGANISM\tASSAY\tSIZE")
 		_, _ = fmt.Fprintln(w, "---------\t-----\t--------\t-----\t----")
 		for _, r := range results {
@@ -202,5 +243,7 @@ func init() {
 	searchCmd.Flags().IntVar(&searchLimit, "limit", 10, "maximum number of results to return")
 	searchCmd.Flags().StringVar(&searchOrganism, "organism", "", "filter by organism (e.g. 'Homo sapiens')")
 	searchCmd.Flags().StringVar(&searchType, "type", "",
-		"GEO: entry type to filter (GSE/GSM/GPL/GDS, default GSE); CZI: assay filter (e.g. 'Perturb-Seq')")
+		"GEO: entry type to filter (GSE/GSM/GPL/GDS, default GSE); CZI: assay filter (e.g. 'Perturb-Seq'); BioStudies: study type")
+	searchCmd.Flags().StringVar(&searchCollection, "collection", "",
+		"BioStudies: restrict to a collection (e.g. 'arrayexpress')")
 }
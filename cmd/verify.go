@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/btraven00/hapiq/pkg/downloaders"
+	"github.com/btraven00/hapiq/pkg/downloaders/common"
+	"github.com/btraven00/hapiq/pkg/manifest"
+)
+
+var verifyStrict bool
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <dir>",
+	Short: "Re-check a downloaded directory against its hapiq.json",
+	Long: `Verify re-hashes every file recorded in <dir>/hapiq.json with the checksum
+type recorded at download time and reports files that are missing, modified,
+or present on disk but not in the witness (extra).
+
+The exit status is nonzero when any file is missing or modified; with
+--strict, extra files fail the check too. Use -o json for a machine-readable
+report.
+
+Examples:
+  hapiq verify ./GSE123456
+  hapiq verify ./GSE123456 -o json --strict`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVerify,
+}
+
+// Verify statuses for a witnessed file.
+const (
+	verifyOK        = "ok"
+	verifyMissing   = "missing"
+	verifyModified  = "modified"
+	verifyUnchecked = "unchecked" // no checksum recorded
+)
+
+// verifyReport is the result of checking a directory against its witness.
+type verifyReport struct {
+	Dir      string            `json:"dir"`
+	Datasets []string          `json:"datasets"`
+	Files    []verifyFileEntry `json:"files"`
+	Extra    []string          `json:"extra,omitempty"`
+	OK       int               `json:"ok"`
+	Missing  int               `json:"missing"`
+	Modified int               `json:"modified"`
+	Skipped  int               `json:"unchecked"`
+}
+
+type verifyFileEntry struct {
+	Path     string `json:"path"`
+	Status   string `json:"status"`
+	Expected string `json:"expected,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// failed reports whether the check should exit nonzero.
+func (r *verifyReport) failed(strict bool) bool {
+	return r.Missing > 0 || r.Modified > 0 || (strict && len(r.Extra) > 0)
+}
+
+func runVerify(_ *cobra.Command, args []string) error {
+	report, err := verifyTree(args[0])
+	if err != nil {
+		return err
+	}
+
+	if output == outputFormatJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printVerifyReport(report)
+	}
+
+	if report.failed(verifyStrict) {
+		return fmt.Errorf("verification failed: %d missing, %d modified, %d extra",
+			report.Missing, report.Modified, len(report.Extra))
+	}
+	return nil
+}
+
+// verifyTree checks every file in dir's witness (which also covers the files
+// of any additional Datasets downloaded into dir) and lists files on disk the
+// witness does not mention.
+func verifyTree(dir string) (*verifyReport, error) {
+	witness, err := common.LoadWitnessFile(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &verifyReport{Dir: dir, Datasets: witnessDatasets(witness)}
+	seen := map[string]bool{}
+	anchor := witnessAnchor(dir, witness.Files)
+
+	for _, f := range witness.Files {
+		path, found := resolveWitnessPath(dir, f.Path, anchor)
+		entry := verifyFileEntry{Path: displayPath(dir, path)}
+		if found {
+			seen[absPath(path)] = true
+		}
+
+		spec := checksumSpec(f)
+		entry.Expected = spec
+		switch {
+		case !found:
+			entry.Status = verifyMissing
+			report.Missing++
+		case spec == "":
+			entry.Status = verifyUnchecked
+			report.Skipped++
+		default:
+			if err := manifest.VerifyFile(path, spec); err != nil {
+				entry.Status, entry.Error = verifyModified, err.Error()
+				report.Modified++
+			} else {
+				entry.Status = verifyOK
+				report.OK++
+			}
+		}
+		report.Files = append(report.Files, entry)
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// A subdirectory with its own witness (e.g. one per Zenodo
+			// version) is a separate download; verify it on its own.
+			if path != dir && fileExists(filepath.Join(path, "hapiq.json")) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if path == filepath.Join(dir, "hapiq.json") {
+			return nil
+		}
+		if !seen[absPath(path)] {
+			report.Extra = append(report.Extra, displayPath(dir, path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", dir, err)
+	}
+	sort.Strings(report.Extra)
+
+	return report, nil
+}
+
+// witnessDatasets lists the "source:id" of every dataset recorded in w.
+func witnessDatasets(w *downloaders.WitnessFile) []string {
+	out := []string{w.Source + ":" + w.OriginalID}
+	for _, d := range w.Datasets {
+		out = append(out, d.Source+":"+d.OriginalID)
+	}
+	return out
+}
+
+// checksumSpec returns the "<algo>:<hex>" spec for f, defaulting the type to
+// sha256 (what the witness records when it does not say). A checksum already
+// in "algo:hex" form is used as is.
+func checksumSpec(f downloaders.FileWitness) string {
+	if f.Checksum == "" {
+		return ""
+	}
+	if c, ok := common.ParseChecksum(f.Checksum); ok && strings.Contains(f.Checksum, ":") {
+		return c.String()
+	}
+	typ := f.ChecksumType
+	if typ == "" {
+		typ = "sha256"
+	}
+	return strings.ToLower(typ) + ":" + f.Checksum
+}
+
+// witnessAnchor works out how many leading components of the recorded paths
+// stand for the directory the witness was written to. Downloaders record
+// paths relative to the working directory at download time or as absolute
+// paths, and the tree may since have moved, so each count up to the
+// directory prefix all paths share is tried, and the one under which the
+// most files exist in dir wins (the fewest stripped components on a tie).
+// Anchoring every file the same way keeps a missing file from resolving to
+// a same-named file elsewhere in the tree.
+func witnessAnchor(dir string, files []downloaders.FileWitness) int {
+	depth := commonDirDepth(files)
+	best, bestHits := depth, 0
+	for k := 0; k <= depth; k++ {
+		hits := 0
+		for _, f := range files {
+			parts := splitWitnessPath(f.Path)
+			if fileExists(filepath.Join(dir, filepath.Join(parts[k:]...))) {
+				hits++
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = k, hits
+		}
+	}
+	return best
+}
+
+// commonDirDepth is the number of leading directory components all recorded
+// paths share: the deepest possible anchor, and the one used when none of
+// the files exist.
+func commonDirDepth(files []downloaders.FileWitness) int {
+	var prefix []string
+	for i, f := range files {
+		parts := splitWitnessPath(f.Path)
+		dirs := parts[:len(parts)-1]
+		if i == 0 {
+			prefix = dirs
+			continue
+		}
+		n := 0
+		for n < len(prefix) && n < len(dirs) && prefix[n] == dirs[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return len(prefix)
+}
+
+func splitWitnessPath(p string) []string {
+	return strings.Split(filepath.ToSlash(filepath.Clean(p)), "/")
+}
+
+// resolveWitnessPath finds the file a witness path refers to under dir,
+// dropping the first anchor components (see witnessAnchor). A missing file
+// is returned at the path it should have.
+func resolveWitnessPath(dir, p string, anchor int) (string, bool) {
+	parts := splitWitnessPath(p)
+	if anchor >= len(parts) {
+		return p, false
+	}
+	candidate := filepath.Join(dir, filepath.Join(parts[anchor:]...))
+	return candidate, fileExists(candidate)
+}
+
+// displayPath returns path relative to dir when possible.
+func displayPath(dir, path string) string {
+	if rel, err := filepath.Rel(absPath(dir), absPath(path)); err == nil {
+		return rel
+	}
+	return path
+}
+
+func absPath(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return filepath.Clean(p)
+}
+
+func fileExists(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && !info.IsDir()
+}
+
+// printVerifyReport writes the human-readable report: one line per problem,
+// then a summary.
+func printVerifyReport(r *verifyReport) {
+	for _, f := range r.Files {
+		switch f.Status {
+		case verifyMissing:
+			fmt.Printf("MISSING:  %s\n", f.Path)
+		case verifyModified:
+			fmt.Printf("MODIFIED: %s (%s)\n", f.Path, f.Error)
+		case verifyUnchecked:
+			if !quiet {
+				fmt.Printf("NO HASH:  %s\n", f.Path)
+			}
+		}
+	}
+	for _, p := range r.Extra {
+		fmt.Printf("EXTRA:    %s\n", p)
+	}
+	fmt.Printf("Verified %d files in %s (%s): %d ok, %d modified, %d missing, %d unchecked, %d extra.\n",
+		len(r.Files), r.Dir, strings.Join(r.Datasets, ", "), r.OK, r.Modified, r.Missing, r.Skipped, len(r.Extra))
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().BoolVar(&verifyStrict, "strict", false,
+		"also fail when files not recorded in hapiq.json are present")
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btraven00/hapiq/pkg/downloaders"
+	"github.com/btraven00/hapiq/pkg/downloaders/common"
+)
+
+// writeVerifyFixture lays out dir with three witnessed files (one then
+// modified, one deleted) plus an unrecorded extra file.
+func writeVerifyFixture(t *testing.T, dir string) {
+	t.Helper()
+	files := map[string]string{
+		"a.txt":         "alpha",
+		"sub/b.txt":     "bravo",
+		"gone.txt":      "deleted later",
+		"tampered.txt":  "original",
+		"unhashed.json": "{}",
+	}
+	var witnessed []downloaders.FileWitness
+	for name, body := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		fw := downloaders.FileWitness{Path: p}
+		if name != "unhashed.json" {
+			fw.Checksum, fw.ChecksumType = md5Hex([]byte(body)), "md5"
+		}
+		witnessed = append(witnessed, fw)
+	}
+	err := common.WriteWitnessFile(dir, &downloaders.WitnessFile{
+		Source: "zenodo", OriginalID: "123", Files: witnessed,
+		Datasets: []downloaders.DatasetRecord{{Source: "figshare", OriginalID: "456"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = os.Remove(filepath.Join(dir, "gone.txt"))
+	_ = os.WriteFile(filepath.Join(dir, "tampered.txt"), []byte("changed"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "stray.txt"), []byte("?"), 0o644)
+}
+
+func TestVerifyTree(t *testing.T) {
+	dir := t.TempDir()
+	writeVerifyFixture(t, dir)
+
+	r, err := verifyTree(dir)
+	if err != nil {
+		t.Fatalf("verifyTree: %v", err)
+	}
+	if r.OK != 2 || r.Modified != 1 || r.Missing != 1 || r.Skipped != 1 {
+		t.Errorf("counts = ok %d, modified %d, missing %d, unchecked %d", r.OK, r.Modified, r.Missing, r.Skipped)
+	}
+	if len(r.Extra) != 1 || r.Extra[0] != "stray.txt" {
+		t.Errorf("extra = %v, want [stray.txt]", r.Extra)
+	}
+	if len(r.Datasets) != 2 || r.Datasets[1] != "figshare:456" {
+		t.Errorf("datasets = %v", r.Datasets)
+	}
+	if !r.failed(false) {
+		t.Error("failed(false) = false with missing and modified files")
+	}
+}
+
+// TestVerifyTree_MovedTree checks that absolute witness paths still resolve
+// after the directory has been moved.
+func TestVerifyTree_MovedTree(t *testing.T) {
+	root := t.TempDir()
+	orig := filepath.Join(root, "orig", "GSE1")
+	if err := os.MkdirAll(filepath.Join(orig, "suppl"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(orig, "suppl", "x.txt")
+	if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := common.WriteWitnessFile(orig, &downloaders.WitnessFile{
+		Source: "geo", OriginalID: "GSE1",
+		Files: []downloaders.FileWitness{{Path: p, Checksum: md5Hex([]byte("x")), ChecksumType: "md5"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := filepath.Join(root, "archive", "GSE1")
+	if err := os.MkdirAll(filepath.Dir(moved), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(orig, moved); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := verifyTree(moved)
+	if err != nil {
+		t.Fatalf("verifyTree: %v", err)
+	}
+	if r.OK != 1 || len(r.Extra) != 0 || r.failed(true) {
+		t.Errorf("report = %+v", r)
+	}
+}
+
+// TestVerifyTree_MissingFileNotMatchedElsewhere checks that a deleted file is
+// reported missing rather than resolved to a same-named file in another
+// directory of the tree.
+func TestVerifyTree_MissingFileNotMatchedElsewhere(t *testing.T) {
+	dir := t.TempDir()
+	var witnessed []downloaders.FileWitness
+	for _, name := range []string{"run1/reads.fq", "run2/reads.fq"} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("reads"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		witnessed = append(witnessed, downloaders.FileWitness{Path: p, Checksum: md5Hex([]byte("reads")), ChecksumType: "md5"})
+	}
+	if err := os.WriteFile(filepath.Join(dir, "reads.fq"), []byte("reads"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := common.WriteWitnessFile(dir, &downloaders.WitnessFile{Source: "sra", OriginalID: "SRP1", Files: witnessed}); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Remove(filepath.Join(dir, "run2", "reads.fq"))
+
+	r, err := verifyTree(dir)
+	if err != nil {
+		t.Fatalf("verifyTree: %v", err)
+	}
+	if r.OK != 1 || r.Missing != 1 {
+		t.Errorf("ok %d, missing %d; want 1 and 1", r.OK, r.Missing)
+	}
+	if len(r.Extra) != 1 || r.Extra[0] != "reads.fq" {
+		t.Errorf("extra = %v, want [reads.fq]", r.Extra)
+	}
+}
+
+// TestVerifyTree_NestedWitness checks that a subdirectory with its own
+// hapiq.json is left to its own verification instead of listed as extra.
+func TestVerifyTree_NestedWitness(t *testing.T) {
+	dir := t.TempDir()
+	writeVerifyFixture(t, dir)
+	nested := filepath.Join(dir, "v2")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(nested, "data.csv")
+	if err := os.WriteFile(p, []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := common.WriteWitnessFile(nested, &downloaders.WitnessFile{
+		Source: "zenodo", OriginalID: "124",
+		Files: []downloaders.FileWitness{{Path: p, Checksum: md5Hex([]byte("v2")), ChecksumType: "md5"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := verifyTree(dir)
+	if err != nil {
+		t.Fatalf("verifyTree: %v", err)
+	}
+	if len(r.Extra) != 1 || r.Extra[0] != "stray.txt" {
+		t.Errorf("extra = %v, want [stray.txt]", r.Extra)
+	}
+}
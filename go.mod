@@ -4,6 +4,7 @@ go 1.25.10
 
 require (
 	github.com/jlaffaye/ftp v0.2.0
+	github.com/klauspost/compress v1.20.1
 	github.com/spf13/cobra v1.9.1
 	github.com/spf13/viper v1.20.1
 	golang.org/x/sys v0.42.0
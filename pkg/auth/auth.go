@@ -0,0 +1,102 @@
+// Package auth resolves credentials for data sources. Credentials come from
+// environment variables, the hapiq config file, a 0600 credentials file
+// managed by `hapiq auth`, and ~/.netrc, and are looked up by source name so
+// downloaders never read the environment or files themselves.
+package auth
+
+import "strings"
+
+// Credential is what a source needs to authenticate: a token (API key,
+// personal access token or JWT) and, for host-based logins, a user name.
+type Credential struct {
+	Login string `yaml:"login,omitempty"`
+	Token string `yaml:"token,omitempty"`
+
+	// Origin says where the credential was found, e.g. "env ZENODO_TOKEN"
+	// or "netrc". It is informational and never persisted.
+	Origin string `yaml:"-"`
+}
+
+// IsZero reports whether c carries no credential.
+func (c Credential) IsZero() bool { return c.Token == "" }
+
+// Masked returns the token with all but its last four characters hidden,
+// for display.
+func (c Credential) Masked() string {
+	if len(c.Token) <= 8 {
+		return strings.Repeat("*", len(c.Token))
+	}
+	return "****" + c.Token[len(c.Token)-4:]
+}
+
+// Provider looks up credentials by source name. A missing credential is not
+// an error: Lookup returns a zero Credential and downloaders fall back to
+// anonymous access.
+type Provider interface {
+	Lookup(source string) (Credential, error)
+}
+
+// Source describes where credentials for one source may be found besides
+// the config and credentials files.
+type Source struct {
+	Name        string
+	Description string
+	// Env lists environment variables holding the token, first wins.
+	Env []string
+	// Hosts are matched against ~/.netrc machine entries.
+	Hosts []string
+}
+
+// Sources lists the sources that accept credentials.
+var Sources = []Source{
+	{
+		Name:        "figshare",
+		Description: "personal token for private and ACL-restricted articles",
+		Env:         []string{"FIGSHARE_TOKEN"},
+		Hosts:       []string{"api.figshare.com", "figshare.com"},
+	},
+	{
+		Name:        "geo",
+		Description: "NCBI API key, raises E-utilities rate limits from 3 to 10 req/s",
+		Env:         []string{"NCBI_API_KEY", "GEO_TOKEN"},
+		Hosts:       []string{"eutils.ncbi.nlm.nih.gov"},
+	},
+	{
+		Name:        "vcp",
+		Description: "CZI Virtual Cell Platform JWT for private datasets",
+		Env:         []string{"VCP_TOKEN"},
+		Hosts:       []string{"cosmic-shepherd.prod-vcp.prod.czi.team"},
+	},
+	{
+		Name:        "zenodo",
+		Description: "personal access token for restricted and embargoed records",
+		Env:         []string{"ZENODO_TOKEN"},
+		Hosts:       []string{"zenodo.org"},
+	},
+}
+
+// aliases maps alternative names to the source name credentials are stored
+// under.
+var aliases = map[string]string{
+	"ncbi": "geo",
+}
+
+// Canonical returns the name credentials for source are stored under.
+func Canonical(source string) string {
+	source = strings.ToLower(strings.TrimSpace(source))
+	if name, ok := aliases[source]; ok {
+		return name
+	}
+	return source
+}
+
+// lookupSource returns the Source entry for name, or one with the
+// conventional <NAME>_TOKEN variable for sources not listed in Sources.
+func lookupSource(name string) Source {
+	for _, s := range Sources {
+		if s.Name == name {
+			return s
+		}
+	}
+	return Source{Name: name, Env: []string{strings.ToUpper(name) + "_TOKEN"}}
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestStore_SaveIsPrivateAndRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hapiq", "credentials.yaml")
+	s, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set("Zenodo", Credential{Token: "z-token", Origin: "ignored"})
+	s.Set("ncbi", Credential{Token: "ncbi-key"})
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0o600 {
+			t.Errorf("credentials file mode = %o, want 600", fi.Mode().Perm())
+		}
+	}
+
+	s2, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := s2.Get("zenodo"); !ok || c.Token != "z-token" || c.Origin != "" {
+		t.Errorf("zenodo = %+v, %v", c, ok)
+	}
+	if c, ok := s2.Get("geo"); !ok || c.Token != "ncbi-key" {
+		t.Errorf("alias ncbi not stored as geo: %+v, %v", c, ok)
+	}
+	if !s2.Remove("zenodo") || s2.Remove("zenodo") {
+		t.Error("Remove should report the credential once")
+	}
+}
+
+func TestParseNetrc(t *testing.T) {
+	entries := parseNetrc(`# comment
+machine ftp.example.org login anonymous password me@example.org
+macdef init
+cd /pub
+bin
+
+machine zenodo.org
+  login token
+  password z-secret
+default login guest password guest
+`)
+	c, ok := netrcLookup(entries, []string{"zenodo.org"})
+	if !ok || c.Token != "z-secret" || c.Login != "token" {
+		t.Errorf("zenodo.org = %+v, %v", c, ok)
+	}
+	if c, ok := netrcLookup(entries, []string{"api.figshare.com"}); ok {
+		t.Errorf("default entry used for figshare: %+v", c)
+	}
+}
+
+func TestResolver_Order(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	t.Setenv("ZENODO_TOKEN", "")
+	dir := t.TempDir()
+
+	netrc := filepath.Join(dir, "netrc")
+	if err := os.WriteFile(netrc, []byte("machine zenodo.org password from-netrc\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, _ := OpenStore(filepath.Join(dir, "credentials.yaml"))
+	r := &Resolver{Store: store, NetrcPath: netrc}
+
+	want := func(origin, token string) {
+		t.Helper()
+		c, err := r.Lookup("zenodo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Token != token || c.Origin != origin {
+			t.Errorf("Lookup = %q from %q, want %q from %q", c.Token, c.Origin, token, origin)
+		}
+	}
+
+	want("netrc zenodo.org", "from-netrc")
+
+	store.Set("zenodo", Credential{Token: "from-store"})
+	want("credentials file", "from-store")
+
+	tokenFile := filepath.Join(dir, "zenodo.token")
+	if err := os.WriteFile(tokenFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("auth.zenodo.token_file", tokenFile)
+	want("token file "+tokenFile, "from-file")
+
+	viper.Set("auth.zenodo.token", "from-config")
+	want("config", "from-config")
+
+	t.Setenv("ZENODO_TOKEN", "from-env")
+	want("env ZENODO_TOKEN", "from-env")
+
+	if c, err := r.Lookup("figshare"); err != nil || !c.IsZero() {
+		t.Errorf("unconfigured source: %+v, %v", c, err)
+	}
+
+	viper.Set("auth.figshare.token_file", filepath.Join(dir, "missing"))
+	if _, err := r.Lookup("figshare"); err == nil {
+		t.Error("missing token file: want error")
+	}
+}
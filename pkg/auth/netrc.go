@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// netrcEntry is one machine (or default) entry of a .netrc file.
+type netrcEntry struct {
+	machine  string // "" for the default entry
+	login    string
+	password string
+}
+
+// netrcPath returns $NETRC or ~/.netrc.
+func netrcPath() string {
+	if p := os.Getenv("NETRC"); p != "" {
+		return p
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".netrc")
+}
+
+// readNetrc parses the .netrc file at path. A missing file yields no entries.
+func readNetrc(path string) ([]netrcEntry, error) {
+	data, err := os.ReadFile(filepath.Clean(path)) // #nosec G304 -- user netrc file
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseNetrc(string(data)), nil
+}
+
+// parseNetrc parses .netrc tokens: machine, default, login, password and
+// account. macdef bodies (up to the next blank line) are skipped.
+func parseNetrc(data string) []netrcEntry {
+	var (
+		entries []netrcEntry
+		cur     *netrcEntry
+	)
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), "#") {
+			continue
+		}
+		fields := strings.Fields(lines[i])
+		for j := 0; j < len(fields); j++ {
+			next := func() string {
+				if j+1 < len(fields) {
+					j++
+					return fields[j]
+				}
+				return ""
+			}
+			switch fields[j] {
+			case "machine":
+				entries = append(entries, netrcEntry{machine: next()})
+				cur = &entries[len(entries)-1]
+			case "default":
+				entries = append(entries, netrcEntry{})
+				cur = &entries[len(entries)-1]
+			case "login":
+				if v := next(); cur != nil {
+					cur.login = v
+				}
+			case "password":
+				if v := next(); cur != nil {
+					cur.password = v
+				}
+			case "account":
+				next()
+			case "macdef":
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					i++
+				}
+				j = len(fields)
+			}
+		}
+	}
+	return entries
+}
+
+// netrcLookup returns the credential of the first entry naming one of hosts.
+// The default entry is deliberately ignored: it usually holds an FTP or
+// proxy login that must not be sent to an API as a token.
+func netrcLookup(entries []netrcEntry, hosts []string) (Credential, bool) {
+	for _, e := range entries {
+		for _, h := range hosts {
+			if e.machine != "" && strings.EqualFold(e.machine, h) && e.password != "" {
+				return Credential{Login: e.login, Token: e.password, Origin: "netrc " + e.machine}, true
+			}
+		}
+	}
+	return Credential{}, false
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// Resolver is the Provider hapiq uses. For each source it consults, in
+// order: the source's environment variables, auth.<source>.token and
+// auth.<source>.token_file in the config file, the credentials file, and
+// .netrc entries for the source's hosts.
+type Resolver struct {
+	// Store is the credentials file; nil skips it.
+	Store *Store
+	// NetrcPath is the .netrc file; "" skips it.
+	NetrcPath string
+
+	netrcOnce    sync.Once
+	netrcEntries []netrcEntry
+	netrcErr     error
+}
+
+// NewResolver returns a Resolver over the credentials file named by
+// auth.credentials_file (default DefaultPath) and $NETRC or ~/.netrc. An
+// unreadable credentials file is returned as an error alongside a Resolver
+// that skips it, so callers can warn and carry on.
+func NewResolver() (*Resolver, error) {
+	r := &Resolver{NetrcPath: netrcPath()}
+	store, err := OpenStore(CredentialsPath())
+	if err != nil {
+		return r, err
+	}
+	r.Store = store
+	return r, nil
+}
+
+// CredentialsPath returns the credentials file in use: auth.credentials_file
+// from the config, or DefaultPath.
+func CredentialsPath() string {
+	if p := viper.GetString("auth.credentials_file"); p != "" {
+		return expandHome(p)
+	}
+	return DefaultPath()
+}
+
+// Lookup implements Provider.
+func (r *Resolver) Lookup(source string) (Credential, error) {
+	name := Canonical(source)
+	src := lookupSource(name)
+
+	for _, env := range src.Env {
+		if tok := os.Getenv(env); tok != "" {
+			return Credential{Token: tok, Origin: "env " + env}, nil
+		}
+	}
+
+	if tok := viper.GetString("auth." + name + ".token"); tok != "" {
+		return Credential{Token: tok, Origin: "config"}, nil
+	}
+	if path := viper.GetString("auth." + name + ".token_file"); path != "" {
+		data, err := os.ReadFile(filepath.Clean(expandHome(path))) // #nosec G304 -- user-configured token file
+		if err != nil {
+			return Credential{}, fmt.Errorf("read %s token file: %w", name, err)
+		}
+		return Credential{Token: strings.TrimSpace(string(data)), Origin: "token file " + path}, nil
+	}
+
+	if r.Store != nil {
+		if c, ok := r.Store.Get(name); ok {
+			c.Origin = "credentials file"
+			return c, nil
+		}
+	}
+
+	if r.NetrcPath != "" && len(src.Hosts) > 0 {
+		r.netrcOnce.Do(func() {
+			r.netrcEntries, r.netrcErr = readNetrc(r.NetrcPath)
+		})
+		if r.netrcErr != nil {
+			return Credential{}, fmt.Errorf("read %s: %w", r.NetrcPath, r.netrcErr)
+		}
+		if c, ok := netrcLookup(r.netrcEntries, src.Hosts); ok {
+			return c, nil
+		}
+	}
+
+	return Credential{}, nil
+}
+
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
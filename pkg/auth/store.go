@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Store is the credentials file written by `hapiq auth set`: a YAML map from
+// source name to Credential, readable only by its owner.
+type Store struct {
+	path  string
+	creds map[string]Credential
+}
+
+// DefaultPath returns the default credentials file,
+// <user config dir>/hapiq/credentials.yaml (~/.config/hapiq on Linux).
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "hapiq", "credentials.yaml")
+}
+
+// OpenStore reads the credentials file at path. A missing file yields an
+// empty store; it is created on the first Save.
+func OpenStore(path string) (*Store, error) {
+	s := &Store{path: path, creds: map[string]Credential{}}
+	data, err := os.ReadFile(filepath.Clean(path)) // #nosec G304 -- user credentials file
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read credentials: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &s.creds); err != nil {
+		return nil, fmt.Errorf("parse credentials %s: %w", path, err)
+	}
+	if s.creds == nil {
+		s.creds = map[string]Credential{}
+	}
+	return s, nil
+}
+
+// Path returns the file the store reads and writes.
+func (s *Store) Path() string { return s.path }
+
+// Get returns the stored credential for source.
+func (s *Store) Get(source string) (Credential, bool) {
+	c, ok := s.creds[Canonical(source)]
+	return c, ok && !c.IsZero()
+}
+
+// Set stores c for source, replacing any previous credential.
+func (s *Store) Set(source string, c Credential) {
+	c.Origin = ""
+	s.creds[Canonical(source)] = c
+}
+
+// Remove deletes the credential for source and reports whether one existed.
+func (s *Store) Remove(source string) bool {
+	name := Canonical(source)
+	_, ok := s.creds[name]
+	delete(s.creds, name)
+	return ok
+}
+
+// Sources returns the names of all stored sources, sorted.
+func (s *Store) Sources() []string {
+	names := make([]string, 0, len(s.creds))
+	for name := range s.creds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save writes the store with mode 0600, replacing the file atomically so a
+// crash never leaves a truncated or world-readable credentials file.
+func (s *Store) Save() error {
+	data, err := yaml.Marshal(s.creds)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, ".credentials-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	// CreateTemp already uses 0600; make it explicit.
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -19,12 +19,18 @@ import (
 type contextKey struct{}
 
 // Cache is a content-addressable store backed by the local filesystem and a
-// SQLite index. It is safe for concurrent use.
+// SQLite index. It is safe for concurrent use, including by several processes
+// sharing one directory: index writes are SQLite transactions and concurrent
+// fetches of one URL are coordinated with AcquireLease.
 type Cache struct {
 	cfg Config
 	db  *sql.DB
 	s   *dbStmts
-	mu  sync.Mutex
+	// tiers lists every tier in lookup order, c itself included, when
+	// cfg.Tiers configures more than one; nil otherwise.
+	tiers    []*Cache
+	mu       sync.Mutex
+	readOnly bool
 }
 
 // Open opens (and if necessary initialises) the cache rooted at cfg.Dir.
@@ -40,11 +46,20 @@ func Open(cfg Config) (*Cache, error) {
 		return nil, err
 	}
 
-	return &Cache{cfg: cfg, db: db, s: s}, nil
+	c := &Cache{cfg: cfg, db: db, s: s}
+	c.openTiers()
+	return c, nil
 }
 
-// Close releases the database connection and prepared statements.
+// Close releases the database connection and prepared statements, including
+// those of read-only tiers.
 func (c *Cache) Close() error {
+	for _, t := range c.tiers {
+		if t != c {
+			t.s.close()
+			_ = t.db.Close()
+		}
+	}
 	c.s.close()
 	return c.db.Close()
 }
@@ -67,38 +82,142 @@ func (c *Cache) blobPath(sha256hex string) string {
 
 // Get looks up rawURL in the index. On hit it refreshes last_used and returns
 // the sha256 hash and the recorded blob size. On miss it returns ("", 0, false, nil).
+// Get ignores freshness; use Lookup to decide whether to revalidate.
 func (c *Cache) Get(ctx context.Context, rawURL string) (sha256hex string, size int64, hit bool, err error) {
+	e, hit, err := c.Lookup(ctx, rawURL)
+	return e.SHA256, e.Size, hit, err
+}
+
+// Entry is the index row for a cached URL.
+type Entry struct {
+	// FetchedAt is when the URL was last downloaded or revalidated.
+	FetchedAt time.Time
+	SHA256    string
+	// Tier is the directory of the cache tier holding the entry.
+	Tier string
+	Validators
+	Size int64
+}
+
+// Validators are the origin's cache validators for a URL, sent back as
+// If-None-Match / If-Modified-Since when revalidating.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// Lookup is Get returning the whole index row, including the validators and
+// fetch time needed to revalidate it. With several tiers holding rawURL the
+// most recently fetched entry answers, the first in lookup order on a tie:
+// once a changed file has been refetched into the writable tier, a stale
+// entry in a read-only tier no longer shadows it.
+func (c *Cache) Lookup(ctx context.Context, rawURL string) (Entry, bool, error) {
+	var best Entry
+	var bestTier *Cache
+	for _, t := range c.lookupOrder() {
+		e, hit, err := t.lookupLocal(ctx, rawURL)
+		if err != nil {
+			return Entry{Tier: t.cfg.Dir}, false, err
+		}
+		if hit && (bestTier == nil || e.FetchedAt.After(best.FetchedAt)) {
+			e.Tier = t.cfg.Dir
+			best, bestTier = e, t
+		}
+	}
+	if bestTier == nil {
+		return Entry{}, false, nil
+	}
+	c.countTierHit(ctx, bestTier, true)
+	return best, true, nil
+}
+
+// lookupLocal is Lookup within this tier only.
+func (c *Cache) lookupLocal(ctx context.Context, rawURL string) (Entry, bool, error) {
 	canonical, err := canonicalizeURL(rawURL)
 	if err != nil {
-		return "", 0, false, err
+		return Entry{}, false, err
+	}
+
+	var e Entry
+	var etag, lastModified sql.NullString
+	var fetchedAt int64
+	row := c.s.getByURL.QueryRowContext(ctx, canonical)
+	if err := row.Scan(&e.SHA256, &e.Size, &etag, &lastModified, &fetchedAt); err == sql.ErrNoRows {
+		return Entry{}, false, nil
+	} else if err != nil {
+		return Entry{}, false, fmt.Errorf("cache lookup: %w", err)
 	}
+	if !c.touchIfPresent(ctx, e.SHA256, e.Size) {
+		return Entry{}, false, nil
+	}
+	e.ETag, e.LastModified = etag.String, lastModified.String
+	e.FetchedAt = time.Unix(fetchedAt, 0)
+	return e, true, nil
+}
 
+// lookup runs stmt, which selects (sha256, size) for key, and checks that the
+// blob is still on disk. On hit it refreshes last_used.
+func (c *Cache) lookup(ctx context.Context, stmt *sql.Stmt, key string) (sha256hex string, size int64, hit bool, err error) {
 	var hash string
 	var sz int64
-	row := c.s.getByURL.QueryRowContext(ctx, canonical)
+	row := stmt.QueryRowContext(ctx, key)
 	if err := row.Scan(&hash, &sz); err == sql.ErrNoRows {
 		return "", 0, false, nil
 	} else if err != nil {
 		return "", 0, false, fmt.Errorf("cache lookup: %w", err)
 	}
-
-	// Verify the blob file still exists on disk and matches the recorded size.
-	info, err := os.Stat(c.blobPath(hash))
-	if err != nil || info.Size() != sz {
+	if !c.touchIfPresent(ctx, hash, sz) {
 		return "", 0, false, nil
 	}
+	return hash, sz, true, nil
+}
 
-	now := time.Now().Unix()
-	_, _ = c.s.touchBlob.ExecContext(ctx, now, hash)
+// touchIfPresent verifies the blob file still exists on disk and matches the
+// recorded size, and if so refreshes its last_used. A compacted blob is
+// present if its compressed file is; its size is checked when it is read.
+func (c *Cache) touchIfPresent(ctx context.Context, sha256hex string, size int64) bool {
+	info, err := os.Stat(c.blobPath(sha256hex))
+	if os.IsNotExist(err) {
+		if _, gzErr := os.Stat(c.compressedPath(sha256hex)); gzErr != nil {
+			return false
+		}
+	} else if err != nil || info.Size() != size {
+		return false
+	}
+	if !c.readOnly {
+		_, _ = c.s.touchBlob.ExecContext(ctx, time.Now().Unix(), sha256hex)
+	}
+	return true
+}
 
-	return hash, sz, true, nil
+// MarkRevalidated records that the origin confirmed rawURL unchanged (HTTP
+// 304): only fetched_at moves, so a max-age policy starts counting again.
+func (c *Cache) MarkRevalidated(ctx context.Context, rawURL string) error {
+	canonical, err := canonicalizeURL(rawURL)
+	if err != nil {
+		return err
+	}
+	_, err = c.s.setFetched.ExecContext(ctx, time.Now().Unix(), canonical)
+	return err
 }
 
 // Put promotes tmpPath into the CAS and records rawURL → sha256hex.
 // If a blob with the same hash already exists, tmpPath is removed and the URL
 // is re-indexed pointing at the existing blob.
-// Returns an error if the new blob would violate the configured quota.
+// Returns an error if the new blob would violate the configured quota; on
+// any error tmpPath is left for the caller to keep or remove.
+//
+// The quota check and index updates run in one IMMEDIATE transaction, so
+// processes sharing the cache directory cannot both pass the check against
+// the same total.
 func (c *Cache) Put(ctx context.Context, rawURL, tmpPath, sha256hex string) error {
+	return c.PutWithValidators(ctx, rawURL, tmpPath, sha256hex, Validators{})
+}
+
+// PutWithValidators is Put that also records the response's ETag and
+// Last-Modified for later revalidation. If rawURL already pointed at another
+// blob (the content changed upstream) it is re-pointed at the new one.
+func (c *Cache) PutWithValidators(ctx context.Context, rawURL, tmpPath, sha256hex string, v Validators) error {
 	canonical, err := canonicalizeURL(rawURL)
 	if err != nil {
 		return err
@@ -107,9 +226,14 @@ func (c *Cache) Put(ctx context.Context, rawURL, tmpPath, sha256hex string) erro
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin put: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
 	blobSz := fileSizeOrZero(tmpPath)
-	if err := c.checkQuota(ctx, blobSz); err != nil {
-		_ = os.Remove(tmpPath)
+	if err := c.checkQuota(ctx, tx, blobSz); err != nil {
 		return err
 	}
 
@@ -118,7 +242,11 @@ func (c *Cache) Put(ctx context.Context, rawURL, tmpPath, sha256hex string) erro
 
 	existingInfo, statErr := os.Stat(blob)
 	blobMissing := os.IsNotExist(statErr)
-	blobCorrupt := !blobMissing && statErr == nil && existingInfo.Size() != blobSz
+	if blobMissing && fileSizeOrZero(c.compressedPath(sha256hex)) > 0 {
+		blobMissing = false // compacted: the content is already stored
+		existingInfo, statErr = nil, nil
+	}
+	blobCorrupt := existingInfo != nil && existingInfo.Size() != blobSz
 
 	if blobMissing || blobCorrupt {
 		if blobCorrupt {
@@ -127,21 +255,29 @@ func (c *Cache) Put(ctx context.Context, rawURL, tmpPath, sha256hex string) erro
 		if err := os.MkdirAll(filepath.Dir(blob), 0o750); err != nil {
 			return fmt.Errorf("create shard dir: %w", err)
 		}
+		// rename(2) is atomic, so a concurrent Put of the same content from
+		// another process at worst replaces the blob with identical bytes.
 		if err := os.Rename(tmpPath, blob); err != nil {
 			return fmt.Errorf("promote blob: %w", err)
 		}
-		if _, err := c.s.insertBlob.ExecContext(ctx, sha256hex, fileSizeOrZero(blob), now, now); err != nil {
+		if _, err := tx.StmtContext(ctx, c.s.insertBlob).ExecContext(ctx, sha256hex, fileSizeOrZero(blob), now, now); err != nil {
+			return fmt.Errorf("record blob: %w", err)
+		}
+		if _, err := tx.StmtContext(ctx, c.s.resetCodec).ExecContext(ctx, sha256hex); err != nil {
 			return fmt.Errorf("record blob: %w", err)
 		}
 	} else {
 		// Blob already in CAS and healthy; discard the duplicate tmp.
 		_ = os.Remove(tmpPath)
-		_, _ = c.s.touchBlob.ExecContext(ctx, now, sha256hex)
+		_, _ = tx.StmtContext(ctx, c.s.touchBlob).ExecContext(ctx, now, sha256hex)
 	}
 
-	if _, err := c.s.insertURL.ExecContext(ctx, canonical, sha256hex, "", "", now); err != nil {
+	if _, err := tx.StmtContext(ctx, c.s.insertURL).ExecContext(ctx, canonical, sha256hex, v.ETag, v.LastModified, now); err != nil {
 		return fmt.Errorf("record url: %w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit put: %w", err)
+	}
 	return nil
 }
 
@@ -163,7 +299,17 @@ func (c *Cache) RecordFilename(ctx context.Context, rawURL, filename string) err
 
 // Filename returns the filename recorded for rawURL via RecordFilename, or ""
 // if none is known (unindexed URL, or recorded before this column existed).
+// Tiers are searched in lookup order.
 func (c *Cache) Filename(ctx context.Context, rawURL string) (string, error) {
+	for _, t := range c.lookupOrder() {
+		if fn, err := t.filenameLocal(ctx, rawURL); err != nil || fn != "" {
+			return fn, err
+		}
+	}
+	return "", nil
+}
+
+func (c *Cache) filenameLocal(ctx context.Context, rawURL string) (string, error) {
 	canonical, err := canonicalizeURL(rawURL)
 	if err != nil {
 		return "", err
@@ -179,9 +325,29 @@ func (c *Cache) Filename(ctx context.Context, rawURL string) (string, error) {
 }
 
 // Materialize links or copies the blob identified by sha256hex to destPath,
-// using the strategy configured in cfg.LinkStrategy.
+// using the strategy configured in cfg.LinkStrategy. A compacted blob is
+// decompressed into a private copy instead. The blob comes from the first
+// tier holding it. destPath is recorded as a reference to a blob of the
+// writable tier (see Refs).
 func (c *Cache) Materialize(sha256hex, destPath string) error {
-	return tryLink(c.blobPath(sha256hex), destPath, c.cfg.LinkStrategy)
+	t := c.blobTier(sha256hex)
+	// Only link a raw file that is there: "auto" would otherwise fall back
+	// to a dangling symlink for a compacted blob.
+	raw := t.blobPath(sha256hex)
+	_, err := os.Stat(raw)
+	if err == nil {
+		err = tryLink(raw, destPath, c.cfg.LinkStrategy)
+	}
+	if err != nil && fileSizeOrZero(t.compressedPath(sha256hex)) > 0 {
+		err = t.decompressTo(sha256hex, destPath)
+	}
+	if err != nil {
+		return err
+	}
+	if t == c {
+		c.recordRef(context.Background(), sha256hex, destPath)
+	}
+	return nil
 }
 
 // NewTmpFile creates a new temporary file in the cache's tmp directory.
@@ -191,10 +357,22 @@ func (c *Cache) NewTmpFile() (*os.File, error) {
 	return os.CreateTemp(tmpDir, "download-*")
 }
 
+// PartialPath returns a stable path in the cache's tmp directory for an
+// in-progress download of rawURL. Unlike NewTmpFile the name is derived from
+// the canonical URL, so a later process can find and resume the partial.
+func (c *Cache) PartialPath(rawURL string) string {
+	canonical, err := canonicalizeURL(rawURL)
+	if err != nil {
+		canonical = rawURL
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return filepath.Join(c.cfg.Dir, "tmp", "partial-"+hex.EncodeToString(sum[:16]))
+}
+
 // VerifyBlob re-hashes the blob at sha256hex, evicts it if corrupt, and
 // returns whether it is valid.
 func (c *Cache) VerifyBlob(ctx context.Context, sha256hex string) (bool, error) {
-	actual, err := hashFile(c.blobPath(sha256hex))
+	actual, err := c.hashBlob(sha256hex)
 	if err != nil {
 		return false, err
 	}
@@ -214,10 +392,32 @@ func (c *Cache) BlobCount(ctx context.Context) (int, error) {
 	return n, nil
 }
 
-// ListBlobs returns a snapshot of all blobs with their URL mappings.
+// ListBlobs returns a snapshot of all blobs with their URL mappings. With
+// several tiers a blob is listed once, for the tier that serves it.
 func (c *Cache) ListBlobs(ctx context.Context, urlGlob string) ([]BlobInfo, error) {
+	var out []BlobInfo
+	seen := map[string]bool{}
+	for _, t := range c.lookupOrder() {
+		blobs, err := t.listBlobsLocal(ctx, urlGlob)
+		if err != nil {
+			return nil, fmt.Errorf("list tier %s: %w", t.cfg.Dir, err)
+		}
+		for _, b := range blobs {
+			if !seen[b.SHA256] {
+				seen[b.SHA256] = true
+				out = append(out, b)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (c *Cache) listBlobsLocal(ctx context.Context, urlGlob string) ([]BlobInfo, error) {
 	query := `
-SELECT b.sha256, b.size, b.last_used, GROUP_CONCAT(u.url, char(10))
+SELECT b.sha256, b.size, b.last_used, GROUP_CONCAT(u.url, char(10)),
+       (SELECT GROUP_CONCAT(d.digest, char(10)) FROM digests d WHERE d.sha256 = b.sha256),
+       (SELECT COUNT(*) FROM pins p WHERE p.sha256 = b.sha256),
+       (SELECT GROUP_CONCAT(p.label, char(10)) FROM pins p WHERE p.sha256 = b.sha256 AND p.label != '')
 FROM blobs b LEFT JOIN urls u ON u.sha256 = b.sha256
 GROUP BY b.sha256
 ORDER BY b.last_used DESC`
@@ -230,15 +430,23 @@ ORDER BY b.last_used DESC`
 	var out []BlobInfo
 	for rows.Next() {
 		var bi BlobInfo
-		var urls *string
-		var lastUsed int64
-		if err := rows.Scan(&bi.SHA256, &bi.Size, &lastUsed, &urls); err != nil {
+		var urls, digests, pins *string
+		var lastUsed, pinCount int64
+		if err := rows.Scan(&bi.SHA256, &bi.Size, &lastUsed, &urls, &digests, &pinCount, &pins); err != nil {
 			continue
 		}
 		bi.LastUsed = time.Unix(lastUsed, 0)
+		bi.Tier, bi.ReadOnly = c.cfg.Dir, c.readOnly
 		if urls != nil {
 			bi.URLs = splitLines(*urls)
 		}
+		if digests != nil {
+			bi.Digests = splitLines(*digests)
+		}
+		bi.Pinned = pinCount > 0
+		if pins != nil {
+			bi.Pins = splitLines(*pins)
+		}
 		if urlGlob == "" || matchGlob(urlGlob, bi.URLs) {
 			out = append(out, bi)
 		}
@@ -251,7 +459,17 @@ type BlobInfo struct {
 	LastUsed time.Time
 	SHA256   string
 	URLs     []string
+	// Digests are upstream digest aliases ("md5:…") recorded via AddDigest.
+	Digests []string
+	// Pins are the labels of the blob's pins; Pinned is also set for a pin
+	// without a label.
+	Pins []string
+	// Tier is the directory of the cache tier holding the blob; ReadOnly is
+	// set for blobs of a read-only tier.
+	Tier     string
 	Size     int64
+	Pinned   bool
+	ReadOnly bool
 }
 
 // Dir returns the cache root directory.
@@ -261,7 +479,8 @@ func (c *Cache) Dir() string { return c.cfg.Dir }
 // outside the cache (Nlink > 1). A pinned blob is still referenced by at least
 // one output directory and should not be evicted by automated GC.
 // Returns false for blobs that do not exist or on platforms where Nlink is
-// unavailable (Windows).
+// unavailable (Windows). Outputs that were copied or symlinked go unnoticed;
+// explicit pins (see Pin) cover those.
 func (c *Cache) IsPinned(sha256hex string) bool {
 	return blobNlink(c.blobPath(sha256hex)) > 1
 }
@@ -276,14 +495,28 @@ func fileSizeOrZero(path string) int64 {
 	return info.Size()
 }
 
+// hashBlob hashes a blob's original bytes, decompressing it if compacted.
+func (c *Cache) hashBlob(sha256hex string) (string, error) {
+	f, _, err := c.openBlob(sha256hex)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return hashReader(f)
+}
+
 func hashFile(path string) (string, error) {
 	f, err := os.Open(filepath.Clean(path)) // #nosec G304 -- internal cache path
 	if err != nil {
 		return "", err
 	}
 	defer f.Close()
+	return hashReader(f)
+}
+
+func hashReader(r io.Reader) (string, error) {
 	h := sha256.New()
-	if _, err := io.Copy(h, f); err != nil {
+	if _, err := io.Copy(h, r); err != nil {
 		return "", err
 	}
 	return hex.EncodeToString(h.Sum(nil)), nil
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/btraven00/hapiq/pkg/cache"
@@ -134,6 +135,43 @@ func TestURLCanonicalization(t *testing.T) {
 	}
 }
 
+func TestURLCredentialsNotStored(t *testing.T) {
+	c := openTestCache(t)
+	ctx := context.Background()
+
+	tmpPath, hash := writeTmp(t, c, []byte("restricted"))
+	if err := c.Put(ctx, "https://user:pw@example.com/f?id=7&access_token=secret", tmpPath, hash); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if _, _, hit, err := c.Get(ctx, "https://example.com/f?id=7"); err != nil || !hit {
+		t.Fatalf("Get without credentials: hit=%v err=%v", hit, err)
+	}
+	blobs, err := c.ListBlobs(ctx, "")
+	if err != nil || len(blobs) != 1 {
+		t.Fatalf("ListBlobs: %v / %d", err, len(blobs))
+	}
+	for _, u := range blobs[0].URLs {
+		if strings.Contains(u, "secret") || strings.Contains(u, "pw@") {
+			t.Errorf("credentials stored in urls table: %s", u)
+		}
+	}
+}
+
+func TestHasCredentials(t *testing.T) {
+	for rawURL, want := range map[string]bool{
+		"https://example.com/f?id=7":                false,
+		"https://example.com/f?id=7&access_token=s": true,
+		"https://example.com/f?apikey=s":            true,
+		"https://user:pw@example.com/f":             true,
+		"https://example.com/f?tokenized=1&api=key": false,
+	} {
+		if got := cache.HasCredentials(rawURL); got != want {
+			t.Errorf("HasCredentials(%q) = %v, want %v", rawURL, got, want)
+		}
+	}
+}
+
 func TestPutDedup(t *testing.T) {
 	c := openTestCache(t)
 	ctx := context.Background()
@@ -164,6 +202,45 @@ func TestPutDedup(t *testing.T) {
 	}
 }
 
+func TestGetByDigest(t *testing.T) {
+	c := openTestCache(t)
+	ctx := context.Background()
+
+	tmpPath, hash := writeTmp(t, c, []byte("fastq bytes"))
+	if err := c.Put(ctx, "https://ftp.sra.ebi.ac.uk/run_1.fastq.gz", tmpPath, hash); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	const md5 = "0123456789abcdef0123456789abcdef"
+
+	if _, _, hit, _ := c.GetByDigest(ctx, "md5", md5); hit {
+		t.Fatal("hit before the digest was recorded")
+	}
+	if err := c.AddDigest(ctx, hash, "MD5", strings.ToUpper(md5)); err != nil {
+		t.Fatalf("AddDigest: %v", err)
+	}
+	if got, _, hit, err := c.GetByDigest(ctx, "md5", md5); err != nil || !hit || got != hash {
+		t.Errorf("GetByDigest(md5) = %q, %v, %v; want %q", got, hit, err, hash)
+	}
+	// An upstream sha256 is the blob key itself.
+	if got, _, hit, _ := c.GetByDigest(ctx, "sha256", strings.ToUpper(hash)); !hit || got != hash {
+		t.Errorf("GetByDigest(sha256) = %q, %v", got, hit)
+	}
+	// Short checksums are never trusted as aliases.
+	if err := c.AddDigest(ctx, hash, "crc32c", "e3069283"); err != nil {
+		t.Fatalf("AddDigest(crc32c): %v", err)
+	}
+	if _, _, hit, _ := c.GetByDigest(ctx, "crc32c", "e3069283"); hit {
+		t.Error("crc32c alias recorded")
+	}
+
+	if err := c.Evict(ctx, hash); err != nil {
+		t.Fatalf("Evict: %v", err)
+	}
+	if _, _, hit, _ := c.GetByDigest(ctx, "md5", md5); hit {
+		t.Error("digest alias survived Evict")
+	}
+}
+
 func TestMaterialize(t *testing.T) {
 	c := openTestCache(t)
 	ctx := context.Background()
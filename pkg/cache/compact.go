@@ -0,0 +1,363 @@
+package cache
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Blob codecs recorded in blobs.codec. A blob is stored raw at blobPath
+// unless it has been compacted, in which case only compressedPath exists.
+// The sha256 key is always the hash of the original bytes. Compaction
+// writes zstd; gzip blobs from earlier versions are still read.
+const (
+	codecRaw  = ""     // not yet considered for compaction
+	codecNone = "none" // considered and incompressible; stays raw
+	codecZstd = "zstd"
+	codecGzip = "gzip"
+)
+
+// compressedCodecs lists the codecs of compacted blobs, in the order their
+// files are looked for, with the suffix each adds to blobPath.
+var compressedCodecs = []struct{ codec, suffix string }{
+	{codecZstd, ".zst"},
+	{codecGzip, ".gz"},
+}
+
+// Compaction thresholds. Blobs below minCompactSize are not worth the
+// trouble; compactSample bytes are test-compressed first so already
+// compressed data (.gz FASTQ, .h5ad) is not read in full; a blob is only
+// kept compressed if it shrinks to compactMaxRatio of its size or less.
+const (
+	minCompactSize  = 64 << 10
+	compactSample   = 1 << 20
+	compactMaxRatio = 0.9
+)
+
+// compressedPath returns where the compressed form of a blob lives: the
+// file of whichever codec is on disk, or where compaction would write it.
+func (c *Cache) compressedPath(sha256hex string) string {
+	path, _ := c.compressedFile(sha256hex)
+	return path
+}
+
+// compressedFile returns the compressed file of a blob and its codec, or
+// the zstd path and codecRaw when there is none.
+func (c *Cache) compressedFile(sha256hex string) (path, codec string) {
+	for _, cc := range compressedCodecs {
+		p := c.blobPath(sha256hex) + cc.suffix
+		if _, err := os.Stat(p); err == nil {
+			return p, cc.codec
+		}
+	}
+	return c.blobPath(sha256hex) + compressedCodecs[0].suffix, codecRaw
+}
+
+// removeCompressed removes a blob's compressed files of every codec.
+func (c *Cache) removeCompressed(sha256hex string) {
+	for _, cc := range compressedCodecs {
+		_ = os.Remove(c.blobPath(sha256hex) + cc.suffix)
+	}
+}
+
+// CompactResult holds the outcome of a Compact run.
+type CompactResult struct {
+	// Compacted counts blobs compressed (on a dry run: eligible).
+	Compacted int
+	// Before and After are those blobs' bytes on disk before and after
+	// compression. After is 0 on a dry run.
+	Before int64
+	After  int64
+	// Incompressible counts blobs left raw because compression did not pay off.
+	Incompressible int
+	// Skipped counts blobs left alone because output directories hardlink
+	// them, so compressing would not free any space.
+	Skipped int
+	DryRun  bool
+}
+
+// Compact compresses blobs not used within olderThan. Compressed blobs keep
+// their sha256 key; Materialize decompresses them into a private copy, so
+// they are never hardlinked or reflinked. Compaction is refused with
+// link_strategy = "symlink", whose outputs point at the raw blob files.
+// c.mu is only held to record each blob's codec, so downloads are not held
+// up while large blobs are compressed.
+func (c *Cache) Compact(ctx context.Context, olderThan time.Duration, dryRun bool) (CompactResult, error) {
+	res := CompactResult{DryRun: dryRun}
+	if c.cfg.LinkStrategy == StrategySymlink {
+		return res, errors.New(`cache compaction is disabled with link_strategy = "symlink": outputs link to the raw blob files`)
+	}
+
+	type candidate struct {
+		sha256 string
+		size   int64
+	}
+	rows, err := c.db.QueryContext(ctx,
+		`SELECT sha256, size FROM blobs WHERE codec = ? AND last_used < ? AND size >= ? ORDER BY last_used ASC`,
+		codecRaw, time.Now().Add(-olderThan).Unix(), minCompactSize)
+	if err != nil {
+		return res, err
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var b candidate
+		if err := rows.Scan(&b.sha256, &b.size); err != nil {
+			continue
+		}
+		candidates = append(candidates, b)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return res, err
+	}
+
+	for _, b := range candidates {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		if blobNlink(c.blobPath(b.sha256)) > 1 {
+			res.Skipped++
+			continue
+		}
+		if dryRun {
+			res.Compacted++
+			res.Before += b.size
+			continue
+		}
+
+		codec, stored, err := c.compressBlob(b.sha256)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // evicted since the query
+		} else if err != nil {
+			return res, fmt.Errorf("compact %s: %w", b.sha256[:16], err)
+		}
+		recorded, err := c.recordCodec(ctx, b.sha256, codec, stored)
+		if err != nil {
+			return res, fmt.Errorf("compact %s: %w", b.sha256[:16], err)
+		}
+		if !recorded {
+			continue
+		}
+		if codec != codecNone {
+			res.Compacted++
+			res.Before += b.size
+			res.After += stored
+		} else {
+			res.Incompressible++
+		}
+	}
+	return res, nil
+}
+
+// recordCodec stores the outcome of compressBlob under c.mu. It reports
+// false, and removes the compressed file, if the blob was evicted while it
+// was being compressed.
+func (c *Cache) recordCodec(ctx context.Context, sha256hex, codec string, stored int64) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, err := c.db.ExecContext(ctx, `UPDATE blobs SET codec = ?, stored_size = ? WHERE sha256 = ?`,
+		codec, stored, sha256hex)
+	if err != nil {
+		return false, err
+	}
+	if n, err := r.RowsAffected(); err == nil && n == 0 {
+		c.removeCompressed(sha256hex)
+		return false, nil
+	}
+	return true, nil
+}
+
+// compressBlob replaces the raw blob file with its compressed form and
+// returns the codec and bytes now on disk. Incompressible blobs are left raw
+// with codecNone. The compressed file is in place before the raw one is
+// removed, so a concurrent reader always finds one of them.
+func (c *Cache) compressBlob(sha256hex string) (codec string, stored int64, err error) {
+	raw := c.blobPath(sha256hex)
+	info, err := os.Stat(raw)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Interrupted after the raw file went: finish the bookkeeping.
+		if path, codec := c.compressedFile(sha256hex); codec != codecRaw {
+			return codec, fileSizeOrZero(path), nil
+		}
+		return "", 0, err
+	} else if err != nil {
+		return "", 0, err
+	}
+
+	if ok, err := sampleCompresses(raw); err != nil || !ok {
+		return codecNone, info.Size(), err
+	}
+
+	tmp, err := c.NewTmpFile()
+	if err != nil {
+		return "", 0, err
+	}
+	tmpPath := tmp.Name()
+	stored, err = zstdFile(raw, tmp)
+	if closeErr := tmp.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return "", 0, err
+	}
+	if float64(stored) > compactMaxRatio*float64(info.Size()) {
+		_ = os.Remove(tmpPath)
+		return codecNone, info.Size(), nil
+	}
+
+	if err := os.Rename(tmpPath, c.blobPath(sha256hex)+".zst"); err != nil {
+		_ = os.Remove(tmpPath)
+		return "", 0, err
+	}
+	if err := os.Remove(raw); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", 0, err
+	}
+	return codecZstd, stored, nil
+}
+
+// sampleCompresses reports whether the first compactSample bytes of path
+// compress well enough to be worth compressing the whole file.
+func sampleCompresses(path string) (bool, error) {
+	f, err := os.Open(filepath.Clean(path)) // #nosec G304 -- internal cache path
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	var out countingWriter
+	zw, err := zstd.NewWriter(&out)
+	if err != nil {
+		return false, err
+	}
+	n, err := io.Copy(zw, io.LimitReader(f, compactSample))
+	if err != nil {
+		return false, err
+	}
+	if err := zw.Close(); err != nil {
+		return false, err
+	}
+	return float64(out) <= compactMaxRatio*float64(n), nil
+}
+
+// zstdFile writes the zstd-compressed contents of src to w and returns the
+// compressed size.
+func zstdFile(src string, w io.Writer) (int64, error) {
+	in, err := os.Open(filepath.Clean(src)) // #nosec G304 -- internal cache path
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out := new(countingWriter)
+	zw, err := zstd.NewWriter(io.MultiWriter(w, out))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := zw.ReadFrom(in); err != nil {
+		_ = zw.Close()
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	return int64(*out), nil
+}
+
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// openBlob opens a blob for reading its original bytes, decompressing it if
+// it has been compacted.
+func (c *Cache) openBlob(sha256hex string) (rc io.ReadCloser, compressed bool, err error) {
+	f, err := os.Open(filepath.Clean(c.blobPath(sha256hex))) // #nosec G304 -- internal cache path
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return f, false, err
+	}
+	path, codec := c.compressedFile(sha256hex)
+	if codec == codecRaw {
+		return nil, false, err // report the raw path, as before compaction existed
+	}
+	cf, cfErr := os.Open(filepath.Clean(path)) // #nosec G304 -- internal cache path
+	if cfErr != nil {
+		return nil, false, err
+	}
+	var r io.ReadCloser
+	switch codec {
+	case codecZstd:
+		var zr *zstd.Decoder
+		if zr, err = zstd.NewReader(cf); err == nil {
+			r = zr.IOReadCloser()
+		}
+	case codecGzip:
+		r, err = gzip.NewReader(cf)
+	}
+	if err != nil {
+		_ = cf.Close()
+		return nil, false, fmt.Errorf("blob %s: %w", sha256hex[:16], err)
+	}
+	return &compressedBlob{ReadCloser: r, f: cf}, true, nil
+}
+
+// compressedBlob closes both the decompressing stream and the underlying
+// file.
+type compressedBlob struct {
+	io.ReadCloser
+	f *os.File
+}
+
+func (b *compressedBlob) Close() error {
+	err := b.ReadCloser.Close()
+	if closeErr := b.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// decompressTo writes the original bytes of a compacted blob to destPath.
+func (c *Cache) decompressTo(sha256hex, destPath string) error {
+	in, _, err := c.openBlob(sha256hex)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o750); err != nil {
+		return fmt.Errorf("create dest dir: %w", err)
+	}
+	// As in tryLink: never write through an existing file, which may be a
+	// hardlink to another blob.
+	_ = os.Remove(destPath)
+	out, err := os.Create(filepath.Clean(destPath)) // #nosec G304 -- caller-controlled destination
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(destPath)
+		return err
+	}
+	return out.Close()
+}
+
+// CompressedStats reports how many blobs are compacted, their original size
+// and the bytes they take on disk.
+func (c *Cache) CompressedStats(ctx context.Context) (count int, original, stored int64, err error) {
+	err = c.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(size), 0), COALESCE(SUM(stored_size), 0) FROM blobs WHERE codec IN (?, ?)`,
+		codecZstd, codecGzip).Scan(&count, &original, &stored)
+	return count, original, stored, err
+}
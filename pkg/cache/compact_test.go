@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompact(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir(), LinkStrategy: StrategyHardlink})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	now := time.Now()
+	old := now.Add(-100 * 24 * time.Hour)
+
+	text := strings.Repeat("ACGT sample barcode umi count\n", 8192) // ~240 KiB
+	random := make([]byte, 128<<10)
+	_, _ = rand.Read(random)
+	cold := seedBlob(t, c, text, old, old, 0)
+	noise := seedBlob(t, c, string(random), old, old, 0)
+	warm := seedBlob(t, c, text+"warm", now, now, 0)
+
+	res, err := c.Compact(ctx, 90*24*time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Compacted != 1 || res.Incompressible != 1 || res.After >= res.Before {
+		t.Fatalf("Compact = %+v, want one compressed and one incompressible blob", res)
+	}
+	if _, err := os.Stat(c.blobPath(cold)); !os.IsNotExist(err) {
+		t.Errorf("raw blob still present: %v", err)
+	}
+	if path, codec := c.compressedFile(cold); codec != codecZstd || filepath.Ext(path) != ".zst" {
+		t.Errorf("compressed file = %s (%q), want zstd", path, codec)
+	}
+	for _, hash := range []string{noise, warm} {
+		if _, err := os.Stat(c.blobPath(hash)); err != nil {
+			t.Errorf("blob %s should stay raw: %v", hash[:8], err)
+		}
+	}
+	if total, _ := c.TotalSize(ctx); total != int64(len(random)+len(text)+4)+res.After {
+		t.Errorf("TotalSize = %d, want the compressed size counted", total)
+	}
+
+	// A compacted blob is still a hit and materializes to the original bytes
+	// as a private copy.
+	if _, _, hit, _ := c.Get(ctx, "https://example.com/"+cold[:8]); !hit {
+		t.Fatal("compacted blob is a miss")
+	}
+	dest := filepath.Join(t.TempDir(), "out", "counts.tsv")
+	if err := c.Materialize(cold, dest); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil || string(got) != text {
+		t.Fatalf("materialized %d bytes (%v), want the original %d", len(got), err, len(text))
+	}
+	if blobNlink(dest) != 1 {
+		t.Error("compacted blob materialized as a link")
+	}
+	if ok, err := c.VerifyBlob(ctx, cold); !ok || err != nil {
+		t.Errorf("VerifyBlob = %v, %v", ok, err)
+	}
+
+	// Nothing left to do on a second run.
+	if res, err := c.Compact(ctx, 90*24*time.Hour, false); err != nil || res.Compacted+res.Incompressible != 0 {
+		t.Errorf("second Compact = %+v, %v", res, err)
+	}
+
+	// Peers get the decompressed bytes.
+	srv := httptest.NewServer(NewServer(c, "").Handler())
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/v1/blob/" + cold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != text {
+		t.Errorf("served %d, %d bytes; want the original %d", resp.StatusCode, len(body), len(text))
+	}
+}
+
+func TestCompact_RefusesSymlinks(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir(), LinkStrategy: StrategySymlink})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.Compact(context.Background(), 0, false); err == nil {
+		t.Fatal("Compact with symlinked outputs should fail")
+	}
+}
+
+// TestCompact_BlobEvictedWhileCompressing checks that a blob evicted between
+// compression and recording its codec leaves no compressed file behind.
+func TestCompact_BlobEvictedWhileCompressing(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	old := time.Now().Add(-100 * 24 * time.Hour)
+	hash := seedBlob(t, c, strings.Repeat("ACGT sample barcode umi count\n", 8192), old, old, 0)
+
+	codec, stored, err := c.compressBlob(hash)
+	if err != nil || codec != codecZstd {
+		t.Fatalf("compressBlob = %q, %v", codec, err)
+	}
+	if err := c.Evict(ctx, hash); err != nil {
+		t.Fatal(err)
+	}
+	// Eviction ran before the compressed file was in place.
+	if err := os.WriteFile(c.compressedPath(hash), []byte("zst"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := c.recordCodec(ctx, hash, codec, stored); ok || err != nil {
+		t.Errorf("recordCodec = %v, %v; want false for an evicted blob", ok, err)
+	}
+	if _, err := os.Stat(c.compressedPath(hash)); !os.IsNotExist(err) {
+		t.Errorf("compressed file of an evicted blob left behind: %v", err)
+	}
+}
+
+// TestCompact_ReadsGzipBlobs checks that blobs compacted with gzip by earlier
+// versions are still served and counted.
+func TestCompact_ReadsGzipBlobs(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	old := time.Now().Add(-100 * 24 * time.Hour)
+	text := strings.Repeat("ACGT sample barcode umi count\n", 8192)
+	hash := seedBlob(t, c, text, old, old, 0)
+
+	f, err := os.Create(c.blobPath(hash) + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(f)
+	_, _ = io.WriteString(zw, text)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+	if err := os.Remove(c.blobPath(hash)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.recordCodec(ctx, hash, codecGzip, fileSizeOrZero(c.blobPath(hash)+".gz")); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "counts.tsv")
+	if err := c.Materialize(hash, dest); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(dest); err != nil || string(got) != text {
+		t.Fatalf("materialized %d bytes (%v), want the original %d", len(got), err, len(text))
+	}
+	if n, original, _, err := c.CompressedStats(ctx); err != nil || n != 1 || original != int64(len(text)) {
+		t.Errorf("CompressedStats = %d, %d, %v; want the gzip blob counted", n, original, err)
+	}
+	if err := c.Evict(ctx, hash); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c.blobPath(hash) + ".gz"); !os.IsNotExist(err) {
+		t.Errorf("gzip file left behind by Evict: %v", err)
+	}
+}
@@ -23,7 +23,7 @@ import (
 //   - common.Fetch — delegates to pkg/downloaders/common.Fetch. Covered
 //     transitively by TestCommonFetchCacheContract.
 //   - inline       — re-implements the cache flow using cache.FromContext.
-//     Each instance must have its own behavioral test (see geo, sra,
+//     Each instance must have its own behavioral test (see geo,
 //     experimenthub).
 //   - exception    — deliberately bypasses the cache. Must be allowlisted
 //     in static_test.go with a justification.
@@ -36,9 +36,11 @@ var cachePatternByDownloader = map[string]string{
 	"NewFigshareDownloader":      "common.Fetch",
 	"NewZenodoDownloader":        "common.Fetch",
 	"NewEnsemblDownloader":       "exception",  // FTP/multi-protocol, see static_test allowlist
-	"NewSRADownloader":           "inline",     // pkg/downloaders/sra
+	"NewSRADownloader":           "common.Fetch",
+	"NewGSADownloader":           "common.Fetch",
 	"NewVCPDownloader":           "common.Fetch",
 	"NewHCADownloader":           "common.Fetch",
+	"NewCellxgeneDownloader":     "common.Fetch",
 	"NewBioStudiesDownloader":    "common.Fetch",
 	"NewScPerturbDownloader":     "common.Fetch",
 	"NewExperimentHubDownloader": "inline",     // pkg/downloaders/experimenthub
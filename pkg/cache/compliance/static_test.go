@@ -54,6 +54,9 @@ var allowlist = map[string]string{
 	// match (it uses cache.FromContext via its caller's ctx, but the local
 	// streamToFile helper does not). Allowlisted by design.
 	"common/fetch.go": "sanctioned cache-aware download primitive",
+	// streamResumable is the Range-resume half of common.Fetch; Fetch decides
+	// where the partial lives (cache tmp/ or next to the target).
+	"common/resume.go": "resume helper called only by common.Fetch",
 
 	// Ensembl uses a custom MultiProtocolClient (HTTP + FTP) that pre-dates
 	// the cache and is tracked as a known exception. Integrating FTP into
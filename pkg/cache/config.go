@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -16,8 +17,40 @@ type Config struct {
 	MaxSize      int64
 	MinFreeDisk  int64
 	QuotaPolicy  string
+	// TTL is the maximum blob age for the "ttl" quota policy; 0 means blobs
+	// only go when the cache is over quota, oldest first.
+	TTL time.Duration
+	// CompressAfter is how long a blob must go unused before `cache gc`
+	// compresses it; 0 disables compaction during gc.
+	CompressAfter time.Duration
+	// Tiers are the `[[cache.tiers]]` entries in lookup order. The first
+	// writable tier is Dir; when none is writable, Dir is looked up last.
+	Tiers  []Tier
+	Server ServerConfig
 }
 
+// Tier is one `[[cache.tiers]]` entry. Read-only tiers, such as an
+// admin-managed shared cache, are only consulted for hits; hapiq never
+// writes to them.
+type Tier struct {
+	Dir      string `mapstructure:"dir"`
+	ReadOnly bool   `mapstructure:"read_only"`
+}
+
+// ServerConfig holds the `[cache.server]` keys. Listen and Token configure
+// `hapiq cache serve`; Peers lists other hapiq caches consulted on a local
+// miss. Token is also sent to peers, so a lab sharing one token needs no
+// per-peer setup.
+type ServerConfig struct {
+	Listen string
+	Token  string
+	Peers  []string
+}
+
+// DefaultListen is the address `hapiq cache serve` binds to when
+// cache.server.listen is unset.
+const DefaultListen = "127.0.0.1:7777"
+
 // DefaultDir returns the default cache directory (~/.cache/hapiq).
 func DefaultDir() string {
 	home, _ := os.UserHomeDir()
@@ -32,7 +65,12 @@ func RegisterDefaults() {
 	viper.SetDefault("cache.link_strategy", string(StrategyAuto))
 	viper.SetDefault("cache.max_size", "")
 	viper.SetDefault("cache.min_free_disk", "5GB")
-	viper.SetDefault("cache.quota_policy", "lru")
+	viper.SetDefault("cache.quota_policy", PolicyLRU)
+	viper.SetDefault("cache.ttl", "")
+	viper.SetDefault("cache.compress_after", "")
+	viper.SetDefault("cache.server.listen", DefaultListen)
+	viper.SetDefault("cache.server.token", "")
+	viper.SetDefault("cache.server.peers", []string{})
 }
 
 // ConfigFromViper builds a Config from the current Viper state.
@@ -41,9 +79,18 @@ func ConfigFromViper() Config {
 	if dir == "" {
 		dir = DefaultDir()
 	}
-	if strings.HasPrefix(dir, "~/") {
-		home, _ := os.UserHomeDir()
-		dir = filepath.Join(home, dir[2:])
+	dir = expandHome(dir)
+
+	var tiers []Tier
+	_ = viper.UnmarshalKey("cache.tiers", &tiers)
+	for i := range tiers {
+		tiers[i].Dir = expandHome(tiers[i].Dir)
+	}
+	for _, t := range tiers {
+		if !t.ReadOnly && t.Dir != "" {
+			dir = t.Dir
+			break
+		}
 	}
 
 	strategy := Strategy(viper.GetString("cache.link_strategy"))
@@ -51,17 +98,40 @@ func ConfigFromViper() Config {
 		strategy = StrategyAuto
 	}
 
-	policy := viper.GetString("cache.quota_policy")
+	policy := strings.ToLower(viper.GetString("cache.quota_policy"))
 	if policy == "" {
-		policy = "lru"
+		policy = PolicyLRU
+	}
+	ttl, _ := ParseAge(viper.GetString("cache.ttl"))
+	compressAfter, _ := ParseAge(viper.GetString("cache.compress_after"))
+
+	listen := viper.GetString("cache.server.listen")
+	if listen == "" {
+		listen = DefaultListen
 	}
 
 	return Config{
-		Mode:         viper.GetString("cache.mode"),
-		Dir:          dir,
-		LinkStrategy: strategy,
-		MaxSize:      ParseSizeDefault(viper.GetString("cache.max_size"), 0),
-		MinFreeDisk:  ParseSizeDefault(viper.GetString("cache.min_free_disk"), 5_000_000_000), // 5GB SI, matches RegisterDefaults
-		QuotaPolicy:  policy,
+		Mode:          viper.GetString("cache.mode"),
+		Dir:           dir,
+		LinkStrategy:  strategy,
+		MaxSize:       ParseSizeDefault(viper.GetString("cache.max_size"), 0),
+		MinFreeDisk:   ParseSizeDefault(viper.GetString("cache.min_free_disk"), 5_000_000_000), // 5GB SI, matches RegisterDefaults
+		QuotaPolicy:   policy,
+		TTL:           ttl,
+		CompressAfter: compressAfter,
+		Tiers:         tiers,
+		Server: ServerConfig{
+			Listen: listen,
+			Token:  viper.GetString("cache.server.token"),
+			Peers:  viper.GetStringSlice("cache.server.peers"),
+		},
+	}
+}
+
+func expandHome(dir string) string {
+	if strings.HasPrefix(dir, "~/") {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, dir[2:])
 	}
+	return dir
 }
@@ -70,6 +70,44 @@ func TestConfigFromViper_TOMLFile(t *testing.T) {
 	}
 }
 
+// TestConfigFromViper_Tiers verifies that [[cache.tiers]] tables keep their
+// order and that the first writable tier becomes Dir.
+func TestConfigFromViper_Tiers(t *testing.T) {
+	resetViper(t)
+
+	fakeHome := t.TempDir()
+	t.Setenv("HOME", fakeHome)
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "hapiqrc.toml")
+	body := "" +
+		"[cache]\n" +
+		"dir = \"/ignored\"\n" +
+		"[[cache.tiers]]\n" +
+		"dir = \"/shared/hapiq-cache\"\n" +
+		"read_only = true\n" +
+		"[[cache.tiers]]\n" +
+		"dir = \"~/.cache/hapiq\"\n"
+	if err := os.WriteFile(cfgPath, []byte(body), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cache.RegisterDefaults()
+	viper.SetConfigFile(cfgPath)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatalf("ReadInConfig: %v", err)
+	}
+
+	cfg := cache.ConfigFromViper()
+	userDir := filepath.Join(fakeHome, ".cache", "hapiq")
+	want := []cache.Tier{{Dir: "/shared/hapiq-cache", ReadOnly: true}, {Dir: userDir}}
+	if len(cfg.Tiers) != 2 || cfg.Tiers[0] != want[0] || cfg.Tiers[1] != want[1] {
+		t.Errorf("Tiers: got %+v, want %+v", cfg.Tiers, want)
+	}
+	if cfg.Dir != userDir {
+		t.Errorf("Dir: got %q, want the writable tier %q", cfg.Dir, userDir)
+	}
+}
+
 // TestConfigFromViper_TildeExpansion verifies that a `cache.dir` value
 // starting with `~/` is expanded against $HOME. This is a common config-file
 // idiom and silently broken if the expansion logic regresses.
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// aliasTypes are the upstream digest types the index records as aliases of a
+// blob. crc32c and BSD sum are too short to identify content and are left out.
+var aliasTypes = map[string]bool{"md5": true, "sha1": true, "sha256": true, "sha512": true}
+
+// digestKey normalises an upstream digest to its index key, "type:value" in
+// lowercase. ok is false for types that are not recorded.
+func digestKey(typ, value string) (key string, ok bool) {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	value = strings.ToLower(strings.TrimSpace(value))
+	if !aliasTypes[typ] || value == "" {
+		return "", false
+	}
+	return typ + ":" + value, true
+}
+
+// GetByDigest looks a blob up by a digest the repository publishes for it
+// (Zenodo's md5, ENA's fastq_md5, HCA's sha256, ...), so a file already cached
+// from another URL is a hit before any network I/O. A sha256 digest is the
+// blob key itself; other types go through aliases recorded by AddDigest.
+// Unsupported types are always a miss. Tiers are searched in lookup order.
+func (c *Cache) GetByDigest(ctx context.Context, typ, value string) (sha256hex string, size int64, hit bool, err error) {
+	for _, t := range c.lookupOrder() {
+		if sha256hex, size, hit, err = t.getByDigestLocal(ctx, typ, value); err != nil || hit {
+			c.countTierHit(ctx, t, hit)
+			return sha256hex, size, hit, err
+		}
+	}
+	return "", 0, false, nil
+}
+
+func (c *Cache) getByDigestLocal(ctx context.Context, typ, value string) (sha256hex string, size int64, hit bool, err error) {
+	key, ok := digestKey(typ, value)
+	if !ok {
+		return "", 0, false, nil
+	}
+	if strings.HasPrefix(key, "sha256:") {
+		return c.lookup(ctx, c.s.getBlob, strings.TrimPrefix(key, "sha256:"))
+	}
+	return c.lookup(ctx, c.s.getByDigest, key)
+}
+
+// AddDigest records typ:value as an alias of the blob sha256hex. Callers
+// should only record a digest they have verified against the blob. A no-op
+// for sha256 and for types that are not recorded.
+func (c *Cache) AddDigest(ctx context.Context, sha256hex, typ, value string) error {
+	key, ok := digestKey(typ, value)
+	if !ok || strings.HasPrefix(key, "sha256:") {
+		return nil
+	}
+	if _, err := c.s.insertAlias.ExecContext(ctx, key, sha256hex); err != nil {
+		return fmt.Errorf("record digest: %w", err)
+	}
+	return nil
+}
+
+// IndexURL points rawURL at the existing blob sha256hex, e.g. after a digest
+// hit, so the next lookup of rawURL is a plain URL hit.
+func (c *Cache) IndexURL(ctx context.Context, rawURL, sha256hex string) error {
+	canonical, err := canonicalizeURL(rawURL)
+	if err != nil {
+		return err
+	}
+	if _, err := c.s.insertURL.ExecContext(ctx, canonical, sha256hex, "", "", time.Now().Unix()); err != nil {
+		return fmt.Errorf("record url: %w", err)
+	}
+	return nil
+}
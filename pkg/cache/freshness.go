@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// FreshnessMode says when a cached URL must be checked with the origin.
+type FreshnessMode string
+
+const (
+	// FreshImmutable serves a cached URL forever. Right for content behind a
+	// DOI or an accession, which does not change once published.
+	FreshImmutable FreshnessMode = "immutable"
+	// FreshMaxAge serves a cached URL for MaxAge after it was last fetched or
+	// revalidated, then revalidates it.
+	FreshMaxAge FreshnessMode = "max-age"
+	// FreshRevalidate checks with the origin on every use, with
+	// If-None-Match / If-Modified-Since so an unchanged file costs one 304.
+	FreshRevalidate FreshnessMode = "revalidate"
+)
+
+// Freshness is the policy for serving a cached URL without asking the
+// origin. The zero value is immutable.
+type Freshness struct {
+	Mode   FreshnessMode
+	MaxAge time.Duration
+}
+
+// Fresh reports whether an entry last fetched or revalidated at fetchedAt can
+// be served at now without revalidation.
+func (f Freshness) Fresh(fetchedAt, now time.Time) bool {
+	switch f.Mode {
+	case FreshRevalidate:
+		return false
+	case FreshMaxAge:
+		return now.Sub(fetchedAt) < f.MaxAge
+	}
+	return true
+}
+
+// defaultFreshness holds the built-in modes for sources whose URLs can
+// change content in place. Every other source is immutable.
+var defaultFreshness = map[string]FreshnessMode{
+	"url":    FreshRevalidate,
+	"scanpy": FreshRevalidate,
+}
+
+// FreshnessFor resolves the freshness policy for source: the built-in
+// default, then the [cache.freshness] keys, then [cache.freshness.<source>].
+// Each level may set mode and max_age; max_age alone implies mode "max-age".
+// Invalid values are ignored.
+func FreshnessFor(source string) Freshness {
+	source = strings.ToLower(source)
+	f := Freshness{Mode: FreshImmutable}
+	if m, ok := defaultFreshness[source]; ok {
+		f.Mode = m
+	}
+	for _, prefix := range []string{"cache.freshness.", "cache.freshness." + source + "."} {
+		age, ageErr := ParseAge(viper.GetString(prefix + "max_age"))
+		if ageErr == nil && age > 0 {
+			f.MaxAge = age
+		}
+		switch m := FreshnessMode(strings.ToLower(viper.GetString(prefix + "mode"))); m {
+		case FreshImmutable, FreshMaxAge, FreshRevalidate:
+			f.Mode = m
+		case "":
+			if ageErr == nil && age > 0 {
+				f.Mode = FreshMaxAge
+			}
+		}
+	}
+	return f
+}
+
+// ParseAge parses a duration as time.ParseDuration does, additionally
+// accepting whole days ("7d"). An empty string is zero.
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
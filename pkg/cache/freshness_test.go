@@ -0,0 +1,52 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/btraven00/hapiq/pkg/cache"
+)
+
+func TestFreshnessFor(t *testing.T) {
+	resetViper(t)
+
+	if f := cache.FreshnessFor("zenodo"); f.Mode != cache.FreshImmutable {
+		t.Errorf("zenodo default = %+v, want immutable", f)
+	}
+	if f := cache.FreshnessFor("url"); f.Mode != cache.FreshRevalidate {
+		t.Errorf("url default = %+v, want revalidate", f)
+	}
+
+	viper.Set("cache.freshness.url.max_age", "7d")
+	if f := cache.FreshnessFor("URL"); f.Mode != cache.FreshMaxAge || f.MaxAge != 7*24*time.Hour {
+		t.Errorf("url with max_age = %+v, want max-age 168h", f)
+	}
+
+	viper.Set("cache.freshness.mode", "revalidate")
+	viper.Set("cache.freshness.figshare.mode", "bogus")
+	if f := cache.FreshnessFor("figshare"); f.Mode != cache.FreshRevalidate {
+		t.Errorf("figshare = %+v, want the global revalidate (invalid per-source mode ignored)", f)
+	}
+}
+
+func TestFreshness_Fresh(t *testing.T) {
+	now := time.Now()
+	hourAgo := now.Add(-time.Hour)
+	tests := []struct {
+		f    cache.Freshness
+		want bool
+	}{
+		{cache.Freshness{}, true},
+		{cache.Freshness{Mode: cache.FreshImmutable}, true},
+		{cache.Freshness{Mode: cache.FreshRevalidate}, false},
+		{cache.Freshness{Mode: cache.FreshMaxAge, MaxAge: 2 * time.Hour}, true},
+		{cache.Freshness{Mode: cache.FreshMaxAge, MaxAge: 30 * time.Minute}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.f.Fresh(hourAgo, now); got != tt.want {
+			t.Errorf("%+v.Fresh(1h ago) = %v, want %v", tt.f, got, tt.want)
+		}
+	}
+}
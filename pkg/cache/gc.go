@@ -2,12 +2,16 @@ package cache
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"time"
 )
 
-// TotalSize returns the sum of all blob sizes recorded in the index.
+// TotalSize returns the sum of all blob sizes recorded in the index, counting
+// compacted blobs at their compressed size.
 func (c *Cache) TotalSize(ctx context.Context) (int64, error) {
 	var n int64
 	if err := c.s.totalSize.QueryRowContext(ctx).Scan(&n); err != nil {
@@ -17,17 +21,28 @@ func (c *Cache) TotalSize(ctx context.Context) (int64, error) {
 }
 
 // checkQuota returns an error if admitting blobSize bytes would violate the
-// configured max_size or min_free_disk. Must be called with c.mu held.
-func (c *Cache) checkQuota(ctx context.Context, blobSize int64) error {
+// configured max_size or min_free_disk. Pinned blobs count against max_size
+// like any other; GC cannot evict them, so once they fill the quota nothing
+// new is admitted until some are unpinned. Must be called with c.mu held,
+// inside the transaction that admits the blob so the total cannot change
+// under it.
+func (c *Cache) checkQuota(ctx context.Context, tx *sql.Tx, blobSize int64) error {
 	if c.cfg.MaxSize > 0 {
-		var total int64
-		if err := c.s.totalSize.QueryRowContext(ctx).Scan(&total); err != nil {
+		var total, pinned int64
+		if err := tx.StmtContext(ctx, c.s.quotaSize).QueryRowContext(ctx).Scan(&total, &pinned); err != nil {
 			return fmt.Errorf("read total size: %w", err)
 		}
 		if total+blobSize > c.cfg.MaxSize {
+			hint := "run 'hapiq cache gc' to free space"
+			switch {
+			case pinned+blobSize > c.cfg.MaxSize:
+				hint = fmt.Sprintf("pinned blobs take %s; unpin some with 'hapiq cache unpin' or raise max_size", formatSize(pinned))
+			case c.Policy() == PolicyNever:
+				hint = "quota_policy is never; remove blobs with 'hapiq cache evict'"
+			}
 			return fmt.Errorf(
-				"cache quota exceeded: %s used + %s new > %s limit (run 'hapiq cache gc' to free space)",
-				formatSize(total), formatSize(blobSize), formatSize(c.cfg.MaxSize),
+				"cache quota exceeded: %s used + %s new > %s limit (%s)",
+				formatSize(total), formatSize(blobSize), formatSize(c.cfg.MaxSize), hint,
 			)
 		}
 	}
@@ -45,14 +60,16 @@ func (c *Cache) checkQuota(ctx context.Context, blobSize int64) error {
 	return nil
 }
 
-// Evict removes a blob and all its URL mappings from the cache.
+// Evict removes a blob and all its URL and digest mappings and pins from the
+// cache.
 func (c *Cache) Evict(ctx context.Context, sha256hex string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	return c.evictLocked(ctx, sha256hex)
 }
 
-// evictLocked removes blob + URLs from the DB and filesystem.
+// evictLocked removes blob + URLs + digests + pins + refs from the DB and
+// filesystem.
 // Caller must hold c.mu.
 func (c *Cache) evictLocked(ctx context.Context, sha256hex string) error {
 	tx, err := c.db.BeginTx(ctx, nil)
@@ -64,6 +81,15 @@ func (c *Cache) evictLocked(ctx context.Context, sha256hex string) error {
 	if _, err := tx.ExecContext(ctx, `DELETE FROM urls WHERE sha256 = ?`, sha256hex); err != nil {
 		return err
 	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM digests WHERE sha256 = ?`, sha256hex); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM pins WHERE sha256 = ?`, sha256hex); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM refs WHERE sha256 = ?`, sha256hex); err != nil {
+		return err
+	}
 	if _, err := tx.ExecContext(ctx, `DELETE FROM blobs WHERE sha256 = ?`, sha256hex); err != nil {
 		return err
 	}
@@ -72,106 +98,157 @@ func (c *Cache) evictLocked(ctx context.Context, sha256hex string) error {
 	}
 
 	_ = os.Remove(c.blobPath(sha256hex))
+	c.removeCompressed(sha256hex)
 	return nil
 }
 
 // GCResult holds the outcome of a GC run.
 type GCResult struct {
-	Evicted int
-	Freed   int64
+	// Policy is the quota policy that chose the candidates.
+	Policy string
+	// Candidates are the blobs chosen for eviction, in eviction order, each
+	// with the policy's reason. On a dry run nothing was removed.
+	Candidates []GCCandidate
+	Evicted    int
+	Freed      int64
 	// Skipped counts blobs that were candidates for eviction but were skipped
-	// because they have live hardlinks (Nlink > 1) from output directories.
+	// because output directories still link to them: hardlinks (Nlink > 1)
+	// or recorded refs that are symlinks into the store.
 	Skipped int
 	DryRun  bool
 }
 
-// GC evicts blobs by LRU until the cache is under max_size.
+// GCCandidate is a blob GC chose to evict.
+type GCCandidate struct {
+	SHA256 string
+	Reason string
+	Size   int64
+}
+
+// GC evicts blobs until the cache is under max_size, choosing them by the
+// configured quota policy (see Policies). Pinned blobs are never evicted but
+// count against max_size, so when they alone exceed it every other blob goes
+// and the cache stays over quota. Under the ttl policy it also evicts
+// every blob older than the configured ttl, quota or not; under never it
+// evicts nothing and returns an error while over quota.
+// Orphans (see GCOrphans) are evicted first under every policy.
 // When keepDuration > 0, blobs accessed within that duration are spared.
 func (c *Cache) GC(ctx context.Context, dryRun bool, keepDuration time.Duration) (GCResult, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if c.cfg.MaxSize == 0 {
-		return GCResult{DryRun: dryRun}, nil
+	policy := c.Policy()
+	res := GCResult{DryRun: dryRun, Policy: policy}
+	if !validPolicy(policy) {
+		return res, fmt.Errorf("unknown cache.quota_policy %q (want one of %s)", policy, strings.Join(Policies, ", "))
 	}
 
-	var total int64
-	if err := c.s.totalSize.QueryRowContext(ctx).Scan(&total); err != nil {
-		return GCResult{}, err
+	var total, pinned int64
+	if err := c.s.quotaSize.QueryRowContext(ctx).Scan(&total, &pinned); err != nil {
+		return res, err
 	}
-
-	target := total - c.cfg.MaxSize
-	if target <= 0 {
-		return GCResult{DryRun: dryRun}, nil
+	var over int64
+	if c.cfg.MaxSize > 0 {
+		over = total - c.cfg.MaxSize
+	}
+	expiry := policy == PolicyTTL && c.cfg.TTL > 0
+	if over <= 0 && !expiry {
+		return res, nil
+	}
+	if policy == PolicyNever {
+		return res, fmt.Errorf(
+			"cache is %s over its %s quota and cache.quota_policy is %q: remove blobs with 'hapiq cache evict' or raise max_size",
+			formatSize(over), formatSize(c.cfg.MaxSize), PolicyNever,
+		)
 	}
 
-	rows, err := c.s.listLRU.QueryContext(ctx)
+	usages, err := c.refUsages(ctx)
 	if err != nil {
-		return GCResult{}, err
+		return res, err
 	}
-	defer rows.Close()
-
-	keepAfter := int64(0)
-	if keepDuration > 0 {
-		keepAfter = time.Now().Add(-keepDuration).Unix()
+	blobs, err := c.gcBlobs(ctx)
+	if err != nil {
+		return res, err
 	}
+	now := time.Now()
+	orderForEviction(policy, blobs, now)
+	// Orphans go first whatever the policy: nothing uses them any more.
+	sort.SliceStable(blobs, func(i, j int) bool {
+		return usages[blobs[i].sha256].orphaned() && !usages[blobs[j].sha256].orphaned()
+	})
 
-	type candidate struct {
-		sha256   string
-		size     int64
-		lastUsed int64
-	}
-	var toEvict []candidate
+	keepAfter := now.Add(-keepDuration)
 	var freed int64
-	for rows.Next() && freed < target {
-		var hash string
-		var size, lastUsed int64
-		if err := rows.Scan(&hash, &size, &lastUsed); err != nil {
+	for _, b := range blobs {
+		expired := expiry && now.Sub(b.created) > c.cfg.TTL
+		if !expired && freed >= over {
 			continue
 		}
-		if keepDuration > 0 && lastUsed >= keepAfter {
+		if keepDuration > 0 && !b.lastUsed.Before(keepAfter) {
 			continue
 		}
-		toEvict = append(toEvict, candidate{hash, size, lastUsed})
-		freed += size
-	}
-	if err := rows.Err(); err != nil {
-		return GCResult{}, err
-	}
-
-	// Filter out pinned blobs before reporting or acting.
-	var unpinned []candidate
-	skipped := 0
-	for _, e := range toEvict {
-		if blobNlink(c.blobPath(e.sha256)) > 1 {
-			skipped++
-		} else {
-			unpinned = append(unpinned, e)
+		u := usages[b.sha256]
+		if u.symlinked > 0 || blobNlink(c.blobPath(b.sha256)) > 1 {
+			res.Skipped++
+			continue
+		}
+		reason := evictionReason(policy, b, expired, c.cfg.TTL, now)
+		if u.orphaned() {
+			reason = orphanReason(u.total)
 		}
+		res.Candidates = append(res.Candidates, GCCandidate{
+			SHA256: b.sha256,
+			Size:   b.size,
+			Reason: reason,
+		})
+		freed += b.size
 	}
 
-	res := GCResult{DryRun: dryRun, Freed: freed, Evicted: len(unpinned), Skipped: skipped}
 	if dryRun {
+		res.Evicted, res.Freed = len(res.Candidates), freed
 		return res, nil
 	}
-
-	res.Freed = 0
-	res.Evicted = 0
-	for _, e := range unpinned {
-		blobSz := fileSizeOrZero(c.blobPath(e.sha256))
-		if err := c.evictLocked(ctx, e.sha256); err == nil {
+	for _, e := range res.Candidates {
+		if err := c.evictLocked(ctx, e.SHA256); err == nil {
 			res.Evicted++
-			res.Freed += blobSz
+			res.Freed += e.Size
 		}
 	}
 	return res, nil
 }
 
+// gcBlobs reads every unpinned blob row. The rows are closed before GC evicts
+// anything: the index has a single connection.
+func (c *Cache) gcBlobs(ctx context.Context) ([]gcBlob, error) {
+	rows, err := c.s.listGC.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []gcBlob
+	for rows.Next() {
+		var b gcBlob
+		var created, lastUsed int64
+		if err := rows.Scan(&b.sha256, &b.size, &created, &lastUsed, &b.hits); err != nil {
+			continue
+		}
+		b.created, b.lastUsed = time.Unix(created, 0), time.Unix(lastUsed, 0)
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
 // PruneURLs removes url rows whose corresponding blob is missing from the index.
+// Dangling digest aliases are dropped along with them but not counted.
 func (c *Cache) PruneURLs(ctx context.Context) (int, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if _, err := c.db.ExecContext(ctx,
+		`DELETE FROM digests WHERE sha256 NOT IN (SELECT sha256 FROM blobs)`); err != nil {
+		return 0, err
+	}
 	result, err := c.db.ExecContext(ctx,
 		`DELETE FROM urls WHERE sha256 NOT IN (SELECT sha256 FROM blobs)`)
 	if err != nil {
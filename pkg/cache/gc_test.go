@@ -2,6 +2,8 @@ package cache_test
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/btraven00/hapiq/pkg/cache"
@@ -93,3 +95,38 @@ func TestPruneURLs(t *testing.T) {
 	// already be gone. PruneURLs is still correct if it returns 0.
 	_ = pruned
 }
+
+// TestQuotaAcrossProcesses checks that two Cache handles on one directory
+// (as two hapiq processes would have) cannot both pass the quota check when
+// only one blob fits.
+func TestQuotaAcrossProcesses(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	content := [][]byte{[]byte("first blob, 20 bytes"), []byte("second blob, 21 byte")}
+
+	errs := make(chan error, 2)
+	for i := range content {
+		c, err := cache.Open(cache.Config{Dir: dir, MaxSize: 30})
+		if err != nil {
+			t.Fatalf("cache.Open: %v", err)
+		}
+		defer c.Close()
+		tmpPath, hash := writeTmp(t, c, content[i])
+		go func(url string) {
+			errs <- c.Put(ctx, url, tmpPath, hash)
+		}(fmt.Sprintf("https://example.com/%d", i))
+	}
+
+	failed := 0
+	for range content {
+		if err := <-errs; err != nil {
+			if !strings.Contains(err.Error(), "quota") {
+				t.Fatalf("Put: %v", err)
+			}
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("%d Puts refused, want exactly 1", failed)
+	}
+}
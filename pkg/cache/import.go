@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrImportNeedsCopy is returned by Import when path can be neither
+// reflinked nor hardlinked into the store, typically because it lives on
+// another filesystem, and copying was not allowed.
+var ErrImportNeedsCopy = errors.New("cannot reflink or hardlink into the cache (different filesystem?)")
+
+// Import adds an existing file, downloaded from rawURL before the cache was
+// in use, so later fetches of rawURL are hits. The file is reflinked into
+// the store, else hardlinked; with allowCopy it is copied as a last resort.
+// The content is hashed once linked and must match sha256hex when that is
+// given. path is recorded as a reference to the blob.
+//
+// added is false when the blob was already cached; rawURL is then only
+// indexed and the file is not linked.
+func (c *Cache) Import(ctx context.Context, rawURL, path, sha256hex string, allowCopy bool) (hash string, added bool, err error) {
+	canonical, err := canonicalizeURL(rawURL)
+	if err != nil {
+		return "", false, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false, err
+	}
+	if !info.Mode().IsRegular() {
+		return "", false, fmt.Errorf("%s is not a regular file", path)
+	}
+
+	tmp, err := c.linkIntoTmp(path, allowCopy)
+	if err != nil {
+		return "", false, err
+	}
+	defer os.Remove(tmp) //nolint:errcheck // gone once promoted
+
+	hash, err = hashFile(tmp)
+	if err != nil {
+		return "", false, err
+	}
+	if sha256hex != "" && !strings.EqualFold(hash, sha256hex) {
+		return "", false, fmt.Errorf("sha256 mismatch: file has %s, witness records %s", shortHash(hash), shortHash(sha256hex))
+	}
+
+	if added, err = c.admitImport(ctx, canonical, tmp, hash, info.Size()); err != nil {
+		return "", false, err
+	}
+	c.recordRef(ctx, hash, path)
+	return hash, added, nil
+}
+
+// admitImport promotes tmp to the blob hash, unless the store already holds
+// it, and points canonical at it. added reports whether tmp was promoted.
+func (c *Cache) admitImport(ctx context.Context, canonical, tmp, hash string, size int64) (added bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin import: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	now := time.Now().Unix()
+	blob := c.blobPath(hash)
+	if fileSizeOrZero(blob) == size || fileSizeOrZero(c.compressedPath(hash)) > 0 {
+		_, _ = tx.StmtContext(ctx, c.s.touchBlob).ExecContext(ctx, now, hash)
+	} else {
+		if err := c.checkQuota(ctx, tx, size); err != nil {
+			return false, err
+		}
+		if err := os.MkdirAll(filepath.Dir(blob), 0o750); err != nil {
+			return false, fmt.Errorf("create shard dir: %w", err)
+		}
+		if err := os.Rename(tmp, blob); err != nil {
+			return false, fmt.Errorf("promote blob: %w", err)
+		}
+		if _, err := tx.StmtContext(ctx, c.s.insertBlob).ExecContext(ctx, hash, size, now, now); err != nil {
+			return false, fmt.Errorf("record blob: %w", err)
+		}
+		if _, err := tx.StmtContext(ctx, c.s.resetCodec).ExecContext(ctx, hash); err != nil {
+			return false, fmt.Errorf("record blob: %w", err)
+		}
+		added = true
+	}
+	if _, err := tx.StmtContext(ctx, c.s.insertURL).ExecContext(ctx, canonical, hash, "", "", now); err != nil {
+		return false, fmt.Errorf("record url: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit import: %w", err)
+	}
+	return added, nil
+}
+
+// linkIntoTmp reflinks or hardlinks path to a fresh name in tmp/, which is
+// on the store's filesystem, so it can be renamed into place.
+func (c *Cache) linkIntoTmp(path string, allowCopy bool) (string, error) {
+	f, err := c.NewTmpFile()
+	if err != nil {
+		return "", err
+	}
+	tmp := f.Name()
+	_ = f.Close()
+
+	if err := tryReflink(path, tmp); err == nil {
+		return tmp, nil
+	}
+	_ = os.Remove(tmp)
+	if err := os.Link(path, tmp); err == nil {
+		return tmp, nil
+	}
+	if !allowCopy {
+		return "", ErrImportNeedsCopy
+	}
+	if err := copyFile(path, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
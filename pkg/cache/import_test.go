@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(Config{Dir: filepath.Join(dir, "cache")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	// A download from before the cache, on the same filesystem.
+	content := "GSE1 series matrix"
+	sum := sha256.Sum256([]byte(content))
+	want := hex.EncodeToString(sum[:])
+	path := filepath.Join(dir, "GSE1", "GSE1_series_matrix.txt")
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	const url = "https://ftp.ncbi.nlm.nih.gov/geo/series/GSE1_series_matrix.txt"
+
+	if _, _, err := c.Import(ctx, url, path, "00"+want[2:], false); err == nil {
+		t.Fatal("Import with a mismatching sha256 should fail")
+	}
+	if _, hit, _ := c.Lookup(ctx, url); hit {
+		t.Fatal("mismatching import indexed the url")
+	}
+
+	hash, added, err := c.Import(ctx, url, path, want, false)
+	if err != nil || hash != want || !added {
+		t.Fatalf("Import = %s, %v, %v", hash, added, err)
+	}
+	e, hit, err := c.Lookup(ctx, url)
+	if err != nil || !hit || e.SHA256 != want {
+		t.Fatalf("Lookup after import = %+v, %v, %v", e, hit, err)
+	}
+	if refs, err := c.Refs(ctx, want); err != nil || len(refs) != 1 || !refs[0].Live {
+		t.Errorf("Refs = %+v, %v; want the imported file", refs, err)
+	}
+
+	// The same content under another URL only adds the URL.
+	const mirror = "https://mirror.example.org/GSE1_series_matrix.txt"
+	if _, added, err := c.Import(ctx, mirror, path, "", false); err != nil || added {
+		t.Fatalf("re-import = %v, %v; want no new blob", added, err)
+	}
+	if _, hit, _ := c.Lookup(ctx, mirror); !hit {
+		t.Error("re-import did not index the mirror url")
+	}
+	if got, _ := os.ReadFile(path); string(got) != content {
+		t.Errorf("original file changed: %q", got)
+	}
+}
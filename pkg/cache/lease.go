@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Lease timing. A holder refreshes its lockfile's mtime every leaseRefresh;
+// a lockfile untouched for leaseStale belongs to a crashed process (or a
+// node that lost the shared filesystem) and may be broken. Vars so tests can
+// shrink them.
+var (
+	leaseRefresh = 10 * time.Second
+	leaseStale   = 60 * time.Second
+	leasePoll    = 500 * time.Millisecond
+)
+
+// Lease is a cross-process claim on fetching one URL into the cache. It is a
+// lockfile in tmp/ created with O_EXCL, which unlike flock is honoured by
+// NFS and Lustre, kept alive by a heartbeat while the fetch runs.
+type Lease struct {
+	path string
+	stop chan struct{}
+	done chan struct{}
+}
+
+// leasePath returns the lockfile for rawURL's canonical form.
+func (c *Cache) leasePath(rawURL string) string {
+	canonical, err := canonicalizeURL(rawURL)
+	if err != nil {
+		canonical = rawURL
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return filepath.Join(c.cfg.Dir, "tmp", "lock-"+hex.EncodeToString(sum[:16]))
+}
+
+// AcquireLease claims rawURL for fetching, waiting while another process (or
+// goroutine) holds it. waited reports whether it had to wait; the caller
+// should then look the URL up again, since the previous holder has most
+// likely just cached it. Release the lease once the blob is Put (or the
+// fetch failed).
+func (c *Cache) AcquireLease(ctx context.Context, rawURL string) (lease *Lease, waited bool, err error) {
+	path := c.leasePath(rawURL)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) // #nosec G304 -- internal cache path
+		if err == nil {
+			host, _ := os.Hostname()
+			_, _ = fmt.Fprintf(f, "%s %d %s\n", host, os.Getpid(), time.Now().UTC().Format(time.RFC3339))
+			_ = f.Close()
+			l := &Lease{path: path, stop: make(chan struct{}), done: make(chan struct{})}
+			go l.heartbeat()
+			return l, waited, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, waited, fmt.Errorf("cache lease: %w", err)
+		}
+
+		waited = true
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > leaseStale {
+			// The holder stopped refreshing. Breaking the lock races with
+			// other waiters, but at worst two processes fetch the same URL,
+			// which Put tolerates.
+			_ = os.Remove(path)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, waited, ctx.Err()
+		case <-time.After(leasePoll):
+		}
+	}
+}
+
+// heartbeat keeps the lockfile fresh until Release.
+func (l *Lease) heartbeat() {
+	defer close(l.done)
+	t := time.NewTicker(leaseRefresh)
+	defer t.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case now := <-t.C:
+			_ = os.Chtimes(l.path, now, now)
+		}
+	}
+}
+
+// Release gives the lease up. It is safe to call on a nil Lease.
+func (l *Lease) Release() {
+	if l == nil {
+		return
+	}
+	close(l.stop)
+	<-l.done
+	_ = os.Remove(l.path)
+}
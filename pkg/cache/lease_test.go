@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAcquireLease_WaitsForHolder(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	first, waited, err := c.AcquireLease(ctx, "https://example.com/a")
+	if err != nil || waited {
+		t.Fatalf("first lease: waited=%v err=%v", waited, err)
+	}
+
+	// Another URL is independent.
+	other, waited, err := c.AcquireLease(ctx, "https://example.com/b")
+	if err != nil || waited {
+		t.Fatalf("other URL: waited=%v err=%v", waited, err)
+	}
+	other.Release()
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		first.Release()
+	}()
+	second, waited, err := c.AcquireLease(ctx, "HTTPS://EXAMPLE.COM/a")
+	if err != nil || !waited {
+		t.Fatalf("second lease: waited=%v err=%v, want to wait for the first", waited, err)
+	}
+	second.Release()
+
+	if _, err := os.Stat(c.leasePath("https://example.com/a")); !os.IsNotExist(err) {
+		t.Errorf("lockfile left behind after Release: %v", err)
+	}
+}
+
+func TestAcquireLease_BreaksStaleLock(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// A lockfile from a process that died without releasing it.
+	path := c.leasePath("https://example.com/a")
+	if err := os.WriteFile(path, []byte("gone 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * leaseStale)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	l, waited, err := c.AcquireLease(ctx, "https://example.com/a")
+	if err != nil || !waited {
+		t.Fatalf("lease over stale lock: waited=%v err=%v", waited, err)
+	}
+	l.Release()
+}
+
+func TestAcquireLease_ContextCancelled(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	held, _, err := c.AcquireLease(context.Background(), "https://example.com/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := c.AcquireLease(ctx, "https://example.com/a"); err == nil {
+		t.Error("AcquireLease succeeded while the lease was held")
+	}
+}
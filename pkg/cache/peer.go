@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// PeerHit describes a blob pulled from a peer cache into the local CAS.
+type PeerHit struct {
+	Peer     string
+	SHA256   string
+	Filename string
+	Size     int64
+}
+
+// FetchFromPeers asks each configured peer, in order, to resolve rawURL. On
+// the first hit the blob is streamed into a tmp file while its sha256 is
+// checked against the resolved hash, then promoted via Put under rawURL.
+// A peer that serves bytes that do not hash to the key is skipped, so peers
+// need not be trusted. Peer errors are reported on stderr and never fatal:
+// ok is false when no peer could supply the blob and the caller should fall
+// back to the origin.
+func (c *Cache) FetchFromPeers(ctx context.Context, client *http.Client, rawURL string) (PeerHit, bool) {
+	if len(c.cfg.Server.Peers) == 0 {
+		return PeerHit{}, false
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	for _, peer := range c.cfg.Server.Peers {
+		base := strings.TrimRight(peer, "/")
+		res, found, err := c.peerResolve(ctx, client, base, rawURL)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "cache: warning: peer %s: %v\n", base, err)
+			continue
+		}
+		if !found {
+			continue
+		}
+		n, err := c.peerPull(ctx, client, base, rawURL, res.SHA256)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "cache: warning: peer %s: %v\n", base, err)
+			continue
+		}
+		_ = c.RecordFilename(ctx, rawURL, res.Filename)
+		return PeerHit{Peer: base, SHA256: res.SHA256, Filename: res.Filename, Size: n}, true
+	}
+	return PeerHit{}, false
+}
+
+// peerResolve calls GET <base>/v1/resolve?url=. A 404 is a clean miss.
+func (c *Cache) peerResolve(ctx context.Context, client *http.Client, base, rawURL string) (ResolveResponse, bool, error) {
+	var res ResolveResponse
+	resp, err := c.peerGet(ctx, client, base+"/v1/resolve?url="+url.QueryEscape(rawURL))
+	if err != nil {
+		return res, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return res, false, nil
+	default:
+		return res, false, fmt.Errorf("resolve: HTTP %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return res, false, fmt.Errorf("resolve: decode: %w", err)
+	}
+	if !sha256Pattern.MatchString(res.SHA256) {
+		return res, false, fmt.Errorf("resolve: invalid sha256 %q", res.SHA256)
+	}
+	return res, true, nil
+}
+
+// peerPull streams <base>/v1/blob/<sha256hex> into the local CAS, verifying
+// the hash before the blob is admitted. Returns the number of bytes stored.
+func (c *Cache) peerPull(ctx context.Context, client *http.Client, base, rawURL, sha256hex string) (int64, error) {
+	resp, err := c.peerGet(ctx, client, base+"/v1/blob/"+sha256hex)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("blob %s: HTTP %d", sha256hex[:16], resp.StatusCode)
+	}
+
+	tmp, err := c.NewTmpFile()
+	if err != nil {
+		return 0, fmt.Errorf("create tmp: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), resp.Body)
+	if closeErr := tmp.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return 0, fmt.Errorf("blob %s: read body: %w", sha256hex[:16], err)
+	}
+
+	if got := hex.EncodeToString(h.Sum(nil)); got != sha256hex {
+		_ = os.Remove(tmpPath)
+		return 0, fmt.Errorf("blob %s: sha256 mismatch (got %s, %d bytes)", sha256hex[:16], got[:16], n)
+	}
+
+	if err := c.Put(ctx, rawURL, tmpPath, sha256hex); err != nil {
+		_ = os.Remove(tmpPath)
+		return 0, err
+	}
+	return n, nil
+}
+
+func (c *Cache) peerGet(ctx context.Context, client *http.Client, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	if c.cfg.Server.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Server.Token)
+	}
+	return client.Do(req)
+}
@@ -0,0 +1,117 @@
+package cache_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btraven00/hapiq/pkg/cache"
+)
+
+func openPeerClientCache(t *testing.T, token string, peers ...string) *cache.Cache {
+	t.Helper()
+	c, err := cache.Open(cache.Config{
+		Dir:          t.TempDir(),
+		LinkStrategy: cache.StrategyCopy,
+		Server:       cache.ServerConfig{Token: token, Peers: peers},
+	})
+	if err != nil {
+		t.Fatalf("cache.Open: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestFetchFromPeers_Hit(t *testing.T) {
+	const rawURL = "https://zenodo.org/records/1/files/a.h5ad"
+	content := []byte("neighbour bytes")
+
+	peerCache, srv := newTestServer(t, "lab-token")
+	tmpPath, hash := writeTmp(t, peerCache, content)
+	ctx := context.Background()
+	if err := peerCache.Put(ctx, rawURL, tmpPath, hash); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	_ = peerCache.RecordFilename(ctx, rawURL, "a.h5ad")
+
+	// The first peer is unreachable; FetchFromPeers must move on to the next.
+	local := openPeerClientCache(t, "lab-token", "http://127.0.0.1:1", srv.URL+"/")
+	ph, ok := local.FetchFromPeers(ctx, srv.Client(), rawURL)
+	if !ok {
+		t.Fatal("FetchFromPeers: ok = false, want peer hit")
+	}
+	if ph.SHA256 != hash || ph.Size != int64(len(content)) || ph.Filename != "a.h5ad" {
+		t.Errorf("PeerHit = %+v", ph)
+	}
+
+	// The blob is now in the local CAS under the origin URL.
+	got, size, hit, err := local.Get(ctx, rawURL)
+	if err != nil || !hit || got != hash || size != int64(len(content)) {
+		t.Fatalf("local Get after peer pull = (%s, %d, %v, %v)", got, size, hit, err)
+	}
+	dest := filepath.Join(t.TempDir(), "a.h5ad")
+	if err := local.Materialize(hash, dest); err != nil {
+		t.Fatalf("Materialize: %v", err)
+	}
+	if b, _ := os.ReadFile(dest); string(b) != string(content) {
+		t.Errorf("materialized content = %q, want %q", b, content)
+	}
+}
+
+func TestFetchFromPeers_RejectsTamperedBlob(t *testing.T) {
+	const rawURL = "https://example.com/data.bin"
+	claimed := sha256.Sum256([]byte("the real bytes"))
+	claimedHex := hex.EncodeToString(claimed[:])
+
+	// A peer that resolves to one hash but serves different bytes.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/resolve":
+			_ = json.NewEncoder(w).Encode(cache.ResolveResponse{SHA256: claimedHex, Size: 14})
+		case "/v1/blob/" + claimedHex:
+			_, _ = w.Write([]byte("forged payload"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	local := openPeerClientCache(t, "", srv.URL)
+	ctx := context.Background()
+	if _, ok := local.FetchFromPeers(ctx, srv.Client(), rawURL); ok {
+		t.Fatal("FetchFromPeers accepted a blob whose bytes do not match its key")
+	}
+	if _, _, hit, _ := local.Get(ctx, rawURL); hit {
+		t.Error("tampered blob was admitted into the local cache")
+	}
+	if n, _ := local.BlobCount(ctx); n != 0 {
+		t.Errorf("BlobCount = %d, want 0", n)
+	}
+}
+
+func TestFetchFromPeers_MissAndWrongToken(t *testing.T) {
+	const rawURL = "https://example.com/missing.bin"
+	peerCache, srv := newTestServer(t, "right")
+	tmpPath, hash := writeTmp(t, peerCache, []byte("x"))
+	if err := peerCache.Put(context.Background(), rawURL, tmpPath, hash); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	// Wrong token: the peer answers 401 and the caller falls back.
+	local := openPeerClientCache(t, "wrong", srv.URL)
+	if _, ok := local.FetchFromPeers(context.Background(), srv.Client(), rawURL); ok {
+		t.Error("FetchFromPeers succeeded with an invalid token")
+	}
+
+	// Right token, unknown URL: clean miss.
+	local = openPeerClientCache(t, "right", srv.URL)
+	if _, ok := local.FetchFromPeers(context.Background(), srv.Client(), "https://example.com/other"); ok {
+		t.Error("FetchFromPeers reported a hit for an unknown URL")
+	}
+}
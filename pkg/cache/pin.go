@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Pin protects the blob sha256hex from GC under label (which may be empty),
+// e.g. "paper-2026 reproducibility". A blob may carry several labels; pinning
+// it again under the same label is a no-op. Pinned blobs are never evicted
+// but still count against max_size. Unlike the hardlink check GC also makes,
+// a pin survives outputs that were copied, symlinked or written to another
+// filesystem.
+func (c *Cache) Pin(ctx context.Context, sha256hex, label string) error {
+	var size int64
+	if err := c.s.getBlob.QueryRowContext(ctx, sha256hex).Scan(new(string), &size); err == sql.ErrNoRows {
+		return fmt.Errorf("blob %s is not in the cache", shortHash(sha256hex))
+	} else if err != nil {
+		return fmt.Errorf("pin: %w", err)
+	}
+	if _, err := c.db.ExecContext(ctx, `INSERT OR IGNORE INTO pins(sha256, label, created_at) VALUES(?,?,?)`,
+		sha256hex, label, time.Now().Unix()); err != nil {
+		return fmt.Errorf("pin: %w", err)
+	}
+	return nil
+}
+
+// Unpin removes the pin labelled label from sha256hex, or every pin on it when
+// label is empty, and returns how many were removed.
+func (c *Cache) Unpin(ctx context.Context, sha256hex, label string) (int, error) {
+	query, args := `DELETE FROM pins WHERE sha256 = ?`, []any{sha256hex}
+	if label != "" {
+		query, args = query+` AND label = ?`, append(args, label)
+	}
+	res, err := c.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("unpin: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// Pins returns the labels pinning sha256hex; an unlabelled pin is "".
+func (c *Cache) Pins(ctx context.Context, sha256hex string) ([]string, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT label FROM pins WHERE sha256 = ? ORDER BY created_at`, sha256hex)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var labels []string
+	for rows.Next() {
+		var l string
+		if err := rows.Scan(&l); err != nil {
+			return nil, err
+		}
+		labels = append(labels, l)
+	}
+	return labels, rows.Err()
+}
+
+// PinnedStats reports how many blobs are pinned and the bytes they take on
+// disk.
+func (c *Cache) PinnedStats(ctx context.Context) (count int, size int64, err error) {
+	err = c.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(COALESCE(stored_size, size)), 0) FROM blobs WHERE sha256 IN (SELECT sha256 FROM pins)`,
+	).Scan(&count, &size)
+	return count, size, err
+}
+
+// BlobsUnder hashes every file below dir, following symlinks, and returns the
+// sha256 of those the cache holds. missing counts files that are not cached.
+// Used to pin everything a download wrote to an output directory.
+func (c *Cache) BlobsUnder(ctx context.Context, dir string) (hashes []string, missing int, err error) {
+	seen := map[string]bool{}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return ctx.Err()
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil //nolint:nilerr // dangling symlinks and special files are not blobs
+		}
+		hash, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		if err := c.s.getBlob.QueryRowContext(ctx, hash).Scan(new(string), new(int64)); err == sql.ErrNoRows {
+			missing++
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !seen[hash] {
+			seen[hash] = true
+			hashes = append(hashes, hash)
+		}
+		return nil
+	})
+	return hashes, missing, err
+}
+
+func shortHash(sha256hex string) string {
+	if len(sha256hex) > 16 {
+		return sha256hex[:16]
+	}
+	return sha256hex
+}
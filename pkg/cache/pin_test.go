@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPin_GCAndQuota(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	kept := seedBlob(t, c, strings.Repeat("k", 400), old, old, 0)
+	other := seedBlob(t, c, strings.Repeat("o", 100), time.Now(), time.Now(), 0)
+
+	if err := c.Pin(ctx, kept, "paper-2026 reproducibility"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Pin(ctx, kept, "paper-2026 reproducibility"); err != nil {
+		t.Fatalf("pinning twice: %v", err)
+	}
+	if err := c.Pin(ctx, strings.Repeat("0", 64), ""); err == nil {
+		t.Error("pinning an unknown blob should fail")
+	}
+
+	// The pinned 400 bytes count against the quota but are never evicted.
+	// Both blobs fit a 600-byte quota, so nothing goes.
+	c.cfg.MaxSize = 600
+	res, err := c.GC(ctx, false, 0)
+	if err != nil || res.Evicted != 0 {
+		t.Fatalf("GC = %+v, %v; want nothing evicted", res, err)
+	}
+	// Over a 450-byte quota only the unpinned blob goes.
+	c.cfg.MaxSize = 450
+	if res, err := c.GC(ctx, false, 0); err != nil || res.Evicted != 1 || res.Candidates[0].SHA256 != other {
+		t.Fatalf("GC = %+v, %v; want only the unpinned blob evicted", res, err)
+	}
+	if _, err := os.Stat(c.blobPath(kept)); err != nil {
+		t.Fatalf("pinned blob evicted: %v", err)
+	}
+
+	// With only pinned blobs left, a blob that does not fit is refused.
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.checkQuota(ctx, tx, 100)
+	_ = tx.Rollback()
+	if err == nil || !strings.Contains(err.Error(), "pinned blobs take") {
+		t.Errorf("checkQuota = %v, want the pinned blobs blamed", err)
+	}
+
+	blobs, err := c.ListBlobs(ctx, "")
+	if err != nil || len(blobs) != 1 || !blobs[0].Pinned || len(blobs[0].Pins) != 1 || blobs[0].Pins[0] != "paper-2026 reproducibility" {
+		t.Fatalf("ListBlobs = %+v, %v", blobs, err)
+	}
+
+	if n, err := c.Unpin(ctx, kept, "other label"); err != nil || n != 0 {
+		t.Errorf("Unpin(other label) = %d, %v", n, err)
+	}
+	if n, err := c.Unpin(ctx, kept, ""); err != nil || n != 1 {
+		t.Errorf("Unpin = %d, %v", n, err)
+	}
+	c.cfg.MaxSize = 150
+	if res, err := c.GC(ctx, false, 0); err != nil || res.Evicted != 1 {
+		t.Errorf("GC after unpin = %+v, %v", res, err)
+	}
+}
+
+func TestBlobsUnder(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	now := time.Now()
+	hash := seedBlob(t, c, "counts matrix", now, now, 0)
+
+	// A copied output is found by content, unlike a hardlink check.
+	out := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(out, "sub"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(out, "sub", "matrix.mtx"), []byte("counts matrix"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(out, "hapiq.json"), []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	hashes, missing, err := c.BlobsUnder(context.Background(), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hashes) != 1 || hashes[0] != hash || missing != 1 {
+		t.Errorf("BlobsUnder = %v, %d missing; want [%s], 1", hashes, missing, hash[:8])
+	}
+}
+
+func TestEvictRemovesPins(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	now := time.Now()
+	hash := seedBlob(t, c, "corrupt later", now, now, 0)
+	if err := c.Pin(ctx, hash, "keep"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Evict(ctx, hash); err != nil {
+		t.Fatal(err)
+	}
+	if labels, err := c.Pins(ctx, hash); err != nil || len(labels) != 0 {
+		t.Errorf("Pins after Evict = %v, %v", labels, err)
+	}
+}
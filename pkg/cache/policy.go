@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Quota policies selectable with cache.quota_policy. They decide which blobs
+// GC evicts to bring the cache under max_size.
+const (
+	// PolicyLRU evicts the least recently used blobs first.
+	PolicyLRU = "lru"
+	// PolicyLFU evicts the least often used blobs first, least recently used
+	// among equals.
+	PolicyLFU = "lfu"
+	// PolicySizeLRU evicts by size times time since last use, so one large
+	// stale blob goes before thousands of small, recently touched ones.
+	PolicySizeLRU = "size-lru"
+	// PolicyTTL evicts every blob created more than Config.TTL ago, then the
+	// oldest ones while the cache is still over quota.
+	PolicyTTL = "ttl"
+	// PolicyNever evicts nothing: downloads skip the cache once it is full
+	// and GC reports the overrun as an error.
+	PolicyNever = "never"
+)
+
+// Policies lists the accepted values of cache.quota_policy.
+var Policies = []string{PolicyLRU, PolicyLFU, PolicySizeLRU, PolicyTTL, PolicyNever}
+
+// Policy returns the active quota policy; an unset policy is LRU.
+func (c *Cache) Policy() string {
+	if c.cfg.QuotaPolicy == "" {
+		return PolicyLRU
+	}
+	return strings.ToLower(c.cfg.QuotaPolicy)
+}
+
+func validPolicy(policy string) bool {
+	for _, p := range Policies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// gcBlob is a blob row as GC sees it.
+type gcBlob struct {
+	created  time.Time
+	lastUsed time.Time
+	sha256   string
+	size     int64
+	hits     int64
+}
+
+// orderForEviction sorts blobs into the order policy evicts them in.
+func orderForEviction(policy string, blobs []gcBlob, now time.Time) {
+	var less func(a, b gcBlob) bool
+	switch policy {
+	case PolicyLFU:
+		less = func(a, b gcBlob) bool {
+			if a.hits != b.hits {
+				return a.hits < b.hits
+			}
+			return a.lastUsed.Before(b.lastUsed)
+		}
+	case PolicySizeLRU:
+		less = func(a, b gcBlob) bool { return sizeIdleScore(a, now) > sizeIdleScore(b, now) }
+	case PolicyTTL:
+		less = func(a, b gcBlob) bool { return a.created.Before(b.created) }
+	default:
+		less = func(a, b gcBlob) bool { return a.lastUsed.Before(b.lastUsed) }
+	}
+	sort.SliceStable(blobs, func(i, j int) bool {
+		if less(blobs[i], blobs[j]) != less(blobs[j], blobs[i]) {
+			return less(blobs[i], blobs[j])
+		}
+		return blobs[i].sha256 < blobs[j].sha256
+	})
+}
+
+// sizeIdleScore is the size-lru weight: bytes times seconds since last use.
+func sizeIdleScore(b gcBlob, now time.Time) float64 {
+	idle := now.Sub(b.lastUsed).Seconds()
+	if idle < 1 {
+		idle = 1
+	}
+	return float64(b.size) * idle
+}
+
+// evictionReason explains, for `cache gc --dry-run`, why policy picked b.
+// expired is set when the ttl policy picked b for its age alone.
+func evictionReason(policy string, b gcBlob, expired bool, ttl time.Duration, now time.Time) string {
+	switch policy {
+	case PolicyLFU:
+		return fmt.Sprintf("%d hits, last used %s ago", b.hits, formatAge(now.Sub(b.lastUsed)))
+	case PolicySizeLRU:
+		return fmt.Sprintf("%s idle for %s", formatSize(b.size), formatAge(now.Sub(b.lastUsed)))
+	case PolicyTTL:
+		if expired {
+			return fmt.Sprintf("created %s ago, past the %s ttl", formatAge(now.Sub(b.created)), formatAge(ttl))
+		}
+		return fmt.Sprintf("oldest while over quota, created %s ago", formatAge(now.Sub(b.created)))
+	}
+	return fmt.Sprintf("last used %s ago", formatAge(now.Sub(b.lastUsed)))
+}
+
+// formatAge renders d coarsely: "3d", "5h", "12m" or "40s".
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= 2*time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	case d >= 2*time.Minute:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	}
+	return fmt.Sprintf("%ds", int(d/time.Second))
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// seedBlob stores content under a URL and backdates its index row.
+func seedBlob(t *testing.T, c *Cache, content string, created, lastUsed time.Time, hits int) string {
+	t.Helper()
+	f, err := c.NewTmpFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+	ctx := context.Background()
+	if err := c.Put(ctx, "https://example.com/"+hash[:8], f.Name(), hash); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.db.ExecContext(ctx, `UPDATE blobs SET created_at = ?, last_used = ?, hits = ? WHERE sha256 = ?`,
+		created.Unix(), lastUsed.Unix(), hits, hash); err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestGC_Policies(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+
+	// big is old and large; small1/small2 are older but tiny; hot is stale
+	// but heavily used.
+	type blob struct {
+		name, content     string
+		created, lastUsed time.Time
+		hits              int
+	}
+	blobs := []blob{
+		{"big", strings.Repeat("B", 400), now.Add(-10 * day), now.Add(-5 * day), 3},
+		{"small1", strings.Repeat("s", 40), now.Add(-40 * day), now.Add(-20 * day), 2},
+		{"small2", strings.Repeat("t", 40), now.Add(-30 * day), now.Add(-19 * day), 1},
+		{"hot", strings.Repeat("h", 100), now.Add(-2 * day), now.Add(-10 * day), 50},
+	}
+
+	tests := []struct {
+		policy string
+		ttl    time.Duration
+		want   []string
+	}{
+		{PolicyLRU, 0, []string{"small1", "small2", "hot", "big"}},
+		{PolicyLFU, 0, []string{"small2", "small1", "big"}},
+		{PolicySizeLRU, 0, []string{"big"}},
+		{PolicyTTL, 0, []string{"small1", "small2", "big"}},
+		{PolicyTTL, 35 * day, []string{"small1", "small2", "big"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%s", tt.policy, tt.ttl), func(t *testing.T) {
+			// 580 bytes stored; a 300-byte quota needs 280 freed.
+			c, err := Open(Config{Dir: t.TempDir(), QuotaPolicy: tt.policy, TTL: tt.ttl})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			names := map[string]string{}
+			for _, b := range blobs {
+				names[seedBlob(t, c, b.content, b.created, b.lastUsed, b.hits)] = b.name
+			}
+			c.cfg.MaxSize = 300
+
+			res, err := c.GC(context.Background(), true, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range res.Candidates {
+				got = append(got, names[e.SHA256])
+				if e.Reason == "" {
+					t.Errorf("no reason for %s", names[e.SHA256])
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("evicts %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGC_TTLWithoutQuota(t *testing.T) {
+	now := time.Now()
+	c, err := Open(Config{Dir: t.TempDir(), QuotaPolicy: PolicyTTL, TTL: 7 * 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	old := seedBlob(t, c, "old", now.Add(-8*24*time.Hour), now, 0)
+	fresh := seedBlob(t, c, "fresh", now.Add(-time.Hour), now, 0)
+
+	res, err := c.GC(context.Background(), false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Evicted != 1 || res.Candidates[0].SHA256 != old {
+		t.Fatalf("GC = %+v, want only the expired blob", res)
+	}
+	if !strings.Contains(res.Candidates[0].Reason, "ttl") {
+		t.Errorf("reason = %q", res.Candidates[0].Reason)
+	}
+	if _, err := os.Stat(c.blobPath(fresh)); err != nil {
+		t.Errorf("fresh blob evicted: %v", err)
+	}
+}
+
+func TestGC_NeverFailsOverQuota(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir(), QuotaPolicy: PolicyNever})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	now := time.Now()
+	seedBlob(t, c, "kept forever", now, now, 0)
+
+	c.cfg.MaxSize = 1
+	res, err := c.GC(context.Background(), false, 0)
+	if err == nil || !strings.Contains(err.Error(), "never") {
+		t.Fatalf("GC err = %v, want a quota error naming the policy", err)
+	}
+	if n, _ := c.BlobCount(context.Background()); n != 1 || res.Evicted != 0 {
+		t.Errorf("never policy evicted something: %+v", res)
+	}
+}
+
+func TestLookupCountsHits(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	now := time.Now()
+	hash := seedBlob(t, c, "counted", now, now, 0)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, _, hit, _ := c.Get(ctx, "https://example.com/"+hash[:8]); !hit {
+			t.Fatal("miss")
+		}
+	}
+	blobs, err := c.gcBlobs(ctx)
+	if err != nil || len(blobs) != 1 || blobs[0].hits != 3 {
+		t.Errorf("gcBlobs = %+v, %v; want 3 hits", blobs, err)
+	}
+}
@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Ref is a file outside the cache that a blob was materialized to.
+type Ref struct {
+	Created time.Time
+	Path    string
+	// Live reports whether Path still exists. A blob whose refs are all gone
+	// is an orphan: nothing downloaded through the cache still uses it.
+	Live bool
+}
+
+// recordRef notes that sha256hex was materialized at destPath and keeps
+// blobs.ref_count in step. A path references one blob at a time, so
+// re-materializing it with new content moves the reference. Best-effort: a
+// failure only costs usage tracking, never the download.
+func (c *Cache) recordRef(ctx context.Context, sha256hex, destPath string) {
+	abs, err := filepath.Abs(destPath)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var previous string
+	_ = tx.QueryRowContext(ctx, `SELECT sha256 FROM refs WHERE path = ?`, abs).Scan(&previous)
+	if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO refs(path, sha256, created_at) VALUES(?,?,?)`,
+		abs, sha256hex, time.Now().Unix()); err != nil {
+		return
+	}
+	for _, h := range []string{sha256hex, previous} {
+		if h == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, updateRefCount, h, h); err != nil {
+			return
+		}
+	}
+	_ = tx.Commit()
+}
+
+// MoveRef follows a materialized file that the caller renamed from oldPath
+// to newPath, so the blob is not mistaken for an orphan.
+func (c *Cache) MoveRef(ctx context.Context, oldPath, newPath string) error {
+	oldAbs, err := filepath.Abs(oldPath)
+	if err != nil {
+		return err
+	}
+	newAbs, err := filepath.Abs(newPath)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// newPath may have referenced another blob, whose count then drops.
+	if _, err := c.db.ExecContext(ctx, `UPDATE OR REPLACE refs SET path = ? WHERE path = ?`, newAbs, oldAbs); err != nil {
+		return err
+	}
+	_, err = c.db.ExecContext(ctx, `UPDATE blobs SET ref_count = (SELECT COUNT(*) FROM refs r WHERE r.sha256 = blobs.sha256)`)
+	return err
+}
+
+const updateRefCount = `UPDATE blobs SET ref_count = (SELECT COUNT(*) FROM refs WHERE sha256 = ?) WHERE sha256 = ?`
+
+// Refs lists the files sha256hex has been materialized to, oldest first.
+func (c *Cache) Refs(ctx context.Context, sha256hex string) ([]Ref, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT path, created_at FROM refs WHERE sha256 = ? ORDER BY created_at, path`, sha256hex)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Ref
+	for rows.Next() {
+		var r Ref
+		var created int64
+		if err := rows.Scan(&r.Path, &created); err != nil {
+			return nil, err
+		}
+		r.Created = time.Unix(created, 0)
+		_, statErr := os.Lstat(r.Path)
+		r.Live = statErr == nil
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// refUsage is how a blob's refs look on disk.
+type refUsage struct {
+	total int
+	live  int
+	// symlinked counts live refs that are symlinks into the blob store;
+	// evicting the blob would leave them dangling.
+	symlinked int
+}
+
+// orphaned reports whether the blob was materialized but nothing remains.
+func (u refUsage) orphaned() bool { return u.total > 0 && u.live == 0 }
+
+// refUsages maps each referenced blob to its refUsage. Caller must hold c.mu.
+func (c *Cache) refUsages(ctx context.Context) (map[string]refUsage, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT sha256, path FROM refs`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usages := map[string]refUsage{}
+	for rows.Next() {
+		var hash, path string
+		if err := rows.Scan(&hash, &path); err != nil {
+			return nil, err
+		}
+		u := usages[hash]
+		u.total++
+		if info, err := os.Lstat(path); err == nil {
+			u.live++
+			if info.Mode()&os.ModeSymlink != 0 {
+				if target, err := os.Readlink(path); err == nil && filepath.Base(target) == hash {
+					u.symlinked++
+				}
+			}
+		}
+		usages[hash] = u
+	}
+	return usages, rows.Err()
+}
+
+// GCOrphans evicts orphaned blobs: blobs that were materialized somewhere but
+// whose every referencing file has since been deleted, along with their
+// output directories. Blobs never materialized through the cache (cached
+// before reference tracking) are not orphans; imported files count as refs.
+// Pinned blobs and blobs with live hardlinks are skipped as in GC.
+func (c *Cache) GCOrphans(ctx context.Context, dryRun bool) (GCResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := GCResult{DryRun: dryRun, Policy: "orphans"}
+	usages, err := c.refUsages(ctx)
+	if err != nil {
+		return res, err
+	}
+	blobs, err := c.gcBlobs(ctx)
+	if err != nil {
+		return res, err
+	}
+	orderForEviction(PolicyLRU, blobs, time.Now())
+
+	for _, b := range blobs {
+		u := usages[b.sha256]
+		if !u.orphaned() {
+			continue
+		}
+		if blobNlink(c.blobPath(b.sha256)) > 1 {
+			res.Skipped++
+			continue
+		}
+		res.Candidates = append(res.Candidates, GCCandidate{
+			SHA256: b.sha256,
+			Size:   b.size,
+			Reason: orphanReason(u.total),
+		})
+	}
+
+	if dryRun {
+		res.Evicted = len(res.Candidates)
+		for _, e := range res.Candidates {
+			res.Freed += e.Size
+		}
+		return res, nil
+	}
+	for _, e := range res.Candidates {
+		if err := c.evictLocked(ctx, e.SHA256); err == nil {
+			res.Evicted++
+			res.Freed += e.Size
+		}
+	}
+	return res, nil
+}
+
+func orphanReason(refs int) string {
+	if refs == 1 {
+		return "orphaned: its one referencing file is gone"
+	}
+	return fmt.Sprintf("orphaned: all %d referencing files are gone", refs)
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRefs_Orphans(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir(), LinkStrategy: StrategyCopy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	now := time.Now()
+	used := seedBlob(t, c, "materialized twice", now, now, 0)
+	untracked := seedBlob(t, c, "never materialized", now, now, 0)
+
+	out := t.TempDir()
+	a := filepath.Join(out, "run1", "matrix.mtx")
+	b := filepath.Join(out, "run2", "matrix.mtx")
+	for _, p := range []string{a, b} {
+		if err := c.Materialize(used, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refs, err := c.Refs(ctx, used)
+	if err != nil || len(refs) != 2 || !refs[0].Live || !refs[1].Live {
+		t.Fatalf("Refs = %+v, %v", refs, err)
+	}
+	var refCount int
+	if err := c.db.QueryRowContext(ctx, `SELECT ref_count FROM blobs WHERE sha256 = ?`, used).Scan(&refCount); err != nil || refCount != 2 {
+		t.Errorf("ref_count = %d, %v; want 2", refCount, err)
+	}
+
+	// One copy left: not an orphan.
+	if err := os.RemoveAll(filepath.Dir(a)); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := c.GCOrphans(ctx, false); err != nil || res.Evicted != 0 {
+		t.Fatalf("GCOrphans = %+v, %v; want nothing while run2 exists", res, err)
+	}
+
+	// A renamed output is followed.
+	renamed := filepath.Join(out, "run2", "counts.mtx")
+	if err := os.Rename(b, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MoveRef(ctx, b, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := c.GCOrphans(ctx, true); err != nil || res.Evicted != 0 {
+		t.Fatalf("GCOrphans after rename = %+v, %v", res, err)
+	}
+
+	if err := os.RemoveAll(filepath.Dir(b)); err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.GCOrphans(ctx, false)
+	if err != nil || res.Evicted != 1 || res.Candidates[0].SHA256 != used {
+		t.Fatalf("GCOrphans = %+v, %v; want the orphan evicted", res, err)
+	}
+	if _, err := os.Stat(c.blobPath(untracked)); err != nil {
+		t.Errorf("blob without refs evicted as an orphan: %v", err)
+	}
+}
+
+func TestGC_OrphansFirst(t *testing.T) {
+	c, err := Open(Config{Dir: t.TempDir(), LinkStrategy: StrategyCopy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	now := time.Now()
+	stale := seedBlob(t, c, "least recently used", now.Add(-time.Hour), now.Add(-time.Hour), 0)
+	orphan := seedBlob(t, c, "recent but orphaned", now, now, 0)
+
+	dest := filepath.Join(t.TempDir(), "out.txt")
+	if err := c.Materialize(orphan, dest); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(dest); err != nil {
+		t.Fatal(err)
+	}
+
+	c.cfg.MaxSize = 25
+	res, err := c.GC(ctx, true, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Candidates) != 1 || res.Candidates[0].SHA256 != orphan {
+		t.Errorf("GC candidates = %+v, want the orphan before %s", res.Candidates, stale[:8])
+	}
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sha256Pattern matches a lowercase hex sha256 digest. Blob keys are
+// validated against it before touching the filesystem so a request can never
+// escape blobs/sha256/.
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Server exposes a Cache over HTTP so other hapiq instances can pull blobs
+// by content hash instead of re-fetching them from the origin.
+//
+// Endpoints:
+//
+//	GET|HEAD /v1/blob/{sha256}  blob bytes, ETag "sha256:<hex>"
+//	GET      /v1/resolve?url=   {"sha256": ..., "size": ...} for a URL
+//	GET      /v1/healthz        liveness
+//
+// Clients are expected to verify the sha256 while streaming, so the server
+// does not need to be trusted beyond the bearer token.
+type Server struct {
+	c     *Cache
+	token string
+}
+
+// NewServer returns a Server backed by c. When token is non-empty every
+// request except /v1/healthz must carry "Authorization: Bearer <token>".
+func NewServer(c *Cache, token string) *Server {
+	return &Server{c: c, token: token}
+}
+
+// ResolveResponse is the JSON body returned by /v1/resolve. Filename is the
+// name recorded via RecordFilename, if any.
+type ResolveResponse struct {
+	SHA256   string `json:"sha256"`
+	Filename string `json:"filename,omitempty"`
+	Size     int64  `json:"size"`
+}
+
+// Handler returns the HTTP handler serving the /v1 API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/healthz", s.handleHealthz)
+	mux.Handle("GET /v1/blob/{sha256}", s.requireToken(http.HandlerFunc(s.handleBlob)))
+	mux.Handle("GET /v1/resolve", s.requireToken(http.HandlerFunc(s.handleResolve)))
+	return mux
+}
+
+// requireToken rejects requests without the configured bearer token.
+// A server started without a token is open to anyone who can reach it.
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="hapiq"`)
+			writeJSONError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleBlob streams a blob straight from the CAS. GET and HEAD share the
+// handler; http.ServeContent takes care of HEAD, Range and If-None-Match.
+// A compacted blob is decompressed on the fly and served whole, without
+// Range support. Blobs of read-only tiers are served too.
+func (s *Server) handleBlob(w http.ResponseWriter, r *http.Request) {
+	hash := r.PathValue("sha256")
+	if !sha256Pattern.MatchString(hash) {
+		writeJSONError(w, http.StatusBadRequest, "blob key must be a lowercase hex sha256")
+		return
+	}
+
+	t := s.c.blobTier(hash)
+	rc, compressed, err := t.openBlob(hash)
+	if os.IsNotExist(err) {
+		writeJSONError(w, http.StatusNotFound, "blob not found")
+		return
+	} else if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rc.Close()
+
+	if r.Method == http.MethodGet && !t.readOnly {
+		_, _ = t.s.touchBlob.ExecContext(r.Context(), time.Now().Unix(), hash)
+	}
+
+	w.Header().Set("ETag", `"sha256:`+hash+`"`)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if compressed {
+		var size int64
+		if err := t.s.getBlob.QueryRowContext(r.Context(), hash).Scan(new(string), &size); err == nil {
+			w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		}
+		if r.Method == http.MethodGet {
+			_, _ = io.Copy(w, rc)
+		}
+		return
+	}
+
+	f := rc.(*os.File)
+	info, err := f.Stat()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+// handleResolve maps a URL to the blob it was last fetched into.
+func (s *Server) handleResolve(w http.ResponseWriter, r *http.Request) {
+	rawURL := r.URL.Query().Get("url")
+	if rawURL == "" {
+		writeJSONError(w, http.StatusBadRequest, "missing url parameter")
+		return
+	}
+
+	hash, size, hit, err := s.c.Get(r.Context(), rawURL)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !hit {
+		writeJSONError(w, http.StatusNotFound, "url not in cache")
+		return
+	}
+	filename, _ := s.c.Filename(r.Context(), rawURL)
+	writeJSON(w, http.StatusOK, ResolveResponse{SHA256: hash, Filename: filename, Size: size})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,144 @@
+package cache_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/btraven00/hapiq/pkg/cache"
+)
+
+func newTestServer(t *testing.T, token string) (*cache.Cache, *httptest.Server) {
+	t.Helper()
+	c := openTestCache(t)
+	srv := httptest.NewServer(cache.NewServer(c, token).Handler())
+	t.Cleanup(srv.Close)
+	return c, srv
+}
+
+func TestServer_BlobGetAndHead(t *testing.T) {
+	c, srv := newTestServer(t, "")
+	content := []byte("served blob bytes")
+	tmpPath, hash := writeTmp(t, c, content)
+	if err := c.Put(context.Background(), "https://example.com/a.bin", tmpPath, hash); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	resp, err := http.Get(srv.URL + "/v1/blob/" + hash)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET status = %d, want 200", resp.StatusCode)
+	}
+	if string(body) != string(content) {
+		t.Errorf("GET body = %q, want %q", body, content)
+	}
+	if got, want := resp.Header.Get("ETag"), `"sha256:`+hash+`"`; got != want {
+		t.Errorf("ETag = %q, want %q", got, want)
+	}
+
+	resp, err = http.Head(srv.URL + "/v1/blob/" + hash)
+	if err != nil {
+		t.Fatalf("HEAD: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("HEAD status = %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Length"); got != strconv.Itoa(len(content)) {
+		t.Errorf("HEAD Content-Length = %q, want %d", got, len(content))
+	}
+}
+
+func TestServer_BlobNotFoundAndInvalid(t *testing.T) {
+	_, srv := newTestServer(t, "")
+
+	for path, want := range map[string]int{
+		"/v1/blob/" + strings.Repeat("0", 64): http.StatusNotFound,
+		"/v1/blob/not-a-hash":                 http.StatusBadRequest,
+		"/v1/blob/..%2F..%2Findex.db":         http.StatusBadRequest,
+	} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s status = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestServer_Resolve(t *testing.T) {
+	c, srv := newTestServer(t, "")
+	tmpPath, hash := writeTmp(t, c, []byte("resolve me"))
+	if err := c.Put(context.Background(), "https://Example.com:443/r.txt", tmpPath, hash); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	// Lookups go through the same canonicalization as Put.
+	resp, err := http.Get(srv.URL + "/v1/resolve?url=" + url.QueryEscape("https://example.com/r.txt"))
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var got cache.ResolveResponse
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.SHA256 != hash || got.Size != int64(len("resolve me")) {
+		t.Errorf("resolve = %+v, want sha256=%s size=%d", got, hash, len("resolve me"))
+	}
+
+	miss, err := http.Get(srv.URL + "/v1/resolve?url=" + url.QueryEscape("https://example.com/other"))
+	if err != nil {
+		t.Fatalf("GET miss: %v", err)
+	}
+	miss.Body.Close()
+	if miss.StatusCode != http.StatusNotFound {
+		t.Errorf("miss status = %d, want 404", miss.StatusCode)
+	}
+}
+
+func TestServer_BearerToken(t *testing.T) {
+	_, srv := newTestServer(t, "s3cret")
+
+	do := func(path, token string) int {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, http.NoBody)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if got := do("/v1/resolve?url=https://example.com/x", ""); got != http.StatusUnauthorized {
+		t.Errorf("no token: status = %d, want 401", got)
+	}
+	if got := do("/v1/resolve?url=https://example.com/x", "wrong"); got != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want 401", got)
+	}
+	if got := do("/v1/resolve?url=https://example.com/x", "s3cret"); got != http.StatusNotFound {
+		t.Errorf("valid token: status = %d, want 404 (authorized miss)", got)
+	}
+	// Liveness stays open so load balancers can probe without credentials.
+	if got := do("/v1/healthz", ""); got != http.StatusOK {
+		t.Errorf("healthz: status = %d, want 200", got)
+	}
+}
@@ -16,7 +16,10 @@ CREATE TABLE IF NOT EXISTS blobs (
   size        INTEGER NOT NULL,
   created_at  INTEGER NOT NULL,
   last_used   INTEGER NOT NULL,
-  ref_count   INTEGER NOT NULL DEFAULT 0
+  ref_count   INTEGER NOT NULL DEFAULT 0,
+  hits        INTEGER NOT NULL DEFAULT 0,
+  codec       TEXT NOT NULL DEFAULT '',
+  stored_size INTEGER
 );
 
 CREATE TABLE IF NOT EXISTS urls (
@@ -29,19 +32,53 @@ CREATE TABLE IF NOT EXISTS urls (
 );
 
 CREATE INDEX IF NOT EXISTS urls_by_hash ON urls(sha256);
+
+CREATE TABLE IF NOT EXISTS digests (
+  digest      TEXT PRIMARY KEY,
+  sha256      TEXT NOT NULL REFERENCES blobs(sha256)
+);
+
+CREATE INDEX IF NOT EXISTS digests_by_hash ON digests(sha256);
+
+CREATE TABLE IF NOT EXISTS pins (
+  sha256      TEXT NOT NULL REFERENCES blobs(sha256),
+  label       TEXT NOT NULL DEFAULT '',
+  created_at  INTEGER NOT NULL,
+  PRIMARY KEY (sha256, label)
+);
+
+CREATE TABLE IF NOT EXISTS refs (
+  path        TEXT PRIMARY KEY,
+  sha256      TEXT NOT NULL REFERENCES blobs(sha256),
+  created_at  INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS refs_by_hash ON refs(sha256);
+
+CREATE TABLE IF NOT EXISTS tier_hits (
+  dir         TEXT PRIMARY KEY,
+  hits        INTEGER NOT NULL DEFAULT 0,
+  last_hit    INTEGER NOT NULL
+);
 `
 
 type dbStmts struct {
 	getByURL    *sql.Stmt
+	getByDigest *sql.Stmt
+	getBlob     *sql.Stmt
 	insertBlob  *sql.Stmt
 	insertURL   *sql.Stmt
+	insertAlias *sql.Stmt
+	resetCodec  *sql.Stmt
 	setFilename *sql.Stmt
+	setFetched  *sql.Stmt
 	getFilename *sql.Stmt
 	touchBlob   *sql.Stmt
 	deleteURLs  *sql.Stmt
 	deleteBlob  *sql.Stmt
 	totalSize   *sql.Stmt
-	listLRU     *sql.Stmt
+	quotaSize   *sql.Stmt
+	listGC      *sql.Stmt
 }
 
 func openDB(dir string) (*sql.DB, *dbStmts, error) {
@@ -49,8 +86,12 @@ func openDB(dir string) (*sql.DB, *dbStmts, error) {
 		return nil, nil, fmt.Errorf("create cache dir: %w", err)
 	}
 
+	// Several hapiq processes may share one cache: wait for the write lock
+	// instead of failing with SQLITE_BUSY, and take it at BEGIN so a
+	// read-then-write transaction (quota check, then insert) cannot
+	// interleave with another process's.
 	dbPath := filepath.Join(dir, "index.db")
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(30000)&_txlock=immediate")
 	if err != nil {
 		return nil, nil, fmt.Errorf("open sqlite: %w", err)
 	}
@@ -70,12 +111,19 @@ func openDB(dir string) (*sql.DB, *dbStmts, error) {
 		return nil, nil, fmt.Errorf("apply schema: %w", err)
 	}
 
-	// Migrate databases created before urls.filename existed. ADD COLUMN is a
-	// no-op error ("duplicate column name") once applied, which we ignore.
-	if _, err := db.Exec(`ALTER TABLE urls ADD COLUMN filename TEXT`); err != nil &&
-		!strings.Contains(err.Error(), "duplicate column name") {
-		_ = db.Close()
-		return nil, nil, fmt.Errorf("migrate urls.filename: %w", err)
+	// Migrate databases created before these columns existed. ADD COLUMN is
+	// a no-op error ("duplicate column name") once applied, which we ignore.
+	for _, m := range []struct{ table, column string }{
+		{"urls", "filename TEXT"},
+		{"blobs", "hits INTEGER NOT NULL DEFAULT 0"},
+		{"blobs", "codec TEXT NOT NULL DEFAULT ''"},
+		{"blobs", "stored_size INTEGER"},
+	} {
+		if _, err := db.Exec(`ALTER TABLE ` + m.table + ` ADD COLUMN ` + m.column); err != nil &&
+			!strings.Contains(err.Error(), "duplicate column name") {
+			_ = db.Close()
+			return nil, nil, fmt.Errorf("migrate %s.%s: %w", m.table, strings.Fields(m.column)[0], err)
+		}
 	}
 
 	s, err := prepareStmts(db)
@@ -87,6 +135,42 @@ func openDB(dir string) (*sql.DB, *dbStmts, error) {
 	return db, s, nil
 }
 
+// openDBReadOnly opens the index of a read-only tier. The schema is neither
+// created nor migrated: the tier belongs to whoever maintains it.
+//
+// The index is opened immutable. A WAL index opened with mode=ro still
+// creates index.db-shm, which fails ("attempt to write a readonly
+// database") for users who cannot write the tier's directory. Immutable
+// readers take no locks and do not read the WAL, so the tier must be updated
+// only while nobody reads it; hapiq checkpoints the WAL into index.db when
+// its last connection closes. See "Cache tiers" in docs/cache.md.
+func openDBReadOnly(dir string) (*sql.DB, *dbStmts, error) {
+	dbPath := filepath.Join(dir, "index.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro&immutable=1")
+	if err != nil {
+		return nil, nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	// sql.Open connects lazily; read once so an unreadable index is
+	// reported here rather than on every lookup.
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM blobs`).Scan(&n); err != nil {
+		_ = db.Close()
+		return nil, nil, fmt.Errorf("read index: %w", err)
+	}
+
+	s, err := prepareStmts(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, err
+	}
+	return db, s, nil
+}
+
 func prepareStmts(db *sql.DB) (*dbStmts, error) {
 	var s dbStmts
 	type named struct {
@@ -94,16 +178,24 @@ func prepareStmts(db *sql.DB) (*dbStmts, error) {
 		sql  string
 	}
 	stmts := []named{
-		{&s.getByURL, `SELECT b.sha256, b.size FROM urls u JOIN blobs b ON b.sha256 = u.sha256 WHERE u.url = ?`},
+		{&s.getByURL, `SELECT b.sha256, b.size, u.etag, u.last_modified, u.fetched_at FROM urls u JOIN blobs b ON b.sha256 = u.sha256 WHERE u.url = ?`},
 		{&s.insertBlob, `INSERT OR IGNORE INTO blobs(sha256, size, created_at, last_used, ref_count) VALUES(?,?,?,?,0)`},
+		{&s.getByDigest, `SELECT b.sha256, b.size FROM digests d JOIN blobs b ON b.sha256 = d.sha256 WHERE d.digest = ?`},
+		{&s.getBlob, `SELECT sha256, size FROM blobs WHERE sha256 = ?`},
 		{&s.insertURL, `INSERT OR REPLACE INTO urls(url, sha256, etag, last_modified, fetched_at) VALUES(?,?,?,?,?)`},
+		{&s.insertAlias, `INSERT OR REPLACE INTO digests(digest, sha256) VALUES(?,?)`},
+		{&s.resetCodec, `UPDATE blobs SET codec = '', stored_size = NULL WHERE sha256 = ?`},
 		{&s.setFilename, `UPDATE urls SET filename = ? WHERE url = ?`},
 		{&s.getFilename, `SELECT filename FROM urls WHERE url = ?`},
-		{&s.touchBlob, `UPDATE blobs SET last_used = ? WHERE sha256 = ?`},
+		{&s.setFetched, `UPDATE urls SET fetched_at = ? WHERE url = ?`},
+		{&s.touchBlob, `UPDATE blobs SET last_used = ?, hits = hits + 1 WHERE sha256 = ?`},
 		{&s.deleteURLs, `DELETE FROM urls WHERE sha256 = ?`},
 		{&s.deleteBlob, `DELETE FROM blobs WHERE sha256 = ?`},
-		{&s.totalSize, `SELECT COALESCE(SUM(size), 0) FROM blobs`},
-		{&s.listLRU, `SELECT sha256, size, last_used FROM blobs ORDER BY last_used ASC`},
+		{&s.totalSize, `SELECT COALESCE(SUM(COALESCE(stored_size, size)), 0) FROM blobs`},
+		{&s.quotaSize, `SELECT COALESCE(SUM(COALESCE(stored_size, size)), 0),
+			COALESCE(SUM(CASE WHEN sha256 IN (SELECT sha256 FROM pins) THEN COALESCE(stored_size, size) ELSE 0 END), 0)
+			FROM blobs`},
+		{&s.listGC, `SELECT sha256, COALESCE(stored_size, size), created_at, last_used, hits FROM blobs WHERE sha256 NOT IN (SELECT sha256 FROM pins)`},
 	}
 	for _, n := range stmts {
 		stmt, err := db.Prepare(n.sql)
@@ -118,10 +210,11 @@ func prepareStmts(db *sql.DB) (*dbStmts, error) {
 
 func (s *dbStmts) close() {
 	for _, stmt := range []*sql.Stmt{
-		s.getByURL, s.insertBlob, s.insertURL,
-		s.setFilename, s.getFilename,
+		s.getByURL, s.getByDigest, s.getBlob,
+		s.insertBlob, s.insertURL, s.insertAlias, s.resetCodec,
+		s.setFilename, s.getFilename, s.setFetched,
 		s.touchBlob, s.deleteURLs, s.deleteBlob,
-		s.totalSize, s.listLRU,
+		s.totalSize, s.quotaSize, s.listGC,
 	} {
 		if stmt != nil {
 			_ = stmt.Close()
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// openTiers opens the read-only tiers of cfg.Tiers and fixes the lookup
+// order. c, the writable tier, takes the place of the first writable entry;
+// later writable entries are only read from, like read-only ones. A tier
+// that cannot be opened (an unmounted shared filesystem, say) is skipped
+// with a warning.
+func (c *Cache) openTiers() {
+	if len(c.cfg.Tiers) == 0 {
+		return
+	}
+	placed := false
+	for _, t := range c.cfg.Tiers {
+		if !t.ReadOnly && !placed {
+			c.tiers = append(c.tiers, c)
+			placed = true
+			continue
+		}
+		if t.Dir == "" || t.Dir == c.cfg.Dir {
+			continue
+		}
+		db, s, err := openDBReadOnly(t.Dir)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "cache: warning: tier %s: %v\n", t.Dir, err)
+			continue
+		}
+		c.tiers = append(c.tiers, &Cache{
+			cfg:      Config{Dir: t.Dir, LinkStrategy: c.cfg.LinkStrategy},
+			db:       db,
+			s:        s,
+			readOnly: true,
+		})
+	}
+	if !placed {
+		c.tiers = append(c.tiers, c)
+	}
+}
+
+// lookupOrder returns the tiers to search, in order.
+func (c *Cache) lookupOrder() []*Cache {
+	if c.tiers == nil {
+		return []*Cache{c}
+	}
+	return c.tiers
+}
+
+// blobTier returns the first tier whose store holds sha256hex, raw or
+// compacted, or c when none does.
+func (c *Cache) blobTier(sha256hex string) *Cache {
+	for _, t := range c.lookupOrder() {
+		if _, err := os.Stat(t.blobPath(sha256hex)); err == nil {
+			return t
+		}
+		if _, err := os.Stat(t.compressedPath(sha256hex)); err == nil {
+			return t
+		}
+	}
+	return c
+}
+
+// countTierHit records in the writable index that tier t served a hit, for
+// `cache info`. A no-op without tiers.
+func (c *Cache) countTierHit(ctx context.Context, t *Cache, hit bool) {
+	if !hit || c.tiers == nil {
+		return
+	}
+	_, _ = c.db.ExecContext(ctx,
+		`INSERT INTO tier_hits(dir, hits, last_hit) VALUES(?, 1, ?)
+		 ON CONFLICT(dir) DO UPDATE SET hits = hits + 1, last_hit = excluded.last_hit`,
+		t.cfg.Dir, time.Now().Unix())
+}
+
+// TierInfo describes one cache tier for `cache info`.
+type TierInfo struct {
+	LastHit  time.Time
+	Dir      string
+	Blobs    int
+	Size     int64
+	Hits     int64
+	ReadOnly bool
+}
+
+// Tiers reports every tier in lookup order, with the hits each has served
+// since tiers were configured.
+func (c *Cache) Tiers(ctx context.Context) ([]TierInfo, error) {
+	var out []TierInfo
+	for _, t := range c.lookupOrder() {
+		info := TierInfo{Dir: t.cfg.Dir, ReadOnly: t.readOnly}
+		var err error
+		if info.Blobs, err = t.BlobCount(ctx); err != nil {
+			return nil, fmt.Errorf("tier %s: %w", t.cfg.Dir, err)
+		}
+		if info.Size, err = t.TotalSize(ctx); err != nil {
+			return nil, fmt.Errorf("tier %s: %w", t.cfg.Dir, err)
+		}
+		var lastHit int64
+		if err := c.db.QueryRowContext(ctx, `SELECT hits, last_hit FROM tier_hits WHERE dir = ?`, t.cfg.Dir).
+			Scan(&info.Hits, &lastHit); err == nil {
+			info.LastHit = time.Unix(lastHit, 0)
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
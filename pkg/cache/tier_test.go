@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTiers(t *testing.T) {
+	ctx := context.Background()
+	long := time.Now().Add(-48 * time.Hour)
+
+	// An admin-managed cache with curated reference data.
+	sharedDir := t.TempDir()
+	shared, err := Open(Config{Dir: sharedDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := seedBlob(t, shared, "GRCh38 annotation", long, long, 0)
+	if err := shared.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	userDir := t.TempDir()
+	c, err := Open(Config{
+		Dir:          userDir,
+		LinkStrategy: StrategyCopy,
+		Tiers:        []Tier{{Dir: sharedDir, ReadOnly: true}, {Dir: userDir}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	e, hit, err := c.Lookup(ctx, "https://example.com/"+ref[:8])
+	if err != nil || !hit || e.SHA256 != ref || e.Tier != sharedDir {
+		t.Fatalf("Lookup = %+v, %v, %v; want a hit from the shared tier", e, hit, err)
+	}
+	if _, _, hit, _ := c.GetByDigest(ctx, "sha256", ref); !hit {
+		t.Error("GetByDigest missed the shared tier")
+	}
+	dest := filepath.Join(t.TempDir(), "genes.gtf")
+	if err := c.Materialize(ref, dest); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(dest); string(got) != "GRCh38 annotation" {
+		t.Errorf("materialized %q", got)
+	}
+
+	// New blobs go to the writable tier.
+	mine := seedBlob(t, c, "my counts", time.Now(), time.Now(), 0)
+	if _, err := os.Stat(c.blobPath(mine)); err != nil {
+		t.Errorf("new blob not in the user tier: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(sharedDir, "blobs", "sha256", mine[:2], mine)); !os.IsNotExist(err) {
+		t.Errorf("new blob written to the read-only tier: %v", err)
+	}
+
+	blobs, err := c.ListBlobs(ctx, "")
+	if err != nil || len(blobs) != 2 {
+		t.Fatalf("ListBlobs = %+v, %v", blobs, err)
+	}
+	for _, b := range blobs {
+		wantTier, wantRO := userDir, false
+		if b.SHA256 == ref {
+			wantTier, wantRO = sharedDir, true
+		}
+		if b.Tier != wantTier || b.ReadOnly != wantRO {
+			t.Errorf("blob %s: tier %s (read-only %v), want %s", b.SHA256[:8], b.Tier, b.ReadOnly, wantTier)
+		}
+	}
+
+	tiers, err := c.Tiers(ctx)
+	if err != nil || len(tiers) != 2 {
+		t.Fatalf("Tiers = %+v, %v", tiers, err)
+	}
+	if tiers[0].Dir != sharedDir || !tiers[0].ReadOnly || tiers[0].Hits != 2 || tiers[0].Blobs != 1 {
+		t.Errorf("shared tier = %+v, want 1 blob and 2 hits", tiers[0])
+	}
+	if tiers[1].Dir != userDir || tiers[1].ReadOnly {
+		t.Errorf("user tier = %+v", tiers[1])
+	}
+
+	// The shared index was only read.
+	shared, err = Open(Config{Dir: sharedDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shared.Close()
+	if blobs, err := shared.gcBlobs(ctx); err != nil || len(blobs) != 1 || !blobs[0].lastUsed.Equal(time.Unix(long.Unix(), 0)) {
+		t.Errorf("shared tier touched: %+v, %v", blobs, err)
+	}
+}
+
+// TestTiers_ReadOnlyDirectory opens a read-only tier whose directory its
+// reader cannot write. The index must be read without creating the WAL
+// shared-memory file next to it.
+func TestTiers_ReadOnlyDirectory(t *testing.T) {
+	ctx := context.Background()
+	sharedDir := t.TempDir()
+	shared, err := Open(Config{Dir: sharedDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := seedBlob(t, shared, "GRCh38 annotation", time.Now(), time.Now(), 0)
+	if err := shared.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(sharedDir, 0o555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(sharedDir, 0o755) })
+
+	userDir := t.TempDir()
+	c, err := Open(Config{Dir: userDir, Tiers: []Tier{{Dir: sharedDir, ReadOnly: true}, {Dir: userDir}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if len(c.tiers) != 2 {
+		t.Fatalf("tiers = %d, want the read-only tier kept", len(c.tiers))
+	}
+	if e, hit, err := c.Lookup(ctx, "https://example.com/"+ref[:8]); err != nil || !hit || e.Tier != sharedDir {
+		t.Fatalf("Lookup = %+v, %v, %v; want a hit from the read-only tier", e, hit, err)
+	}
+	if _, err := os.Stat(filepath.Join(sharedDir, "index.db-shm")); !os.IsNotExist(err) {
+		t.Errorf("reading the tier created index.db-shm: %v", err)
+	}
+}
+
+// TestTiers_NewerEntryWins checks that a URL refetched into the writable tier
+// after an upstream change is served instead of the read-only tier's stale
+// copy.
+func TestTiers_NewerEntryWins(t *testing.T) {
+	ctx := context.Background()
+	long := time.Now().Add(-48 * time.Hour)
+	sharedDir := t.TempDir()
+	shared, err := Open(Config{Dir: sharedDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := seedBlob(t, shared, "annotation v1", long, long, 0)
+	rawURL := "https://example.com/" + old[:8]
+	if _, err := shared.db.ExecContext(ctx, `UPDATE urls SET fetched_at = ?`, long.Unix()); err != nil {
+		t.Fatal(err)
+	}
+	if err := shared.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	userDir := t.TempDir()
+	c, err := Open(Config{Dir: userDir, Tiers: []Tier{{Dir: sharedDir, ReadOnly: true}, {Dir: userDir}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	fresh := seedBlob(t, c, "annotation v2", time.Now(), time.Now(), 0)
+	f, err := c.NewTmpFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("annotation v2")
+	_ = f.Close()
+	if err := c.Put(ctx, rawURL, f.Name(), fresh); err != nil {
+		t.Fatal(err)
+	}
+
+	e, hit, err := c.Lookup(ctx, rawURL)
+	if err != nil || !hit || e.SHA256 != fresh || e.Tier != userDir {
+		t.Errorf("Lookup = %+v, %v, %v; want the refetched copy from the user tier", e, hit, err)
+	}
+}
@@ -5,8 +5,32 @@ import (
 	"strings"
 )
 
+// credentialParams are query parameters that carry credentials rather than
+// identify a resource. They are dropped so secrets never reach the urls table.
+var credentialParams = []string{"access_token", "token", "api_key", "apikey"}
+
+// HasCredentials reports whether rawURL carries credentials in its userinfo
+// or in one of the query parameters canonicalizeURL strips.
+func HasCredentials(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if u.User != nil {
+		return true
+	}
+	q := u.Query()
+	for _, p := range credentialParams {
+		if q.Has(p) {
+			return true
+		}
+	}
+	return false
+}
+
 // canonicalizeURL normalizes a URL for use as a cache key.
-// Rules: lowercase scheme+host, strip default ports, drop fragment, preserve query.
+// Rules: lowercase scheme+host, strip default ports, drop fragment, drop
+// userinfo and credential query parameters, preserve the rest of the query.
 func canonicalizeURL(rawURL string) (string, error) {
 	u, err := url.Parse(rawURL)
 	if err != nil {
@@ -40,6 +64,21 @@ func canonicalizeURL(rawURL string) (string, error) {
 
 	u.Fragment = ""
 	u.RawFragment = ""
+	u.User = nil
+
+	if u.RawQuery != "" {
+		q := u.Query()
+		stripped := false
+		for _, p := range credentialParams {
+			if q.Has(p) {
+				q.Del(p)
+				stripped = true
+			}
+		}
+		if stripped {
+			u.RawQuery = q.Encode()
+		}
+	}
 
 	return u.String(), nil
 }
@@ -0,0 +1,23 @@
+package downloaders
+
+// Authenticator is an optional capability a Downloader implements when it can
+// use credentials to reach restricted or embargoed records.
+type Authenticator interface {
+	// HasCredentials reports whether credentials were configured.
+	HasCredentials() bool
+}
+
+// AuthStatus describes a registered downloader's credential support:
+// "configured", "supported" (accepts credentials but none are set) or ""
+// (public access only).
+func AuthStatus(d Downloader) string {
+	a, ok := d.(Authenticator)
+	switch {
+	case !ok:
+		return ""
+	case a.HasCredentials():
+		return "configured"
+	default:
+		return "supported"
+	}
+}
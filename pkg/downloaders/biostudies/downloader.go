@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/btraven00/hapiq/pkg/cache"
 	"github.com/btraven00/hapiq/pkg/downloaders"
 	"github.com/btraven00/hapiq/internal/version"
 	"github.com/btraven00/hapiq/pkg/downloaders/common"
@@ -22,6 +23,7 @@ var idPattern = regexp.MustCompile(`^(S-[A-Z]{2,}\d+|E-[A-Z]+-\d+)$`)
 // BioStudiesDownloader downloads files attached to BioStudies studies.
 type BioStudiesDownloader struct {
 	client  *http.Client
+	baseURL string // overrides apiBase for search; used in tests
 	timeout time.Duration
 	verbose bool
 }
@@ -40,6 +42,12 @@ func WithTimeout(t time.Duration) Option {
 // WithVerbose toggles progress logging to stderr.
 func WithVerbose(v bool) Option { return func(d *BioStudiesDownloader) { d.verbose = v } }
 
+// WithBaseURL overrides the BioStudies API base URL used by Search. Intended
+// for tests.
+func WithBaseURL(u string) Option {
+	return func(d *BioStudiesDownloader) { d.baseURL = strings.TrimRight(u, "/") }
+}
+
 // NewBioStudiesDownloader creates a new downloader.
 func NewBioStudiesDownloader(opts ...Option) *BioStudiesDownloader {
 	d := &BioStudiesDownloader{timeout: 60 * time.Second}
@@ -214,7 +222,7 @@ func (d *BioStudiesDownloader) Download(ctx context.Context, req *downloaders.Do
 			fmt.Fprintf(os.Stderr, "⬇️  %s → %s\n", f.Path, srcURL)
 		}
 
-		fi, err := d.downloadFile(ctx, srcURL, targetPath)
+		fi, err := d.downloadFile(ctx, srcURL, targetPath, opts != nil && opts.Resume)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("failed %s: %v", f.Path, err))
 			continue
@@ -256,8 +264,8 @@ func (d *BioStudiesDownloader) Download(ctx context.Context, req *downloaders.Do
 	return result, nil
 }
 
-func (d *BioStudiesDownloader) downloadFile(ctx context.Context, rawURL, targetPath string) (*downloaders.FileInfo, error) {
-	result, err := common.Fetch(ctx, rawURL, targetPath, common.FetchOptions{Client: d.client})
+func (d *BioStudiesDownloader) downloadFile(ctx context.Context, rawURL, targetPath string, resume bool) (*downloaders.FileInfo, error) {
+	result, err := common.Fetch(ctx, rawURL, targetPath, common.FetchOptions{Client: d.client, Retry: common.RetryPolicyFor(d.GetSourceType()), Freshness: cache.FreshnessFor(d.GetSourceType()), Resume: resume})
 	if err != nil {
 		return nil, err
 	}
@@ -271,6 +279,7 @@ func (d *BioStudiesDownloader) downloadFile(ctx context.Context, rawURL, targetP
 		DownloadTime: time.Now(),
 		ContentType:  result.ContentType,
 		CacheHit:     result.Hit,
+		Resumed:      result.Resumed,
 	}, nil
 }
 
@@ -0,0 +1,119 @@
+package biostudies
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/btraven00/hapiq/pkg/downloaders"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchPageSize  = 100
+)
+
+// searchResponse is the minimal projection of the BioStudies search JSON.
+type searchResponse struct {
+	TotalHits int         `json:"totalHits"`
+	Hits      []searchHit `json:"hits"`
+}
+
+type searchHit struct {
+	Accession   string `json:"accession"`
+	Type        string `json:"type"` // study, array, …
+	Title       string `json:"title"`
+	Files       int    `json:"files"`
+	ReleaseDate string `json:"release_date"`
+}
+
+// Search implements the Searcher interface using the BioStudies search API.
+//
+// SearchOptions map onto the API as follows:
+//
+//	--collection arrayexpress   →  /arrayexpress/search (ArrayExpress only)
+//	--organism "Homo sapiens"   →  facet.organism=homo sapiens
+//	--type "RNA-seq of coding RNA" → facet.study_type=rna-seq of coding rna
+//
+// Facet values are lower-cased, as the API stores them.
+func (d *BioStudiesDownloader) Search(ctx context.Context, query string, opts downloaders.SearchOptions) ([]downloaders.SearchResult, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	u := d.searchURL(query, opts, min(limit, maxSearchPageSize))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("biostudies search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && opts.Collection != "" {
+		return nil, fmt.Errorf("biostudies search: unknown collection %q", opts.Collection)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("biostudies search: HTTP %d for %s", resp.StatusCode, u)
+	}
+
+	var sr searchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&sr); err != nil {
+		return nil, fmt.Errorf("biostudies search: parse response: %w", err)
+	}
+
+	results := make([]downloaders.SearchResult, 0, len(sr.Hits))
+	for _, h := range sr.Hits {
+		if len(results) >= limit {
+			break
+		}
+		results = append(results, hitToSearchResult(h))
+	}
+	return results, nil
+}
+
+// searchURL builds the search request URL for query and opts.
+func (d *BioStudiesDownloader) searchURL(query string, opts downloaders.SearchOptions, pageSize int) string {
+	base := d.baseURL
+	if base == "" {
+		base = apiBase
+	}
+	if c := strings.ToLower(strings.TrimSpace(opts.Collection)); c != "" {
+		base += "/" + url.PathEscape(c)
+	}
+
+	params := url.Values{
+		"query":    {query},
+		"pageSize": {strconv.Itoa(pageSize)},
+		"page":     {"1"},
+	}
+	if opts.Organism != "" {
+		params.Set("facet.organism", strings.ToLower(opts.Organism))
+	}
+	if opts.EntryType != "" {
+		params.Set("facet.study_type", strings.ToLower(opts.EntryType))
+	}
+	return base + "/search?" + params.Encode()
+}
+
+// hitToSearchResult converts a search hit to the common SearchResult type.
+// Search hits carry no organism or study type, so those stay empty rather
+// than echoing the --organism/--type filters.
+func hitToSearchResult(h searchHit) downloaders.SearchResult {
+	return downloaders.SearchResult{
+		Accession: h.Accession,
+		Title:     h.Title,
+		EntryType: h.Type,
+		Date:      h.ReleaseDate,
+		FileCount: h.Files,
+	}
+}
@@ -0,0 +1,73 @@
+package biostudies
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/btraven00/hapiq/pkg/downloaders"
+)
+
+func TestSearch_MapsOptionsToAPI(t *testing.T) {
+	var got *url.URL
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL
+		_, _ = w.Write([]byte(`{"totalHits": 3, "hits": [
+			{"accession": "E-MTAB-8077", "type": "study", "title": "Liver scRNA-seq", "files": 12, "release_date": "2019-08-30"},
+			{"accession": "E-MTAB-1", "type": "study", "title": "Second", "files": 2, "release_date": "2012-01-01"},
+			{"accession": "E-MTAB-2", "type": "study", "title": "Third", "files": 1, "release_date": "2013-01-01"}
+		]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	d := NewBioStudiesDownloader(WithBaseURL(srv.URL))
+	results, err := d.Search(context.Background(), "liver", downloaders.SearchOptions{
+		Collection: "ArrayExpress",
+		Organism:   "Homo sapiens",
+		EntryType:  "RNA-seq of coding RNA",
+		Limit:      2,
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	if got.Path != "/arrayexpress/search" {
+		t.Errorf("path = %q, want /arrayexpress/search", got.Path)
+	}
+	q := got.Query()
+	if q.Get("query") != "liver" || q.Get("pageSize") != "2" ||
+		q.Get("facet.organism") != "homo sapiens" || q.Get("facet.study_type") != "rna-seq of coding rna" {
+		t.Errorf("query = %v", q)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("results = %d, want limit of 2", len(results))
+	}
+	r := results[0]
+	if r.Accession != "E-MTAB-8077" || r.FileCount != 12 || r.Date != "2019-08-30" {
+		t.Errorf("result = %+v", r)
+	}
+	if r.Organism != "" || r.DatasetType != "" {
+		t.Errorf("result echoes the search filters: %+v", r)
+	}
+}
+
+func TestSearch_AllCollections(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_, _ = w.Write([]byte(`{"totalHits": 0, "hits": []}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	d := NewBioStudiesDownloader(WithBaseURL(srv.URL))
+	if _, err := d.Search(context.Background(), "pancreas", downloaders.SearchOptions{}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if path != "/search" {
+		t.Errorf("path = %q, want /search", path)
+	}
+	var _ downloaders.Searcher = d
+}
@@ -0,0 +1,271 @@
+package cellxgene
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/btraven00/hapiq/internal/version"
+	"github.com/btraven00/hapiq/pkg/cache"
+	"github.com/btraven00/hapiq/pkg/downloaders"
+	"github.com/btraven00/hapiq/pkg/downloaders/common"
+)
+
+// idPattern matches a collection or dataset UUID.
+var idPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// CellxgeneDownloader fetches h5ad/rds assets from CZ CELLxGENE Discover.
+type CellxgeneDownloader struct {
+	c       *client
+	timeout time.Duration
+	verbose bool
+}
+
+// Option configures a CellxgeneDownloader.
+type Option func(*CellxgeneDownloader)
+
+// WithTimeout sets the HTTP timeout.
+func WithTimeout(t time.Duration) Option {
+	return func(d *CellxgeneDownloader) {
+		d.timeout = t
+		d.c.http.Timeout = t
+	}
+}
+
+// WithVerbose toggles progress logging to stderr.
+func WithVerbose(v bool) Option { return func(d *CellxgeneDownloader) { d.verbose = v } }
+
+// WithBaseURL overrides the Curation API base URL. Intended for tests.
+func WithBaseURL(u string) Option {
+	return func(d *CellxgeneDownloader) { d.c.baseURL = strings.TrimRight(u, "/") }
+}
+
+// NewCellxgeneDownloader creates a new downloader.
+func NewCellxgeneDownloader(opts ...Option) *CellxgeneDownloader {
+	d := &CellxgeneDownloader{timeout: 60 * time.Second}
+	d.c = &client{http: &http.Client{Timeout: d.timeout}}
+	for _, o := range opts {
+		o(d)
+	}
+	return d
+}
+
+// GetSourceType returns the source identifier.
+func (d *CellxgeneDownloader) GetSourceType() string { return "cellxgene" }
+
+// Validate checks the UUID format and that it resolves to a collection or
+// dataset. UUIDs are shared with other sources (e.g. hca), so the format
+// alone is not enough for auto-detection.
+func (d *CellxgeneDownloader) Validate(ctx context.Context, id string) (*downloaders.ValidationResult, error) {
+	clean := strings.ToLower(strings.TrimSpace(id))
+	result := &downloaders.ValidationResult{ID: clean, SourceType: d.GetSourceType()}
+	if !idPattern.MatchString(clean) {
+		result.Errors = []string{fmt.Sprintf("invalid CELLxGENE ID %q: expected a collection or dataset UUID", id)}
+		return result, nil
+	}
+	if _, _, err := d.c.resolve(ctx, clean); err != nil {
+		result.Errors = []string{err.Error()}
+		return result, nil
+	}
+	result.Valid = true
+	return result, nil
+}
+
+// GetMetadata returns collection-level metadata and the selected datasets'
+// assets without downloading them.
+func (d *CellxgeneDownloader) GetMetadata(ctx context.Context, id string) (*downloaders.Metadata, error) {
+	clean := strings.ToLower(strings.TrimSpace(id))
+	coll, datasets, err := d.c.resolve(ctx, clean)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &downloaders.Metadata{
+		Source:      d.GetSourceType(),
+		ID:          clean,
+		Title:       coll.Name,
+		Description: coll.Description,
+		DOI:         coll.DOI,
+	}
+	if len(datasets) == 1 && clean == datasets[0].DatasetID {
+		meta.Title = datasets[0].Title
+	}
+
+	var cells int64
+	for _, ds := range datasets {
+		cells += ds.CellCount
+		var dsFiles int
+		var dsSize int64
+		for _, a := range ds.Assets {
+			if a.downloadable() {
+				dsFiles++
+				dsSize += a.FileSize
+			}
+		}
+		meta.FileCount += dsFiles
+		meta.TotalSize += dsSize
+		meta.Collections = append(meta.Collections, downloaders.Collection{
+			Type:          "dataset",
+			ID:            ds.DatasetID,
+			Title:         ds.Title,
+			FileCount:     dsFiles,
+			EstimatedSize: dsSize,
+		})
+	}
+
+	custom := map[string]any{
+		"collection_id": coll.CollectionID,
+		"datasets":      len(datasets),
+		"cell_count":    cells,
+	}
+	if coll.DOI != "" {
+		custom["doi"] = coll.DOI
+	}
+	if v := labels(datasets, organisms); len(v) > 0 {
+		custom["organism"] = v
+	}
+	if v := labels(datasets, assays); len(v) > 0 {
+		custom["assay"] = v
+	}
+	if v := labels(datasets, tissues); len(v) > 0 {
+		custom["tissue"] = v
+	}
+	meta.Custom = custom
+	return meta, nil
+}
+
+// Download fetches the (filtered) h5ad/rds assets of the selected datasets.
+func (d *CellxgeneDownloader) Download(ctx context.Context, req *downloaders.DownloadRequest) (*downloaders.DownloadResult, error) {
+	start := time.Now()
+	result := &downloaders.DownloadResult{Files: []downloaders.FileInfo{}}
+
+	_, datasets, err := d.c.resolve(ctx, strings.ToLower(strings.TrimSpace(req.ID)))
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result, nil
+	}
+
+	var assets []Asset
+	for _, ds := range datasets {
+		for _, a := range ds.Assets {
+			if a.downloadable() {
+				assets = append(assets, a)
+			}
+		}
+	}
+	if len(assets) == 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("CELLxGENE %s has no h5ad or rds assets", req.ID))
+	}
+
+	opts := req.Options
+
+	if opts != nil && opts.DryRun {
+		for _, a := range assets {
+			if !downloaders.ShouldDownload(a.FileName(), a.FileSize, opts) {
+				continue
+			}
+			result.Files = append(result.Files, downloaders.FileInfo{
+				OriginalName: a.FileName(),
+				Size:         a.FileSize,
+				SourceURL:    a.URL,
+			})
+		}
+		result.Success = true
+		return result, nil
+	}
+
+	if err := common.EnsureDirectory(req.OutputDir); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result, nil
+	}
+
+	downloaded := 0
+	for _, a := range assets {
+		name := a.FileName()
+		if !downloaders.ShouldDownload(name, a.FileSize, opts) {
+			continue
+		}
+		if opts != nil && opts.LimitFiles > 0 && downloaded >= opts.LimitFiles {
+			break
+		}
+
+		targetPath := filepath.Join(req.OutputDir, common.SanitizeFilename(name))
+
+		if opts != nil && opts.SkipExisting {
+			if _, err := os.Stat(targetPath); err == nil {
+				if d.verbose {
+					fmt.Fprintf(os.Stderr, "⏭️  Skipping existing: %s\n", name)
+				}
+				continue
+			}
+		}
+
+		if d.verbose {
+			fmt.Fprintf(os.Stderr, "⬇️  %s (%s)\n", name, common.FormatBytes(a.FileSize))
+		}
+
+		fi, err := d.downloadFile(ctx, a.URL, targetPath, opts != nil && opts.Resume)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed %s: %v", name, err))
+			continue
+		}
+		result.Files = append(result.Files, *fi)
+		result.BytesDownloaded += fi.Size
+		downloaded++
+	}
+
+	result.Duration = time.Since(start)
+	result.BytesTotal = result.BytesDownloaded
+	result.Success = len(result.Errors) == 0
+
+	if req.Metadata != nil && len(result.Files) > 0 {
+		witness := &downloaders.WitnessFile{
+			HapiqVersion: version.String(),
+			DownloadTime: start,
+			Source:       d.GetSourceType(),
+			OriginalID:   req.ID,
+			Metadata:     req.Metadata,
+			Files:        make([]downloaders.FileWitness, len(result.Files)),
+			DownloadStats: &downloaders.DownloadStats{
+				Duration:        result.Duration,
+				BytesDownloaded: result.BytesDownloaded,
+				FilesDownloaded: len(result.Files),
+			},
+			Options: req.Options,
+		}
+		for i, f := range result.Files {
+			witness.Files[i] = downloaders.FileWitness(f)
+		}
+		if err := common.WriteWitnessFile(req.OutputDir, witness); err != nil {
+			result.Warnings = append(result.Warnings, "witness file: "+err.Error())
+		} else {
+			result.WitnessFile = filepath.Join(req.OutputDir, "hapiq.json")
+		}
+	}
+
+	return result, nil
+}
+
+func (d *CellxgeneDownloader) downloadFile(ctx context.Context, rawURL, targetPath string, resume bool) (*downloaders.FileInfo, error) {
+	result, err := common.Fetch(ctx, rawURL, targetPath, common.FetchOptions{Client: d.c.http, Retry: common.RetryPolicyFor(d.GetSourceType()), Freshness: cache.FreshnessFor(d.GetSourceType()), Resume: resume})
+	if err != nil {
+		return nil, err
+	}
+	return &downloaders.FileInfo{
+		Path:         targetPath,
+		OriginalName: filepath.Base(targetPath),
+		Size:         result.N,
+		Checksum:     result.SHA256,
+		ChecksumType: "sha256",
+		SourceURL:    rawURL,
+		DownloadTime: time.Now(),
+		ContentType:  result.ContentType,
+		CacheHit:     result.Hit,
+		Resumed:      result.Resumed,
+	}, nil
+}
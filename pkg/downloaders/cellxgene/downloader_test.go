@@ -0,0 +1,188 @@
+package cellxgene
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/btraven00/hapiq/pkg/cache"
+	"github.com/btraven00/hapiq/pkg/downloaders"
+)
+
+const (
+	testCollection = "b52eb423-5d0d-4645-b217-e1c6d38b2e72"
+	testDataset    = "0895c838-e550-48a3-a777-dbcd35d30272"
+	otherDataset   = "1a2b3c4d-0000-4000-8000-000000000002"
+)
+
+// newFakeCurationServer serves one collection with two datasets, the dataset
+// versions endpoint, the collection list and the asset blobs.
+func newFakeCurationServer(t *testing.T, blobGETs *atomic.Int32) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	collection := func() Collection {
+		return Collection{
+			CollectionID: testCollection,
+			Name:         "Human lung cell atlas",
+			Description:  "Healthy and fibrotic lung",
+			DOI:          "10.1038/s41586-020-2922-4",
+			PublishedAt:  "2023-02-01T12:00:00Z",
+			Datasets: []Dataset{
+				{
+					DatasetID: testDataset, CollectionID: testCollection, Title: "Lung epithelium", CellCount: 100,
+					Organism: []OntologyTerm{{Label: "Homo sapiens"}},
+					Assay:    []OntologyTerm{{Label: "10x 3' v3"}},
+					Tissue:   []OntologyTerm{{Label: "lung"}},
+					Assets: []Asset{
+						{FileType: "H5AD", URL: srv.URL + "/blobs/" + testDataset + ".h5ad", FileSize: 9},
+						{FileType: "RDS", URL: srv.URL + "/blobs/" + testDataset + ".rds", FileSize: 8},
+						{FileType: "ATAC_FRAGMENT", URL: srv.URL + "/blobs/fragments.tsv.gz", FileSize: 1},
+					},
+				},
+				{
+					DatasetID: otherDataset, CollectionID: testCollection, Title: "Lung immune", CellCount: 50,
+					Organism: []OntologyTerm{{Label: "Homo sapiens"}},
+					Assay:    []OntologyTerm{{Label: "Smart-seq2"}},
+					Tissue:   []OntologyTerm{{Label: "lung"}, {Label: "blood"}},
+					Assets:   []Asset{{FileType: "H5AD", URL: srv.URL + "/blobs/" + otherDataset + ".h5ad", FileSize: 9}},
+				},
+			},
+		}
+	}
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/collections":
+			_ = json.NewEncoder(w).Encode([]Collection{
+				{CollectionID: "00000000-0000-4000-8000-000000000000", Name: "Mouse brain"},
+				collection(),
+			})
+		case "/collections/" + testCollection:
+			_ = json.NewEncoder(w).Encode(collection())
+		case "/datasets/" + testDataset + "/versions":
+			_ = json.NewEncoder(w).Encode([]Dataset{{DatasetID: testDataset, CollectionID: testCollection}})
+		default:
+			if strings.HasPrefix(r.URL.Path, "/blobs/") {
+				blobGETs.Add(1)
+				_, _ = w.Write([]byte(strings.Repeat("x", 8)))
+				return
+			}
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestValidate(t *testing.T) {
+	var gets atomic.Int32
+	srv := newFakeCurationServer(t, &gets)
+	d := NewCellxgeneDownloader(WithBaseURL(srv.URL))
+	ctx := context.Background()
+
+	for _, id := range []string{testCollection, strings.ToUpper(testDataset)} {
+		if res, err := d.Validate(ctx, id); err != nil || !res.Valid {
+			t.Errorf("Validate(%q) = %+v, %v; want valid", id, res, err)
+		}
+	}
+	for _, id := range []string{"not-a-uuid", "cc95ff89-2e68-4a08-a234-480eca21ce79"} {
+		if res, _ := d.Validate(ctx, id); res.Valid {
+			t.Errorf("Validate(%q) valid; want rejected", id)
+		}
+	}
+}
+
+func TestGetMetadata_Collection(t *testing.T) {
+	var gets atomic.Int32
+	srv := newFakeCurationServer(t, &gets)
+	d := NewCellxgeneDownloader(WithBaseURL(srv.URL))
+
+	meta, err := d.GetMetadata(context.Background(), testCollection)
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if meta.FileCount != 3 || meta.TotalSize != 26 || len(meta.Collections) != 2 {
+		t.Errorf("files=%d size=%d datasets=%d, want 3, 26, 2", meta.FileCount, meta.TotalSize, len(meta.Collections))
+	}
+	if meta.Custom["doi"] != "10.1038/s41586-020-2922-4" || meta.Custom["cell_count"] != int64(150) {
+		t.Errorf("custom = %v", meta.Custom)
+	}
+	if got := meta.Custom["tissue"].([]string); len(got) != 2 || got[0] != "lung" || got[1] != "blood" {
+		t.Errorf("tissue = %v, want [lung blood]", got)
+	}
+	if got := meta.Custom["assay"].([]string); len(got) != 2 {
+		t.Errorf("assay = %v, want both assays", got)
+	}
+}
+
+func TestDownload_DatasetThroughCache(t *testing.T) {
+	var gets atomic.Int32
+	srv := newFakeCurationServer(t, &gets)
+	d := NewCellxgeneDownloader(WithBaseURL(srv.URL))
+
+	c, err := cache.Open(cache.Config{Dir: t.TempDir(), LinkStrategy: cache.StrategyHardlink})
+	if err != nil {
+		t.Fatalf("cache.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	ctx := cache.WithCache(context.Background(), c)
+
+	meta, err := d.GetMetadata(ctx, testDataset)
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if meta.Title != "Lung epithelium" || meta.FileCount != 2 {
+		t.Errorf("dataset metadata = %+v", meta)
+	}
+
+	for run := 1; run <= 2; run++ {
+		out := t.TempDir()
+		res, err := d.Download(ctx, &downloaders.DownloadRequest{ID: testDataset, OutputDir: out, Metadata: meta})
+		if err != nil || !res.Success {
+			t.Fatalf("run %d: %+v, %v", run, res, err)
+		}
+		if len(res.Files) != 2 {
+			t.Fatalf("run %d: %d files, want h5ad and rds only", run, len(res.Files))
+		}
+		if _, err := os.Stat(filepath.Join(out, testDataset+".h5ad")); err != nil {
+			t.Errorf("run %d: %v", run, err)
+		}
+		if _, err := os.Stat(filepath.Join(out, "hapiq.json")); err != nil {
+			t.Errorf("run %d: witness: %v", run, err)
+		}
+	}
+	if n := gets.Load(); n != 2 {
+		t.Errorf("blob GETs = %d, want 2 (second run served from cache)", n)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var gets atomic.Int32
+	srv := newFakeCurationServer(t, &gets)
+	d := NewCellxgeneDownloader(WithBaseURL(srv.URL))
+	ctx := context.Background()
+
+	results, err := d.Search(ctx, "Lung", downloaders.SearchOptions{Organism: "homo sapiens", EntryType: "smart-seq"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("results = %+v, want the lung collection", results)
+	}
+	r := results[0]
+	if r.Accession != testCollection || r.Date != "2023-02-01" || r.SampleCount != 2 || r.FileSize != 26 {
+		t.Errorf("result = %+v", r)
+	}
+
+	if results, _ := d.Search(ctx, "lung", downloaders.SearchOptions{Organism: "Mus musculus"}); len(results) != 0 {
+		t.Errorf("organism filter ignored: %+v", results)
+	}
+	if results, _ := d.Search(ctx, "", downloaders.SearchOptions{Limit: 1}); len(results) != 1 {
+		t.Errorf("limit ignored: %d results", len(results))
+	}
+}
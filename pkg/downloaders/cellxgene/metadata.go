@@ -0,0 +1,200 @@
+// Package cellxgene downloads datasets from CZ CELLxGENE Discover
+// (https://cellxgene.cziscience.com) via the public Curation API.
+//
+// Both collection and dataset UUIDs are accepted, e.g.
+//
+//	b52eb423-5d0d-4645-b217-e1c6d38b2e72   (collection → all datasets)
+//	0895c838-e550-48a3-a777-dbcd35d30272   (single dataset)
+//
+// Each dataset is published as an h5ad (AnnData) and, for older releases,
+// an rds (Seurat) asset; both are listed with their sizes.
+package cellxgene
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const apiBase = "https://api.cellxgene.cziscience.com/curation/v1"
+
+// errNotFound is returned by get for HTTP 404.
+var errNotFound = errors.New("not found")
+
+// Collection is the trimmed Curation API collection document.
+type Collection struct {
+	CollectionID  string    `json:"collection_id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	DOI           string    `json:"doi"`
+	CollectionURL string    `json:"collection_url"`
+	PublishedAt   timestamp `json:"published_at"`
+	Datasets      []Dataset `json:"datasets"`
+}
+
+// Dataset is one dataset inside a collection.
+type Dataset struct {
+	DatasetID    string         `json:"dataset_id"`
+	CollectionID string         `json:"collection_id"`
+	Title        string         `json:"title"`
+	CellCount    int64          `json:"cell_count"`
+	Organism     []OntologyTerm `json:"organism"`
+	Assay        []OntologyTerm `json:"assay"`
+	Tissue       []OntologyTerm `json:"tissue"`
+	Assets       []Asset        `json:"assets"`
+}
+
+// OntologyTerm is a labelled ontology annotation (organism, assay, tissue).
+type OntologyTerm struct {
+	Label          string `json:"label"`
+	OntologyTermID string `json:"ontology_term_id"`
+}
+
+// Asset is one downloadable file of a dataset.
+type Asset struct {
+	FileType string `json:"filetype"` // H5AD, RDS, ATAC_FRAGMENT, …
+	URL      string `json:"url"`
+	FileSize int64  `json:"filesize"`
+}
+
+// FileName returns the asset's base name as served by the datasets bucket.
+func (a Asset) FileName() string {
+	if u, err := url.Parse(a.URL); err == nil && path.Base(u.Path) != "/" {
+		return path.Base(u.Path)
+	}
+	return ""
+}
+
+// downloadable reports whether the asset is an h5ad or rds matrix.
+func (a Asset) downloadable() bool {
+	switch strings.ToUpper(a.FileType) {
+	case "H5AD", "RDS":
+		return a.URL != ""
+	}
+	return false
+}
+
+// timestamp accepts the API's publication dates as either an ISO string or
+// a Unix epoch number.
+type timestamp string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *timestamp) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = timestamp(s)
+		return nil
+	}
+	var f float64
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil // unknown shape; leave empty rather than fail the record
+	}
+	*t = timestamp(strconv.FormatFloat(f, 'f', 0, 64))
+	return nil
+}
+
+// client talks to the Curation API.
+type client struct {
+	http    *http.Client
+	baseURL string // overrides apiBase; used in tests
+}
+
+func (c *client) get(ctx context.Context, p string, v any) error {
+	base := c.baseURL
+	if base == "" {
+		base = apiBase
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+p, http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("cellxgene: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("cellxgene: HTTP %d for %s: %s", resp.StatusCode, p, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("cellxgene: parse %s: %w", p, err)
+	}
+	return nil
+}
+
+// resolve returns the collection id belongs to and the datasets it selects:
+// every dataset for a collection UUID, or just the one for a dataset UUID.
+func (c *client) resolve(ctx context.Context, id string) (*Collection, []Dataset, error) {
+	var coll Collection
+	err := c.get(ctx, "/collections/"+url.PathEscape(id), &coll)
+	if err == nil {
+		return &coll, coll.Datasets, nil
+	}
+	if !errors.Is(err, errNotFound) {
+		return nil, nil, err
+	}
+
+	// Not a collection: a dataset's versions carry its collection_id.
+	var versions []Dataset
+	if err := c.get(ctx, "/datasets/"+url.PathEscape(id)+"/versions", &versions); err != nil {
+		if errors.Is(err, errNotFound) {
+			return nil, nil, fmt.Errorf("cellxgene: %s is neither a collection nor a dataset", id)
+		}
+		return nil, nil, err
+	}
+	if len(versions) == 0 || versions[0].CollectionID == "" {
+		return nil, nil, fmt.Errorf("cellxgene: dataset %s has no published version", id)
+	}
+	if err := c.get(ctx, "/collections/"+url.PathEscape(versions[0].CollectionID), &coll); err != nil {
+		return nil, nil, err
+	}
+	for _, ds := range coll.Datasets {
+		if ds.DatasetID == id {
+			return &coll, []Dataset{ds}, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("cellxgene: dataset %s not found in collection %s", id, coll.CollectionID)
+}
+
+// listCollections returns every public collection.
+func (c *client) listCollections(ctx context.Context) ([]Collection, error) {
+	var colls []Collection
+	if err := c.get(ctx, "/collections", &colls); err != nil {
+		return nil, err
+	}
+	return colls, nil
+}
+
+// labels returns the distinct labels across the datasets' terms, in order of
+// first appearance.
+func labels(datasets []Dataset, terms func(Dataset) []OntologyTerm) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, ds := range datasets {
+		for _, t := range terms(ds) {
+			if t.Label != "" && !seen[t.Label] {
+				seen[t.Label] = true
+				out = append(out, t.Label)
+			}
+		}
+	}
+	return out
+}
+
+func organisms(ds Dataset) []OntologyTerm { return ds.Organism }
+func assays(ds Dataset) []OntologyTerm    { return ds.Assay }
+func tissues(ds Dataset) []OntologyTerm   { return ds.Tissue }
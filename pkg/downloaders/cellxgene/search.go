@@ -0,0 +1,111 @@
+package cellxgene
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/btraven00/hapiq/pkg/downloaders"
+)
+
+const defaultSearchLimit = 10
+
+// Search implements the Searcher interface for CELLxGENE collections.
+//
+// The Curation API has no search endpoint, so the public collection list is
+// fetched and filtered locally. Every query word must appear in the
+// collection name, description, a dataset title or an organism, assay or
+// tissue label. SearchOptions narrow the match further:
+//
+//	--organism "Homo sapiens"  →  some dataset has that organism
+//	--type "10x 3' v3"         →  some dataset used that assay
+func (d *CellxgeneDownloader) Search(ctx context.Context, query string, opts downloaders.SearchOptions) ([]downloaders.SearchResult, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	colls, err := d.c.listCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("CELLxGENE search: %w", err)
+	}
+
+	words := strings.Fields(strings.ToLower(query))
+	var results []downloaders.SearchResult
+	for _, c := range colls {
+		if !matchesQuery(c, words) {
+			continue
+		}
+		if opts.Organism != "" && !containsFold(labels(c.Datasets, organisms), opts.Organism) {
+			continue
+		}
+		if opts.EntryType != "" && !containsFold(labels(c.Datasets, assays), opts.EntryType) {
+			continue
+		}
+		results = append(results, collectionToSearchResult(c))
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// matchesQuery reports whether every word occurs somewhere in c's text.
+func matchesQuery(c Collection, words []string) bool {
+	var b strings.Builder
+	b.WriteString(c.Name)
+	b.WriteString(" ")
+	b.WriteString(c.Description)
+	for _, ds := range c.Datasets {
+		b.WriteString(" ")
+		b.WriteString(ds.Title)
+	}
+	for _, terms := range [][]string{labels(c.Datasets, organisms), labels(c.Datasets, assays), labels(c.Datasets, tissues)} {
+		b.WriteString(" ")
+		b.WriteString(strings.Join(terms, " "))
+	}
+	text := strings.ToLower(b.String())
+	for _, w := range words {
+		if !strings.Contains(text, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold reports whether any label contains want, case-insensitively.
+func containsFold(labels []string, want string) bool {
+	want = strings.ToLower(want)
+	for _, l := range labels {
+		if strings.Contains(strings.ToLower(l), want) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectionToSearchResult converts a Collection to the common SearchResult.
+func collectionToSearchResult(c Collection) downloaders.SearchResult {
+	var totalSize int64
+	for _, ds := range c.Datasets {
+		for _, a := range ds.Assets {
+			if a.downloadable() {
+				totalSize += a.FileSize
+			}
+		}
+	}
+	date := string(c.PublishedAt)
+	if len(date) > 10 && date[4] == '-' {
+		date = date[:10] // ISO timestamp → YYYY-MM-DD
+	}
+	return downloaders.SearchResult{
+		Accession:   c.CollectionID,
+		Title:       c.Name,
+		Organism:    strings.Join(labels(c.Datasets, organisms), ", "),
+		EntryType:   strings.Join(labels(c.Datasets, assays), ", "),
+		DatasetType: "collection",
+		Date:        date,
+		SampleCount: len(c.Datasets),
+		FileSize:    totalSize,
+	}
+}
@@ -0,0 +1,42 @@
+package common
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HostWithin reports whether rawURL's host is one of domains or a subdomain
+// of one. Downloaders use it to keep credentials away from third-party hosts
+// that a repository merely links to.
+func HostWithin(rawURL string, domains ...string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, d := range domains {
+		d = strings.ToLower(d)
+		if d != "" && (host == d || strings.HasSuffix(host, "."+d)) {
+			return true
+		}
+	}
+	return false
+}
+
+// HostOf returns the host name of rawURL, or "" if it does not parse.
+func HostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// SetAuthorization sets req's Authorization header to authorization when it
+// is non-empty and req targets one of domains.
+func SetAuthorization(req *http.Request, authorization string, domains ...string) {
+	if authorization != "" && HostWithin(req.URL.String(), domains...) {
+		req.Header.Set("Authorization", authorization)
+	}
+}
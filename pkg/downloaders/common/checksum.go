@@ -0,0 +1,241 @@
+package common
+
+import (
+	"context"
+	"crypto/md5"  // #nosec G501 -- upstream checksum verification, not security
+	"crypto/sha1" // #nosec G505 -- upstream checksum verification, not security
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btraven00/hapiq/pkg/cache"
+	"github.com/btraven00/hapiq/pkg/downloaders"
+)
+
+// Checksum is a per-file digest published by the upstream repository, e.g.
+// Zenodo's "md5:…" or HCA's sha256. The zero value means "none published".
+type Checksum struct {
+	// Type is md5, sha1, sha256, sha512, crc32c or sum (BSD sum(1), as used
+	// in Ensembl CHECKSUMS files).
+	Type  string
+	Value string
+}
+
+// IsZero reports whether no digest is set.
+func (c Checksum) IsZero() bool { return c.Value == "" }
+
+// String formats c as "type:value".
+func (c Checksum) String() string { return c.Type + ":" + c.Value }
+
+// ParseChecksum parses "type:value" (Zenodo's format). A bare value is taken
+// to be md5. ok is false for an empty string or an unsupported type.
+func ParseChecksum(s string) (Checksum, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Checksum{}, false
+	}
+	typ, val, found := strings.Cut(s, ":")
+	if !found {
+		typ, val = "md5", s
+	}
+	c := Checksum{Type: strings.ToLower(typ), Value: strings.TrimSpace(val)}
+	if _, err := NewHasher(c.Type); err != nil || c.Value == "" {
+		return Checksum{}, false
+	}
+	return c, true
+}
+
+// ChecksumMismatchError reports a file whose content does not match the
+// digest the repository published for it.
+type ChecksumMismatchError struct {
+	Path     string
+	Type     string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s mismatch for %s: expected %s, got %s", e.Type, filepath.Base(e.Path), e.Expected, e.Actual)
+}
+
+// NewHasher returns a hash for the checksum type typ.
+func NewHasher(typ string) (hash.Hash, error) {
+	switch strings.ToLower(typ) {
+	case "md5":
+		return md5.New(), nil // #nosec G401 -- upstream checksum verification
+	case "sha1":
+		return sha1.New(), nil // #nosec G401 -- upstream checksum verification
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	case "crc32c":
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli)), nil
+	case "sum":
+		return &bsdSum{}, nil
+	}
+	return nil, fmt.Errorf("unsupported checksum type %q", typ)
+}
+
+// FileChecksum hashes path with the checksum type typ and returns the digest
+// in the form the upstream repositories publish it.
+func FileChecksum(path, typ string) (string, error) {
+	h, err := NewHasher(typ)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(filepath.Clean(path)) // #nosec G304 -- caller-controlled file
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return formatDigest(typ, h), nil
+}
+
+// formatDigest renders h's sum: lowercase hex, except for BSD sum, which is
+// "<checksum> <1K blocks>".
+func formatDigest(typ string, h hash.Hash) string {
+	if s, ok := h.(*bsdSum); ok {
+		return s.String()
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ChecksumsEqual compares two digests of type typ, ignoring case and, for
+// BSD sum, zero padding and spacing.
+func ChecksumsEqual(typ, a, b string) bool {
+	if strings.EqualFold(typ, "sum") {
+		fa, fb := strings.Fields(a), strings.Fields(b)
+		if len(fa) != len(fb) || len(fa) == 0 {
+			return false
+		}
+		for i := range fa {
+			na, errA := strconv.ParseUint(fa[i], 10, 64)
+			nb, errB := strconv.ParseUint(fb[i], 10, 64)
+			if errA != nil || errB != nil || na != nb {
+				return false
+			}
+		}
+		return true
+	}
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// verifyFetched checks the file Fetch just produced against want and records
+// the outcome in res.Verification. sha256 is compared against the hash Fetch
+// already computed; other types use res.digest when the stream fed it, or
+// re-read destPath. On mismatch destPath is removed and the blob is evicted
+// from the cache so the next attempt goes back to the origin.
+func verifyFetched(ctx context.Context, destPath string, res *FetchResult, want Checksum) error {
+	typ := strings.ToLower(want.Type)
+	var actual string
+	switch {
+	case typ == "sha256":
+		actual = res.SHA256
+	case res.digest != "":
+		actual = res.digest
+	default:
+		var err error
+		if actual, err = FileChecksum(destPath, typ); err != nil {
+			return fmt.Errorf("%s %s: %w", typ, filepath.Base(destPath), err)
+		}
+	}
+
+	var err error
+	if res.Verification, err = newVerification(destPath, want, actual); err == nil {
+		return nil
+	}
+	_ = os.Remove(destPath)
+	if c := cache.FromContext(ctx); c != nil {
+		_ = c.Evict(ctx, res.SHA256)
+	}
+	return err
+}
+
+// VerifyFile hashes path and compares it with want, for downloads that do not
+// go through Fetch (FTP, progress-tracked copies). The returned Verification
+// is always set when the file could be read; a mismatch is reported as a
+// *ChecksumMismatchError and the file is left in place.
+func VerifyFile(path string, want Checksum) (*downloaders.Verification, error) {
+	actual, err := FileChecksum(path, want.Type)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", want.Type, filepath.Base(path), err)
+	}
+	return newVerification(path, want, actual)
+}
+
+// newVerification records the comparison of actual against want.
+func newVerification(path string, want Checksum, actual string) (*downloaders.Verification, error) {
+	typ := strings.ToLower(want.Type)
+	v := &downloaders.Verification{
+		VerifyTime: time.Now(),
+		Method:     typ,
+		Expected:   want.Value,
+		Actual:     actual,
+		Verified:   ChecksumsEqual(typ, want.Value, actual),
+	}
+	if v.Verified {
+		return v, nil
+	}
+	err := &ChecksumMismatchError{Path: path, Type: typ, Expected: want.Value, Actual: actual}
+	v.Errors = []string{err.Error()}
+	return v, err
+}
+
+// streamHasher returns the extra hash Fetch feeds while streaming so that
+// non-sha256 digests need no second read, or nil when none is needed.
+func streamHasher(want Checksum) hash.Hash {
+	if want.IsZero() || strings.EqualFold(want.Type, "sha256") {
+		return nil
+	}
+	h, err := NewHasher(want.Type)
+	if err != nil {
+		return nil
+	}
+	return h
+}
+
+// bsdSum implements the BSD sum(1) algorithm: a 16-bit rotating checksum
+// reported together with the size in 1 KiB blocks.
+type bsdSum struct {
+	sum uint16
+	n   int64
+}
+
+func (s *bsdSum) Write(p []byte) (int, error) {
+	for _, b := range p {
+		s.sum = (s.sum >> 1) | (s.sum << 15)
+		s.sum += uint16(b)
+	}
+	s.n += int64(len(p))
+	return len(p), nil
+}
+
+func (s *bsdSum) Sum(b []byte) []byte {
+	var buf [10]byte
+	binary.BigEndian.PutUint16(buf[:2], s.sum)
+	binary.BigEndian.PutUint64(buf[2:], uint64(s.blocks())) // #nosec G115 -- block count is non-negative
+	return append(b, buf[:]...)
+}
+
+func (s *bsdSum) Reset()         { *s = bsdSum{} }
+func (s *bsdSum) Size() int      { return 10 }
+func (s *bsdSum) BlockSize() int { return 1024 }
+
+func (s *bsdSum) blocks() int64 { return (s.n + 1023) / 1024 }
+
+// String formats the sum like sum(1): "<checksum> <blocks>".
+func (s *bsdSum) String() string { return fmt.Sprintf("%05d %d", s.sum, s.blocks()) }
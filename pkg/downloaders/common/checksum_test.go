@@ -0,0 +1,213 @@
+package common
+
+import (
+	"context"
+	"crypto/md5" // #nosec G501 -- test fixture md5
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/btraven00/hapiq/pkg/cache"
+	"github.com/btraven00/hapiq/pkg/downloaders"
+)
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s)) // #nosec G401 -- test fixture md5
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseChecksum(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Checksum
+		ok   bool
+	}{
+		{"md5:ABC123", Checksum{Type: "md5", Value: "ABC123"}, true},
+		{"SHA256:ff", Checksum{Type: "sha256", Value: "ff"}, true},
+		{"d41d8cd98f00b204e9800998ecf8427e", Checksum{Type: "md5", Value: "d41d8cd98f00b204e9800998ecf8427e"}, true},
+		{"", Checksum{}, false},
+		{"adler32:1234", Checksum{}, false},
+		{"md5:", Checksum{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseChecksum(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseChecksum(%q) = %+v, %v; want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFileChecksum_KnownValues(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	tests := []struct {
+		path, typ, want string
+	}{
+		// Values from coreutils `sum -r` and the CRC-32C check value.
+		{write("hello.txt", "hello world\n"), "sum", "03762 1"},
+		{write("check.txt", "123456789"), "crc32c", "e3069283"},
+		{write("md5.txt", "abc"), "md5", md5Hex("abc")},
+	}
+	for _, tt := range tests {
+		got, err := FileChecksum(tt.path, tt.typ)
+		if err != nil {
+			t.Fatalf("FileChecksum(%s): %v", tt.typ, err)
+		}
+		if !ChecksumsEqual(tt.typ, tt.want, got) {
+			t.Errorf("FileChecksum(%s) = %q, want %q", tt.typ, got, tt.want)
+		}
+	}
+
+	if !ChecksumsEqual("sum", "3762     1", "03762 1") {
+		t.Error("BSD sum comparison should ignore padding")
+	}
+	if ChecksumsEqual("sum", "03762 1", "03762 2") {
+		t.Error("BSD sum comparison should include the block count")
+	}
+}
+
+func TestFetch_ExpectedChecksum(t *testing.T) {
+	const body = "published content"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "ok.txt")
+	fr, err := Fetch(context.Background(), srv.URL+"/ok.txt", dest, FetchOptions{
+		Expected: Checksum{Type: "md5", Value: strings.ToUpper(md5Hex(body))},
+	})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if v := fr.Verification; v == nil || !v.Verified || v.Method != "md5" || v.Actual != md5Hex(body) {
+		t.Errorf("Verification = %+v", fr.Verification)
+	}
+
+	dest = filepath.Join(t.TempDir(), "bad.txt")
+	_, err = Fetch(context.Background(), srv.URL+"/bad.txt", dest, FetchOptions{
+		Expected: Checksum{Type: "md5", Value: md5Hex("something else")},
+	})
+	var mismatch *ChecksumMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Actual != md5Hex(body) {
+		t.Fatalf("err = %v, want ChecksumMismatchError", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("mismatched file left on disk")
+	}
+}
+
+func TestFetch_BadCachedBlobIsRefetched(t *testing.T) {
+	var originGets int32
+	var served atomic.Value
+	served.Store("stale copy")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&originGets, 1)
+		_, _ = io.WriteString(w, served.Load().(string))
+	}))
+	defer srv.Close()
+	rawURL := srv.URL + "/data.bin"
+
+	c, err := cache.Open(cache.Config{Dir: t.TempDir(), LinkStrategy: cache.StrategyCopy})
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	defer c.Close()
+	ctx := cache.WithCache(context.Background(), c)
+
+	if _, err := Fetch(ctx, rawURL, filepath.Join(t.TempDir(), "seed"), FetchOptions{}); err != nil {
+		t.Fatalf("seed Fetch: %v", err)
+	}
+
+	served.Store("current copy")
+	dest := filepath.Join(t.TempDir(), "data.bin")
+	fr, err := Fetch(ctx, rawURL, dest, FetchOptions{Expected: Checksum{Type: "md5", Value: md5Hex("current copy")}})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if fr.Hit || fr.Verification == nil || !fr.Verification.Verified {
+		t.Errorf("result = %+v, want a verified origin fetch", fr)
+	}
+	if got, _ := os.ReadFile(dest); string(got) != "current copy" {
+		t.Errorf("content = %q", got)
+	}
+	if n := atomic.LoadInt32(&originGets); n != 2 {
+		t.Errorf("origin GETs = %d, want 2", n)
+	}
+}
+
+func TestSummarizeVerification(t *testing.T) {
+	files := []downloaders.FileWitness{
+		{Verification: &downloaders.Verification{Method: "md5", Verified: true}},
+		{Verification: &downloaders.Verification{Method: "sha256", Verified: false, Errors: []string{"sha256 mismatch for b"}}},
+		{},
+	}
+	v := summarizeVerification(files)
+	if v == nil || v.Verified || v.Method != "upstream md5,sha256" || len(v.Errors) != 1 {
+		t.Errorf("summary = %+v", v)
+	}
+	if v := summarizeVerification([]downloaders.FileWitness{{}}); v != nil {
+		t.Errorf("summary without verified files = %+v, want nil", v)
+	}
+}
+
+func TestFetch_DigestHitSkipsNewURL(t *testing.T) {
+	const body = "same reads, two archives"
+	var enaGets, ncbiGets int32
+	ena := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&enaGets, 1)
+		_, _ = io.WriteString(w, body)
+	}))
+	defer ena.Close()
+	ncbi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&ncbiGets, 1)
+		_, _ = io.WriteString(w, body)
+	}))
+	defer ncbi.Close()
+
+	c, err := cache.Open(cache.Config{Dir: t.TempDir(), LinkStrategy: cache.StrategyCopy})
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	defer c.Close()
+	ctx := cache.WithCache(context.Background(), c)
+	want := Checksum{Type: "md5", Value: md5Hex(body)}
+
+	if _, err := Fetch(ctx, ena.URL+"/run_1.fastq.gz", filepath.Join(t.TempDir(), "a"), FetchOptions{Expected: want}); err != nil {
+		t.Fatalf("first Fetch: %v", err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "run_1.fastq.gz")
+	fr, err := Fetch(ctx, ncbi.URL+"/SRR1/run_1.fastq.gz", dest, FetchOptions{Expected: want})
+	if err != nil {
+		t.Fatalf("second Fetch: %v", err)
+	}
+	if !fr.Hit || fr.Verification == nil || !fr.Verification.Verified {
+		t.Errorf("result = %+v, want a verified cache hit", fr)
+	}
+	if got, _ := os.ReadFile(dest); string(got) != body {
+		t.Errorf("content = %q", got)
+	}
+	if n := atomic.LoadInt32(&ncbiGets); n != 0 {
+		t.Errorf("second mirror GETs = %d, want 0", n)
+	}
+
+	// The new URL is now indexed directly.
+	if _, _, hit, _ := c.Get(context.Background(), ncbi.URL+"/SRR1/run_1.fastq.gz"); !hit {
+		t.Error("URL of the digest hit was not indexed")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"github.com/btraven00/hapiq/pkg/cache"
+	"github.com/btraven00/hapiq/pkg/downloaders"
 )
 
 // Tunables for polling a server that answers 202 Accepted, meaning the resource
@@ -31,8 +33,25 @@ var (
 type FetchOptions struct {
 	// Client is the HTTP client to use on cache miss. Defaults to http.DefaultClient.
 	Client *http.Client
-	// ExtraHeaders are added to the outbound request on cache miss.
+	// ExtraHeaders are added to the outbound request on cache miss. A request
+	// with an Authorization header, or a token in its URL, bypasses the cache
+	// (see fetchCache).
 	ExtraHeaders map[string]string
+	// Retry re-attempts transient failures (5xx, 429, resets, timeouts).
+	// Downloaders pass RetryPolicyFor(source); the zero value tries once.
+	Retry RetryPolicy
+	// Resume keeps partial downloads across runs (in the cache tmp/ dir, or
+	// as <dest>.part without a cache) and continues them with an HTTP Range
+	// request when the server's ETag/Last-Modified still match.
+	Resume bool
+	// Expected is the digest the repository publishes for the file. When set,
+	// Fetch verifies the result and fails with *ChecksumMismatchError on a
+	// mismatch (after one fresh origin fetch if the bad copy came from a cache).
+	Expected Checksum
+	// Freshness decides when a cached copy of the URL must be revalidated
+	// with the origin. Downloaders pass cache.FreshnessFor(source); the zero
+	// value trusts the cache forever.
+	Freshness cache.Freshness
 }
 
 // FetchResult is returned by Fetch.
@@ -49,147 +68,371 @@ type FetchResult struct {
 	Filename string
 	// N is the number of bytes in the file.
 	N int64
-	// Hit is true when the file was served from the local cache.
+	// Hit is true when the file was served from the local cache or pulled
+	// from a peer cache (cache.server.peers) rather than the origin.
 	Hit bool
+	// Resumed is true when an earlier partial download was continued.
+	Resumed bool
+	// Verification records the check against FetchOptions.Expected; nil when
+	// no digest was expected.
+	Verification *downloaders.Verification
+
+	// digest is the FetchOptions.Expected-type digest computed while
+	// streaming, if any.
+	digest string
 }
 
 // Fetch downloads rawURL to destPath, consulting the local cache when one is
 // attached to ctx. On a cache hit the blob is materialized without a network
-// round-trip. On a miss the response is streamed to a tmp file while computing
+// round-trip, unless opts.Freshness calls for revalidation: then the origin
+// is asked with If-None-Match / If-Modified-Since and a 304 serves the cached
+// blob. On a miss the response is streamed to a tmp file while computing
 // sha256 in parallel; if a cache is present the blob is promoted before
-// materializing to destPath.
+// materializing to destPath. Between the two, configured peer caches are asked
+// for the URL; a peer blob is hash-verified before it is admitted.
+// Transient failures are retried according to opts.Retry; with opts.Resume a
+// retry continues from the bytes already received. With opts.Expected the
+// result is checked against the upstream digest, which also finds a blob
+// cached from a different URL.
 func Fetch(ctx context.Context, rawURL, destPath string, opts FetchOptions) (FetchResult, error) {
+	res, err := fetchWithRetry(ctx, rawURL, destPath, opts)
+	if err != nil || opts.Expected.IsZero() {
+		return res, err
+	}
+	if err = verifyFetched(ctx, destPath, &res, opts.Expected); err != nil && res.Hit {
+		// The cached or peer copy was bad and has been evicted: try the origin.
+		if res, err = fetchWithRetry(ctx, rawURL, destPath, opts); err != nil {
+			return res, err
+		}
+		err = verifyFetched(ctx, destPath, &res, opts.Expected)
+	}
+	if c := fetchCache(ctx, rawURL, opts); c != nil && err == nil {
+		// Remember the verified upstream digest so the same file behind
+		// another URL (a mirror, another repository) is a cache hit.
+		_ = c.AddDigest(ctx, res.SHA256, opts.Expected.Type, opts.Expected.Value)
+	}
+	return res, err
+}
+
+// fetchWithRetry runs fetchOnce under opts.Retry.
+func fetchWithRetry(ctx context.Context, rawURL, destPath string, opts FetchOptions) (FetchResult, error) {
+	var res FetchResult
+	err := Retry(ctx, opts.Retry, rawURL, func() error {
+		var err error
+		res, err = fetchOnce(ctx, rawURL, destPath, opts)
+		return err
+	})
+	return res, err
+}
+
+// fetchOnce is a single attempt of Fetch.
+func fetchOnce(ctx context.Context, rawURL, destPath string, opts FetchOptions) (FetchResult, error) {
 	client := opts.Client
 	if client == nil {
 		client = http.DefaultClient
 	}
 
-	c := cache.FromContext(ctx)
+	c := fetchCache(ctx, rawURL, opts)
+	headers := opts.ExtraHeaders
+	// stale is a cached copy of rawURL that has to be revalidated.
+	var stale *cache.Entry
 
 	// ── cache hit path ────────────────────────────────────────────────────────
 	if c != nil {
-		hash, size, hit, err := c.Get(ctx, rawURL)
+		res, hit, entry, err := fetchFromCache(ctx, c, rawURL, destPath, opts)
+		if err != nil || hit {
+			return res, err
+		}
+
+		// Claim the URL so other processes sharing the cache wait for this
+		// fetch instead of duplicating it. If we had to wait, the previous
+		// holder has most likely cached it by now.
+		lease, waited, err := c.AcquireLease(ctx, rawURL)
 		if err != nil {
-			return FetchResult{}, fmt.Errorf("cache get: %w", err)
+			return FetchResult{}, err
 		}
-		if hit {
-			if err := c.Materialize(hash, destPath); err != nil {
-				return FetchResult{}, fmt.Errorf("materialize: %w", err)
+		defer lease.Release()
+		if waited {
+			if res, hit, entry, err = fetchFromCache(ctx, c, rawURL, destPath, opts); err != nil || hit {
+				return res, err
+			}
+		}
+		if entry != nil {
+			stale = entry
+			headers = conditionalHeaders(opts.ExtraHeaders, entry.Validators)
+		}
+
+		// ── peer hit path ─────────────────────────────────────────────────────
+		// A stale entry is revalidated with the origin; a peer would only
+		// hand back the same copy.
+		if stale == nil {
+			if ph, ok := c.FetchFromPeers(ctx, client, rawURL); ok {
+				if err := c.Materialize(ph.SHA256, destPath); err != nil {
+					return FetchResult{}, fmt.Errorf("materialize: %w", err)
+				}
+				return FetchResult{
+					SHA256:   ph.SHA256,
+					N:        ph.Size,
+					Filename: ph.Filename,
+					Hit:      true,
+				}, nil
 			}
-			// No HTTP response on a hit; recover the filename recorded at store time
-			// so callers can still name the file by its Content-Disposition.
-			cachedName, _ := c.Filename(ctx, rawURL)
-			return FetchResult{
-				SHA256:   hash,
-				N:        size,
-				Filename: cachedName,
-				Hit:      true,
-			}, nil
 		}
 	}
 
 	// ── cache miss / no-cache path ────────────────────────────────────────────
-	var tmpPath string
-	var sha256hex string
-	var contentType string
-	var filename string
-	var n int64
+	var f fetched
+	// extra computes a non-sha256 expected digest alongside the download.
+	extra := streamHasher(opts.Expected)
+	withExtra := func(w io.Writer) io.Writer {
+		if extra == nil {
+			return w
+		}
+		return io.MultiWriter(w, extra)
+	}
 
 	if c != nil {
-		// Stream to a tmp file inside the cache dir so promotion is an atomic rename.
-		tmpFile, err := c.NewTmpFile()
-		if err != nil {
-			return FetchResult{}, fmt.Errorf("create tmp: %w", err)
-		}
-		tmpPath = tmpFile.Name()
+		var tmpPath string
+		var err error
+		if opts.Resume {
+			// A stable per-URL path lets a later run pick up where this one stopped.
+			tmpPath = c.PartialPath(rawURL)
+			f, err = streamResumable(ctx, client, rawURL, headers, tmpPath)
+		} else {
+			// Stream to a tmp file inside the cache dir so promotion is an atomic rename.
+			tmpFile, tmpErr := c.NewTmpFile()
+			if tmpErr != nil {
+				return FetchResult{}, fmt.Errorf("create tmp: %w", tmpErr)
+			}
+			tmpPath = tmpFile.Name()
 
-		n, sha256hex, contentType, filename, err = streamToFile(ctx, client, rawURL, opts.ExtraHeaders, tmpFile)
-		if closeErr := tmpFile.Close(); closeErr != nil && err == nil {
-			err = closeErr
+			f, err = streamToFile(ctx, client, rawURL, headers, withExtra(tmpFile))
+			if closeErr := tmpFile.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				_ = os.Remove(tmpPath)
+			}
 		}
 		if err != nil {
-			_ = os.Remove(tmpPath)
+			if stale != nil && isNotModified(err) {
+				return serveRevalidated(ctx, c, rawURL, destPath, *stale)
+			}
 			return FetchResult{}, err
 		}
 
-		if err := c.Put(ctx, rawURL, tmpPath, sha256hex); err != nil {
-			// Quota or disk error: fall through to direct write without cache.
-			_, _ = fmt.Fprintf(os.Stderr, "cache: warning: skipping cache: %v\n", err)
-			// tmpPath was either removed by Put or still exists; clean up.
-			_ = os.Remove(tmpPath)
-			return directFetch(ctx, client, rawURL, destPath, opts.ExtraHeaders)
+		if !opts.Resume && extra != nil {
+			f.digest = formatDigest(opts.Expected.Type, extra)
 		}
 
-		// Persist the resolved filename so a later cache hit can reproduce it.
-		_ = c.RecordFilename(ctx, rawURL, filename)
+		// A changed body becomes a new blob and rawURL is re-pointed at it.
+		if err := c.PutWithValidators(ctx, rawURL, tmpPath, f.sha256hex, f.validators); err != nil {
+			// Quota or disk error: keep the completed download without caching it.
+			_, _ = fmt.Fprintf(os.Stderr, "cache: warning: skipping cache: %v\n", err)
+			if err := moveFile(tmpPath, destPath); err != nil {
+				_ = os.Remove(tmpPath)
+				return FetchResult{}, fmt.Errorf("finalize %s: %w", destPath, err)
+			}
+		} else {
+			// Persist the resolved filename so a later cache hit can reproduce it.
+			_ = c.RecordFilename(ctx, rawURL, f.filename)
 
-		if err := c.Materialize(sha256hex, destPath); err != nil {
-			return FetchResult{}, fmt.Errorf("materialize: %w", err)
+			if err := c.Materialize(f.sha256hex, destPath); err != nil {
+				return FetchResult{}, fmt.Errorf("materialize: %w", err)
+			}
+		}
+	} else if opts.Resume {
+		// No cache: keep the partial next to the target and rename on success.
+		partPath := destPath + ".part"
+		var err error
+		if f, err = streamResumable(ctx, client, rawURL, opts.ExtraHeaders, partPath); err != nil {
+			return FetchResult{}, err
+		}
+		if err := os.Rename(partPath, destPath); err != nil {
+			return FetchResult{}, fmt.Errorf("finalize %s: %w", destPath, err)
 		}
 	} else {
 		// No cache: stream directly to destPath.
-		f, err := os.Create(filepath.Clean(destPath)) // #nosec G304 -- caller-controlled destination
+		out, err := os.Create(filepath.Clean(destPath)) // #nosec G304 -- caller-controlled destination
 		if err != nil {
 			return FetchResult{}, err
 		}
-		n, sha256hex, contentType, filename, err = streamToFile(ctx, client, rawURL, opts.ExtraHeaders, f)
-		_ = f.Close()
+		f, err = streamToFile(ctx, client, rawURL, opts.ExtraHeaders, withExtra(out))
+		_ = out.Close()
 		if err != nil {
 			_ = os.Remove(destPath)
 			return FetchResult{}, err
 		}
+		if extra != nil {
+			f.digest = formatDigest(opts.Expected.Type, extra)
+		}
 	}
 
 	return FetchResult{
-		ContentType: contentType,
-		SHA256:      sha256hex,
-		Filename:    filename,
-		N:           n,
+		ContentType: f.contentType,
+		SHA256:      f.sha256hex,
+		Filename:    f.filename,
+		N:           f.n,
 		Hit:         false,
+		Resumed:     f.resumed,
+		digest:      f.digest,
 	}, nil
 }
 
-// directFetch streams rawURL directly to destPath without cache involvement.
-func directFetch(ctx context.Context, client *http.Client, rawURL, destPath string, extra map[string]string) (FetchResult, error) {
-	f, err := os.Create(filepath.Clean(destPath)) // #nosec G304 -- caller-controlled destination
+// fetchCache returns the cache attached to ctx, or nil when the request
+// carries credentials, in an Authorization header or in rawURL itself. A body
+// fetched with a token may belong to a private or embargoed record, and the
+// cache hands its blobs to peers and shared tiers without asking for one.
+func fetchCache(ctx context.Context, rawURL string, opts FetchOptions) *cache.Cache {
+	if cache.HasCredentials(rawURL) {
+		return nil
+	}
+	for k := range opts.ExtraHeaders {
+		if strings.EqualFold(k, "Authorization") {
+			return nil
+		}
+	}
+	return cache.FromContext(ctx)
+}
+
+// fetchFromCache materializes rawURL from the local cache if it is indexed
+// and fresh under opts.Freshness, or if a blob matches the upstream digest
+// opts.Expected (which also indexes rawURL). A cached copy that is due for
+// revalidation is returned as stale instead.
+func fetchFromCache(ctx context.Context, c *cache.Cache, rawURL, destPath string, opts FetchOptions) (res FetchResult, hit bool, stale *cache.Entry, err error) {
+	e, hit, err := c.Lookup(ctx, rawURL)
 	if err != nil {
-		return FetchResult{}, err
+		return FetchResult{}, false, nil, fmt.Errorf("cache get: %w", err)
+	}
+	if hit && !opts.Freshness.Fresh(e.FetchedAt, time.Now()) {
+		stale, hit = &e, false
+	}
+	hash, size := e.SHA256, e.Size
+	if !hit && !opts.Expected.IsZero() {
+		if hash, size, hit, err = c.GetByDigest(ctx, opts.Expected.Type, opts.Expected.Value); err != nil {
+			return FetchResult{}, false, nil, fmt.Errorf("cache get: %w", err)
+		}
+		switch {
+		case hit && stale != nil && stale.SHA256 == hash:
+			// The repository's digest vouches for the stale copy.
+			_ = c.MarkRevalidated(ctx, rawURL)
+		case hit:
+			_ = c.IndexURL(ctx, rawURL, hash)
+		}
+	}
+	if !hit {
+		return FetchResult{}, false, stale, nil
+	}
+	if err := c.Materialize(hash, destPath); err != nil {
+		return FetchResult{}, false, nil, fmt.Errorf("materialize: %w", err)
+	}
+	// No HTTP response on a hit; recover the filename recorded at store time
+	// so callers can still name the file by its Content-Disposition.
+	cachedName, _ := c.Filename(ctx, rawURL)
+	return FetchResult{
+		SHA256:   hash,
+		N:        size,
+		Filename: cachedName,
+		Hit:      true,
+	}, true, nil, nil
+}
+
+// conditionalHeaders returns extra plus If-None-Match / If-Modified-Since for
+// the validators v.
+func conditionalHeaders(extra map[string]string, v cache.Validators) map[string]string {
+	headers := make(map[string]string, len(extra)+2)
+	for k, val := range extra {
+		headers[k] = val
+	}
+	if v.ETag != "" {
+		headers["If-None-Match"] = v.ETag
 	}
-	n, sha256hex, ct, filename, err := streamToFile(ctx, client, rawURL, extra, f)
-	_ = f.Close()
+	if v.LastModified != "" {
+		headers["If-Modified-Since"] = v.LastModified
+	}
+	return headers
+}
+
+// isNotModified reports whether err is the origin answering 304 to a
+// conditional request.
+func isNotModified(err error) bool {
+	var he *HTTPError
+	return errors.As(err, &he) && he.StatusCode == http.StatusNotModified
+}
+
+// serveRevalidated materializes a cached copy the origin has just confirmed
+// unchanged, recording the revalidation.
+func serveRevalidated(ctx context.Context, c *cache.Cache, rawURL, destPath string, e cache.Entry) (FetchResult, error) {
+	_ = c.MarkRevalidated(ctx, rawURL)
+	if err := c.Materialize(e.SHA256, destPath); err != nil {
+		return FetchResult{}, fmt.Errorf("materialize: %w", err)
+	}
+	cachedName, _ := c.Filename(ctx, rawURL)
+	return FetchResult{
+		SHA256:   e.SHA256,
+		N:        e.Size,
+		Filename: cachedName,
+		Hit:      true,
+	}, nil
+}
+
+// moveFile renames src to dst, copying across filesystems (the cache tmp/
+// dir and the output directory need not share one).
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(filepath.Clean(src)) // #nosec G304 -- cache tmp file
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(filepath.Clean(dst)) // #nosec G304 -- caller-controlled destination
 	if err != nil {
-		_ = os.Remove(destPath)
-		return FetchResult{}, err
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return err
 	}
-	return FetchResult{ContentType: ct, SHA256: sha256hex, Filename: filename, N: n}, nil
+	if err := out.Close(); err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
 }
 
 // streamToFile makes a GET request and copies the body into w, computing sha256
-// as it goes. Returns (bytes written, sha256hex, Content-Type, filename, error),
-// where filename is parsed from the GET response's Content-Disposition header
-// (empty when the server provides none). The GET response is authoritative: it
-// reflects the final hop after any redirects, which a pre-fetch HEAD often does
-// not (e.g. storage backends that only set Content-Disposition on the redirect
-// target).
-func streamToFile(ctx context.Context, client *http.Client, rawURL string, extra map[string]string, w io.Writer) (int64, string, string, string, error) {
+// as it goes. The filename is parsed from the GET response's
+// Content-Disposition header (empty when the server provides none). The GET
+// response is authoritative: it reflects the final hop after any redirects,
+// which a pre-fetch HEAD often does not (e.g. storage backends that only set
+// Content-Disposition on the redirect target).
+func streamToFile(ctx context.Context, client *http.Client, rawURL string, extra map[string]string, w io.Writer) (fetched, error) {
 	resp, err := getWaitingForReady(ctx, client, rawURL, extra)
 	if err != nil {
-		return 0, "", "", "", err
+		return fetched{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, "", "", "", fmt.Errorf("HTTP %d for %s", resp.StatusCode, rawURL)
+		return fetched{}, NewHTTPError(resp, rawURL)
 	}
 
 	h := sha256.New()
 	n, err := io.Copy(io.MultiWriter(w, h), resp.Body)
 	if err != nil {
-		return 0, "", "", "", fmt.Errorf("read body: %w", err)
+		return fetched{}, fmt.Errorf("read body: %w", err)
 	}
 
-	filename := FilenameFromContentDisposition(resp.Header.Get("Content-Disposition"))
-	return n, hex.EncodeToString(h.Sum(nil)), resp.Header.Get("Content-Type"), filename, nil
+	return fetched{
+		n:           n,
+		sha256hex:   hex.EncodeToString(h.Sum(nil)),
+		contentType: resp.Header.Get("Content-Type"),
+		filename:    FilenameFromContentDisposition(resp.Header.Get("Content-Disposition")),
+		validators:  validatorsOf(resp),
+	}, nil
 }
 
 // getWaitingForReady issues a GET for rawURL, transparently polling while the
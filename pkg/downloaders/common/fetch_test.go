@@ -13,6 +13,8 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/btraven00/hapiq/pkg/cache"
 )
 
 // withFastBackoff shrinks the 202 polling delays for the duration of a test so
@@ -173,3 +175,286 @@ func TestGetWaitingForReady_NonAcceptedReturnedImmediately(t *testing.T) {
 		t.Errorf("server calls = %d, want 1 (no polling on non-202)", got)
 	}
 }
+
+// TestFetch_PeerHitSkipsOrigin verifies that on a local miss Fetch pulls the
+// blob from a configured peer cache and never contacts the origin.
+func TestFetch_PeerHitSkipsOrigin(t *testing.T) {
+	const body = "shared across the lab"
+
+	var originGets int32
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&originGets, 1)
+		_, _ = io.WriteString(w, body)
+	}))
+	defer origin.Close()
+	rawURL := origin.URL + "/file.txt"
+
+	// Seed the peer by fetching through it once.
+	peerCache, err := cache.Open(cache.Config{Dir: t.TempDir(), LinkStrategy: cache.StrategyCopy})
+	if err != nil {
+		t.Fatalf("open peer cache: %v", err)
+	}
+	defer peerCache.Close()
+	peerCtx := cache.WithCache(context.Background(), peerCache)
+	if _, err := Fetch(peerCtx, rawURL, filepath.Join(t.TempDir(), "seed"), FetchOptions{}); err != nil {
+		t.Fatalf("seed Fetch: %v", err)
+	}
+	peer := httptest.NewServer(cache.NewServer(peerCache, "").Handler())
+	defer peer.Close()
+
+	local, err := cache.Open(cache.Config{
+		Dir:          t.TempDir(),
+		LinkStrategy: cache.StrategyCopy,
+		Server:       cache.ServerConfig{Peers: []string{peer.URL}},
+	})
+	if err != nil {
+		t.Fatalf("open local cache: %v", err)
+	}
+	defer local.Close()
+
+	dest := filepath.Join(t.TempDir(), "out.txt")
+	fr, err := Fetch(cache.WithCache(context.Background(), local), rawURL, dest, FetchOptions{})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if !fr.Hit {
+		t.Error("Hit = false, want true for a peer-served blob")
+	}
+	if got, _ := os.ReadFile(dest); string(got) != body {
+		t.Errorf("content = %q, want %q", got, body)
+	}
+	if n := atomic.LoadInt32(&originGets); n != 1 {
+		t.Errorf("origin GETs = %d, want 1 (seed only)", n)
+	}
+}
+
+// TestFetch_ConcurrentProcessesShareOneOriginFetch simulates two hapiq
+// processes (separate Cache handles on one directory) fetching the same URL:
+// the second waits on the first's lease and is served from the cache.
+func TestFetch_ConcurrentProcessesShareOneOriginFetch(t *testing.T) {
+	const body = "large shared reference"
+
+	var originGets int32
+	release := make(chan struct{})
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&originGets, 1)
+		<-release
+		_, _ = io.WriteString(w, body)
+	}))
+	defer origin.Close()
+	rawURL := origin.URL + "/ref.fa"
+
+	dir := t.TempDir()
+	results := make(chan FetchResult, 2)
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		c, err := cache.Open(cache.Config{Dir: dir, LinkStrategy: cache.StrategyCopy})
+		if err != nil {
+			t.Fatalf("open cache: %v", err)
+		}
+		defer c.Close()
+		dest := filepath.Join(t.TempDir(), "ref.fa")
+		go func() {
+			fr, err := Fetch(cache.WithCache(context.Background(), c), rawURL, dest, FetchOptions{})
+			results <- fr
+			errs <- err
+		}()
+	}
+
+	// Let both reach the origin or the lease before the origin answers.
+	time.Sleep(200 * time.Millisecond)
+	close(release)
+
+	hits := 0
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("Fetch: %v", err)
+		}
+		if (<-results).Hit {
+			hits++
+		}
+	}
+	if n := atomic.LoadInt32(&originGets); n != 1 {
+		t.Errorf("origin GETs = %d, want 1", n)
+	}
+	if hits != 1 {
+		t.Errorf("cache hits = %d, want 1 (the waiting process)", hits)
+	}
+}
+
+// TestFetch_RevalidatesStaleEntry checks the revalidate freshness mode: an
+// unchanged file costs a 304 and is served from the cache, a changed one is
+// stored as a new blob and the URL re-pointed at it.
+func TestFetch_RevalidatesStaleEntry(t *testing.T) {
+	var full, notModified int32
+	var current atomic.Value
+	current.Store("v1")
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"` + current.Load().(string) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(&full, 1)
+		w.Header().Set("ETag", etag)
+		_, _ = io.WriteString(w, "body "+current.Load().(string))
+	}))
+	defer origin.Close()
+	rawURL := origin.URL + "/catalog.csv"
+
+	c, err := cache.Open(cache.Config{Dir: t.TempDir(), LinkStrategy: cache.StrategyCopy})
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	defer c.Close()
+	ctx := cache.WithCache(context.Background(), c)
+	opts := FetchOptions{Freshness: cache.Freshness{Mode: cache.FreshRevalidate}}
+
+	fetch := func(want string) FetchResult {
+		t.Helper()
+		dest := filepath.Join(t.TempDir(), "catalog.csv")
+		fr, err := Fetch(ctx, rawURL, dest, opts)
+		if err != nil {
+			t.Fatalf("Fetch: %v", err)
+		}
+		if got, _ := os.ReadFile(dest); string(got) != want {
+			t.Errorf("content = %q, want %q", got, want)
+		}
+		return fr
+	}
+
+	first := fetch("body v1")
+	e, _, _ := c.Lookup(context.Background(), rawURL)
+	if e.ETag != `"v1"` {
+		t.Errorf("recorded ETag = %q, want \"v1\"", e.ETag)
+	}
+
+	if fr := fetch("body v1"); !fr.Hit || fr.SHA256 != first.SHA256 {
+		t.Errorf("304 result = %+v, want a hit on the original blob", fr)
+	}
+	if full, nm := atomic.LoadInt32(&full), atomic.LoadInt32(&notModified); full != 1 || nm != 1 {
+		t.Errorf("origin: %d full, %d not-modified; want 1 and 1", full, nm)
+	}
+
+	current.Store("v2")
+	if fr := fetch("body v2"); fr.Hit || fr.SHA256 == first.SHA256 {
+		t.Errorf("changed result = %+v, want a new origin blob", fr)
+	}
+	if e, hit, _ := c.Lookup(context.Background(), rawURL); !hit || e.ETag != `"v2"` {
+		t.Errorf("URL not re-pointed: %+v", e)
+	}
+}
+
+// TestFetch_QuotaKeepsDownload checks that a download the cache refuses is
+// still delivered from the bytes already received, without a second GET.
+func TestFetch_QuotaKeepsDownload(t *testing.T) {
+	const body = "too big for a one-byte cache"
+
+	var originGets int32
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&originGets, 1)
+		_, _ = io.WriteString(w, body)
+	}))
+	defer origin.Close()
+
+	c, err := cache.Open(cache.Config{Dir: t.TempDir(), MaxSize: 1})
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	defer c.Close()
+
+	dest := filepath.Join(t.TempDir(), "out.txt")
+	fr, err := Fetch(cache.WithCache(context.Background(), c), origin.URL+"/f", dest, FetchOptions{})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if got, _ := os.ReadFile(dest); string(got) != body {
+		t.Errorf("content = %q, want %q", got, body)
+	}
+	if fr.Hit || fr.N != int64(len(body)) {
+		t.Errorf("result = %+v", fr)
+	}
+	if n := atomic.LoadInt32(&originGets); n != 1 {
+		t.Errorf("origin GETs = %d, want 1", n)
+	}
+}
+
+// TestFetch_AuthorizedBypassesCache checks that a body fetched with an
+// Authorization header is neither cached nor served from the cache.
+func TestFetch_AuthorizedBypassesCache(t *testing.T) {
+	var originGets int32
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&originGets, 1)
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		_, _ = io.WriteString(w, "embargoed")
+	}))
+	defer origin.Close()
+
+	c, err := cache.Open(cache.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	defer c.Close()
+	ctx := cache.WithCache(context.Background(), c)
+
+	opts := FetchOptions{ExtraHeaders: map[string]string{"Authorization": "Bearer secret"}}
+	for i := 0; i < 2; i++ {
+		dest := filepath.Join(t.TempDir(), "out.txt")
+		fr, err := Fetch(ctx, origin.URL+"/f", dest, opts)
+		if err != nil {
+			t.Fatalf("Fetch %d: %v", i, err)
+		}
+		if fr.Hit {
+			t.Errorf("Fetch %d served from the cache", i)
+		}
+	}
+	if n := atomic.LoadInt32(&originGets); n != 2 {
+		t.Errorf("origin GETs = %d, want 2", n)
+	}
+	if _, hit, _ := c.Lookup(ctx, origin.URL+"/f"); hit {
+		t.Error("authorized response was cached")
+	}
+}
+
+// TestFetch_TokenInURLBypassesCache checks that a URL carrying a token as a
+// query parameter is fetched from the origin and left out of the cache.
+func TestFetch_TokenInURLBypassesCache(t *testing.T) {
+	var originGets int32
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&originGets, 1)
+		if r.URL.Query().Get("access_token") != "secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		_, _ = io.WriteString(w, "embargoed")
+	}))
+	defer origin.Close()
+
+	c, err := cache.Open(cache.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	defer c.Close()
+	ctx := cache.WithCache(context.Background(), c)
+
+	for i := 0; i < 2; i++ {
+		dest := filepath.Join(t.TempDir(), "out.txt")
+		fr, err := Fetch(ctx, origin.URL+"/f?access_token=secret", dest, FetchOptions{})
+		if err != nil {
+			t.Fatalf("Fetch %d: %v", i, err)
+		}
+		if fr.Hit {
+			t.Errorf("Fetch %d served from the cache", i)
+		}
+	}
+	if n := atomic.LoadInt32(&originGets); n != 2 {
+		t.Errorf("origin GETs = %d, want 2", n)
+	}
+	if _, hit, _ := c.Lookup(ctx, origin.URL+"/f"); hit {
+		t.Error("response fetched with a URL token was cached")
+	}
+}
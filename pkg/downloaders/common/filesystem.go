@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -256,6 +257,9 @@ func WriteWitnessFile(targetDir string, witness *downloaders.WitnessFile) error
 	if existing, err := LoadWitnessFile(targetDir); err == nil {
 		witness = mergeWitnessFiles(existing, witness)
 	}
+	if v := summarizeVerification(witness.Files); v != nil {
+		witness.Verification = v
+	}
 
 	file, err := os.Create(filepath.Clean(witnessPath)) // #nosec G304 -- internal witness path
 	if err != nil {
@@ -303,10 +307,11 @@ func mergeWitnessFiles(prev, next *downloaders.WitnessFile) *downloaders.Witness
 			BytesDownloaded: prev.DownloadStats.BytesDownloaded + next.DownloadStats.BytesDownloaded,
 			FilesDownloaded: prev.DownloadStats.FilesDownloaded + next.DownloadStats.FilesDownloaded,
 			FilesTotal:      prev.DownloadStats.FilesTotal + next.DownloadStats.FilesTotal,
-			// Duration/speed reflect the most recent run only.
-			Duration:      next.DownloadStats.Duration,
-			AverageSpeed:  next.DownloadStats.AverageSpeed,
-			MaxConcurrent: next.DownloadStats.MaxConcurrent,
+			// Duration, speed and resume reflect the most recent run only.
+			Duration:        next.DownloadStats.Duration,
+			AverageSpeed:    next.DownloadStats.AverageSpeed,
+			MaxConcurrent:   next.DownloadStats.MaxConcurrent,
+			ResumedDownload: next.DownloadStats.ResumedDownload,
 		}
 	}
 
@@ -341,6 +346,41 @@ func mergeWitnessFiles(prev, next *downloaders.WitnessFile) *downloaders.Witness
 	return &merged
 }
 
+// summarizeVerification rolls the per-file upstream digest checks up into a
+// witness-level record: Verified only when every checked file matched. It
+// returns nil when no file carried a published digest.
+func summarizeVerification(files []downloaders.FileWitness) *downloaders.Verification {
+	var v *downloaders.Verification
+	methods := map[string]bool{}
+	for _, f := range files {
+		fv := f.Verification
+		if fv == nil {
+			continue
+		}
+		if v == nil {
+			v = &downloaders.Verification{Verified: true}
+		}
+		if fv.VerifyTime.After(v.VerifyTime) {
+			v.VerifyTime = fv.VerifyTime
+		}
+		methods[fv.Method] = true
+		if !fv.Verified {
+			v.Verified = false
+			v.Errors = append(v.Errors, fmt.Sprintf("%s: %s expected %s, got %s", f.Path, fv.Method, fv.Expected, fv.Actual))
+		}
+	}
+	if v == nil {
+		return nil
+	}
+	names := make([]string, 0, len(methods))
+	for m := range methods {
+		names = append(names, m)
+	}
+	sort.Strings(names)
+	v.Method = "upstream " + strings.Join(names, ",")
+	return v
+}
+
 // LoadWitnessFile reads and parses a hapiq.json file.
 func LoadWitnessFile(targetDir string) (*downloaders.WitnessFile, error) {
 	witnessPath := filepath.Join(targetDir, "hapiq.json")
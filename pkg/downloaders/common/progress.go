@@ -37,6 +37,7 @@ type FileProgress struct {
 	Downloaded     int64
 	Speed          float64
 	Status         FileStatus
+	Resumed        bool
 }
 
 // FileStatus represents the status of a file download.
@@ -158,6 +159,16 @@ func (pt *ProgressTracker) CompleteFile(filename string) {
 	}
 }
 
+// ResumeFile records that filename continued an earlier partial download.
+func (pt *ProgressTracker) ResumeFile(filename string) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	if fileProgress, exists := pt.files[filename]; exists {
+		fileProgress.Resumed = true
+	}
+}
+
 // FailFile marks a file as failed.
 func (pt *ProgressTracker) FailFile(filename string, err error) {
 	pt.mu.Lock()
@@ -202,6 +213,15 @@ func (pt *ProgressTracker) GetStats() *downloaders.DownloadStats {
 		averageSpeed = float64(pt.downloadedBytes) / duration.Seconds()
 	}
 
+	resumed := false
+
+	for _, fileProgress := range pt.files {
+		if fileProgress.Resumed {
+			resumed = true
+			break
+		}
+	}
+
 	return &downloaders.DownloadStats{
 		Duration:        duration,
 		BytesTotal:      pt.totalBytes,
@@ -212,7 +232,7 @@ func (pt *ProgressTracker) GetStats() *downloaders.DownloadStats {
 		FilesFailed:     pt.failedFiles,
 		AverageSpeed:    averageSpeed,
 		MaxConcurrent:   1, // This would be set by the caller
-		ResumedDownload: false,
+		ResumedDownload: resumed,
 	}
 }
 
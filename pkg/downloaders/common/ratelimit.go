@@ -0,0 +1,240 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Built-in per-host request rates (requests per second). A rule matches the
+// host itself and any subdomain, and every host matching a rule shares one
+// bucket. The E-utilities rule is scoped to eutils.ncbi.nlm.nih.gov so bulk
+// transfers from ftp.ncbi.nlm.nih.gov are not throttled. Override or extend
+// them in the config file:
+//
+//	[ratelimit]
+//	default_rps = 0 # unlisted hosts; 0 = unlimited
+//	burst       = 1
+//
+//	[ratelimit.hosts]
+//	"eutils.ncbi.nlm.nih.gov" = 3
+//
+//	[ratelimit.keyed_hosts] # requests carrying an api_key query parameter
+//	"eutils.ncbi.nlm.nih.gov" = 10
+var (
+	defaultHostRates = map[string]float64{
+		"eutils.ncbi.nlm.nih.gov": 3, // E-utilities without an API key
+		"rest.ensembl.org":        15,
+		"ftp.ensembl.org":         1,
+		"ftp.ensemblgenomes.org":  1,
+	}
+	defaultKeyedHostRates = map[string]float64{
+		"eutils.ncbi.nlm.nih.gov": 10, // E-utilities with api_key
+	}
+)
+
+// hostLimits holds one token bucket per matched rule, shared by the whole
+// process so concurrent downloaders (e.g. several GEO and SRA manifest
+// entries) stay under a host's limit together. Keyed and unkeyed requests
+// to a host share its bucket; only the refill rate differs, so a process
+// mixing both never exceeds the keyed rate.
+var hostLimits = struct {
+	sync.Mutex
+	buckets map[string]*tokenBucket
+}{buckets: map[string]*tokenBucket{}}
+
+// tokenBucket is a token bucket holding up to burst tokens. Each caller
+// refills it at its own rate (tokens per second), so keyed and unkeyed
+// requests can share a bucket. Reservations may drive tokens negative; the
+// caller then sleeps until its token would have been available, which keeps
+// waiters in FIFO order.
+type tokenBucket struct {
+	mu     sync.Mutex
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{burst: float64(burst), tokens: float64(burst)}
+}
+
+// reserve takes one token at rate and returns how long the caller must wait
+// for it.
+func (b *tokenBucket) reserve(now time.Time, rate float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// cancel returns a token reserved by a caller that gave up waiting.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	b.tokens++
+	b.mu.Unlock()
+}
+
+func (b *tokenBucket) wait(ctx context.Context, rate float64) error {
+	d := b.reserve(time.Now(), rate)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// WaitHost blocks until a request to host may be sent under the configured
+// per-host rate, or ctx is done. keyed selects the [ratelimit.keyed_hosts]
+// rate, for requests authenticated with an API key. Hosts without a rule
+// (and every host when ratelimit.enabled is false) are not delayed.
+func WaitHost(ctx context.Context, host string, keyed bool) error {
+	b, rate := bucketFor(strings.ToLower(host), keyed)
+	if b == nil {
+		return nil
+	}
+	return b.wait(ctx, rate)
+}
+
+// bucketFor returns host's bucket and the rate to draw from it at.
+func bucketFor(host string, keyed bool) (*tokenBucket, float64) {
+	if host == "" || (viper.IsSet("ratelimit.enabled") && !viper.GetBool("ratelimit.enabled")) {
+		return nil, 0
+	}
+	rule, rate := matchHostRate(host, keyed)
+	if rate <= 0 {
+		return nil, 0
+	}
+
+	hostLimits.Lock()
+	defer hostLimits.Unlock()
+	b, ok := hostLimits.buckets[rule]
+	if !ok {
+		b = newTokenBucket(viper.GetInt("ratelimit.burst"))
+		hostLimits.buckets[rule] = b
+	}
+	return b, rate
+}
+
+// matchHostRate returns the most specific rule matching host and its rate.
+// Unmatched hosts get ratelimit.default_rps under their own name.
+func matchHostRate(host string, keyed bool) (string, float64) {
+	rates := hostRates(keyed)
+	for h := host; h != ""; {
+		if r, ok := rates[h]; ok {
+			return h, r
+		}
+		_, rest, found := strings.Cut(h, ".")
+		if !found {
+			break
+		}
+		h = rest
+	}
+	if keyed {
+		// Keyed requests to a host with only an unkeyed rule keep that rule.
+		return matchHostRate(host, false)
+	}
+	return host, viper.GetFloat64("ratelimit.default_rps")
+}
+
+// hostRates merges the built-in table with the configured overrides.
+func hostRates(keyed bool) map[string]float64 {
+	base, key := defaultHostRates, "ratelimit.hosts"
+	if keyed {
+		base, key = defaultKeyedHostRates, "ratelimit.keyed_hosts"
+	}
+	rates := make(map[string]float64, len(base))
+	for h, r := range base {
+		rates[h] = r
+	}
+	flattenRates(viper.GetStringMap(key), "", rates)
+	return rates
+}
+
+// flattenRates copies host = rate pairs into out. Viper splits unquoted
+// dotted keys into nested tables, so nested maps are joined back with ".".
+func flattenRates(m map[string]any, prefix string, out map[string]float64) {
+	for k, v := range m {
+		name := strings.ToLower(k)
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		switch val := v.(type) {
+		case map[string]any:
+			flattenRates(val, name, out)
+		case int:
+			out[name] = float64(val)
+		case int64:
+			out[name] = float64(val)
+		case float64:
+			out[name] = val
+		default:
+			var f float64
+			if _, err := fmt.Sscan(fmt.Sprint(val), &f); err == nil {
+				out[name] = f
+			}
+		}
+	}
+}
+
+// rateLimitedTransport delays each request by its host's token bucket.
+type rateLimitedTransport struct {
+	base http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := WaitHost(req.Context(), req.URL.Hostname(), req.URL.Query().Has("api_key")); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// NewRateLimitedTransport wraps base (http.DefaultTransport when nil) so
+// every request waits for its host's bucket. Wrapping an already limited
+// transport returns it unchanged, so a request never pays twice.
+func NewRateLimitedTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if _, ok := base.(*rateLimitedTransport); ok {
+		return base
+	}
+	return &rateLimitedTransport{base: base}
+}
+
+var installRateLimiter sync.Once
+
+// InstallRateLimiter routes http.DefaultTransport, and with it every client
+// that does not set its own Transport, through the per-host limiter. The CLI
+// calls it once at startup; rates are read from Viper when a host is first
+// contacted.
+func InstallRateLimiter() {
+	installRateLimiter.Do(func() {
+		http.DefaultTransport = NewRateLimitedTransport(http.DefaultTransport)
+	})
+}
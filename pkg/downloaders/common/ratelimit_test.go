@@ -0,0 +1,145 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// resetRateLimits clears Viper and the process-wide buckets for a test.
+func resetRateLimits(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		viper.Reset()
+		hostLimits.Lock()
+		hostLimits.buckets = map[string]*tokenBucket{}
+		hostLimits.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+	RegisterDefaults()
+}
+
+func TestTokenBucketReserve(t *testing.T) {
+	b := newTokenBucket(1)
+	now := time.Now()
+	const rate = 2 // one token every 500ms
+	if d := b.reserve(now, rate); d != 0 {
+		t.Fatalf("first reserve waited %s, want 0", d)
+	}
+	if d := b.reserve(now, rate); d != 500*time.Millisecond {
+		t.Errorf("second reserve = %s, want 500ms", d)
+	}
+	if d := b.reserve(now, rate); d != time.Second {
+		t.Errorf("third reserve = %s, want 1s (queued behind the second)", d)
+	}
+	// After the queue drains and a further second passes, the bucket is full
+	// again but never above burst.
+	if d := b.reserve(now.Add(3*time.Second), rate); d != 0 {
+		t.Errorf("reserve after refill = %s, want 0", d)
+	}
+	if d := b.reserve(now.Add(3*time.Second), rate); d != 500*time.Millisecond {
+		t.Errorf("reserve beyond burst = %s, want 500ms", d)
+	}
+	// A slower caller sharing the bucket waits at its own rate.
+	if d := b.reserve(now.Add(3*time.Second), 1); d != 2*time.Second {
+		t.Errorf("reserve at 1 req/s = %s, want 2s", d)
+	}
+}
+
+func TestMatchHostRate(t *testing.T) {
+	resetRateLimits(t)
+	viper.Set("ratelimit.hosts", map[string]any{
+		"rest.ensembl.org": 5,
+		// An unquoted dotted TOML key arrives as nested tables.
+		"example": map[string]any{"org": 2.5},
+	})
+
+	tests := []struct {
+		host     string
+		keyed    bool
+		wantRule string
+		wantRate float64
+	}{
+		{"eutils.ncbi.nlm.nih.gov", false, "eutils.ncbi.nlm.nih.gov", 3},
+		{"eutils.ncbi.nlm.nih.gov", true, "eutils.ncbi.nlm.nih.gov", 10},
+		{"ftp.ncbi.nlm.nih.gov", false, "ftp.ncbi.nlm.nih.gov", 0},
+		{"rest.ensembl.org", false, "rest.ensembl.org", 5},
+		{"rest.ensembl.org", true, "rest.ensembl.org", 5},
+		{"data.example.org", false, "example.org", 2.5},
+		{"zenodo.org", false, "zenodo.org", 0},
+	}
+	for _, tt := range tests {
+		rule, rate := matchHostRate(tt.host, tt.keyed)
+		if rule != tt.wantRule || rate != tt.wantRate {
+			t.Errorf("matchHostRate(%q, %v) = (%q, %v), want (%q, %v)",
+				tt.host, tt.keyed, rule, rate, tt.wantRule, tt.wantRate)
+		}
+	}
+}
+
+func TestBucketForSharesKeyedAndUnkeyed(t *testing.T) {
+	resetRateLimits(t)
+	unkeyed, slow := bucketFor("eutils.ncbi.nlm.nih.gov", false)
+	keyed, fast := bucketFor("eutils.ncbi.nlm.nih.gov", true)
+	if unkeyed == nil || unkeyed != keyed {
+		t.Error("keyed and unkeyed requests to a host must share one bucket")
+	}
+	if slow != 3 || fast != 10 {
+		t.Errorf("rates = %v unkeyed, %v keyed; want 3 and 10", slow, fast)
+	}
+	if b, _ := bucketFor("ftp.ncbi.nlm.nih.gov", false); b != nil {
+		t.Error("NCBI FTP transfers are limited by the E-utilities rule")
+	}
+	if b, _ := bucketFor("zenodo.org", false); b != nil {
+		t.Error("unlisted host is limited with default_rps = 0")
+	}
+
+	viper.Set("ratelimit.enabled", false)
+	if b, _ := bucketFor("eutils.ncbi.nlm.nih.gov", false); b != nil {
+		t.Error("ratelimit.enabled = false still returned a bucket")
+	}
+}
+
+func TestRateLimitedTransport(t *testing.T) {
+	resetRateLimits(t)
+	viper.Set("ratelimit.hosts", map[string]any{"127.0.0.1": 20}) // 50ms apart
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	client := &http.Client{Transport: NewRateLimitedTransport(srv.Client().Transport)}
+
+	start := time.Now()
+	for range 4 {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("4 requests at 20 req/s took %s, want >= 150ms", elapsed)
+	}
+
+	if NewRateLimitedTransport(client.Transport) != client.Transport {
+		t.Error("re-wrapping a limited transport must return it unchanged")
+	}
+}
+
+func TestWaitHostHonoursContext(t *testing.T) {
+	resetRateLimits(t)
+	viper.Set("ratelimit.hosts", map[string]any{"slow.example": 0.1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := WaitHost(ctx, "slow.example", false); err != nil {
+		t.Fatalf("first WaitHost: %v", err)
+	}
+	if err := WaitHost(ctx, "slow.example", false); err == nil {
+		t.Fatal("second WaitHost returned before its token; want ctx error")
+	}
+}
@@ -0,0 +1,246 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/btraven00/hapiq/pkg/cache"
+)
+
+// partialMeta is stored beside a partial download (<part>.json) so a later
+// run can confirm the server still has the same representation before it
+// asks for the remaining bytes.
+//
+// The cache's urls.etag/last_modified columns are deliberately not used: a
+// urls row names a complete blob (sha256 is NOT NULL), and for a stale entry
+// it holds the validators of the copy being revalidated. Writing the new
+// representation's ETag there before its body is complete would pair the
+// old blob with new validators if the download never finishes, and a later
+// 304 would then serve the old content as current. Without a cache there is
+// no index at all, only <dest>.part.
+type partialMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// ifRange returns the If-Range validator for m. Weak ETags are not allowed
+// in If-Range, so those fall back to Last-Modified. An empty result means the
+// partial cannot be resumed safely.
+func (m partialMeta) ifRange() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+	return m.LastModified
+}
+
+func partialMetaPath(partPath string) string { return partPath + ".json" }
+
+func loadPartialMeta(partPath string) (partialMeta, bool) {
+	var m partialMeta
+	data, err := os.ReadFile(filepath.Clean(partialMetaPath(partPath))) // #nosec G304 -- derived from download path
+	if err != nil {
+		return m, false
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, false
+	}
+	return m, true
+}
+
+func savePartialMeta(partPath string, m partialMeta) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(partialMetaPath(partPath), data, 0o600)
+}
+
+// removePartial deletes a partial download and its metadata.
+func removePartial(partPath string) {
+	_ = os.Remove(partPath)
+	_ = os.Remove(partialMetaPath(partPath))
+}
+
+// streamResumable downloads rawURL into partPath. When partPath already holds
+// a prefix of the file and its recorded ETag/Last-Modified can be sent as
+// If-Range, only the remaining bytes are requested with "Range: bytes=N-".
+// The sha256 is rebuilt by re-hashing the existing prefix before appending.
+// A 200 reply (the server ignored the range or the representation changed)
+// restarts from byte zero.
+//
+// On error partPath and its metadata are left on disk for the next attempt.
+// On success the metadata is removed and the caller owns partPath.
+func streamResumable(ctx context.Context, client *http.Client, rawURL string, extra map[string]string, partPath string) (fetched, error) {
+	var offset int64
+	var validator string
+	if m, ok := loadPartialMeta(partPath); ok && m.URL == rawURL {
+		validator = m.ifRange()
+		if info, err := os.Stat(partPath); err == nil && validator != "" {
+			offset = info.Size()
+		}
+	}
+
+	headers := make(map[string]string, len(extra)+3)
+	for k, v := range extra {
+		headers[k] = v
+	}
+	// Byte offsets must refer to the stored representation; a transparently
+	// decompressed body would make the partial's length meaningless.
+	headers["Accept-Encoding"] = "identity"
+	if offset > 0 {
+		headers["Range"] = fmt.Sprintf("bytes=%d-", offset)
+		headers["If-Range"] = validator
+	}
+
+	resp, err := getWaitingForReady(ctx, client, rawURL, headers)
+	if err != nil {
+		return fetched{}, err
+	}
+	defer resp.Body.Close()
+
+	h := sha256.New()
+	var f *os.File
+	resumed := false
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			removePartial(partPath)
+			return fetched{}, fmt.Errorf("resume %s: unexpected Content-Range %q for offset %d", rawURL, resp.Header.Get("Content-Range"), offset)
+		}
+		if err := hashPrefix(partPath, offset, h); err != nil {
+			removePartial(partPath)
+			return fetched{}, fmt.Errorf("resume %s: re-hash partial: %w", rawURL, err)
+		}
+		f, err = os.OpenFile(filepath.Clean(partPath), os.O_WRONLY|os.O_APPEND, 0o600) // #nosec G304 -- derived from download path
+		if err != nil {
+			return fetched{}, err
+		}
+		resumed = true
+
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// If-Range matched and there is nothing past our offset: the partial
+		// is the whole file, only the promotion step was interrupted.
+		if total, ok := contentRangeTotal(resp.Header.Get("Content-Range")); ok && total == offset {
+			if err := hashPrefix(partPath, offset, h); err != nil {
+				removePartial(partPath)
+				return fetched{}, fmt.Errorf("resume %s: re-hash partial: %w", rawURL, err)
+			}
+			_ = os.Remove(partialMetaPath(partPath))
+			return fetched{
+				n:           offset,
+				sha256hex:   hex.EncodeToString(h.Sum(nil)),
+				contentType: resp.Header.Get("Content-Type"),
+				validators:  validatorsOf(resp),
+				resumed:     true,
+			}, nil
+		}
+		removePartial(partPath)
+		return fetched{}, fmt.Errorf("HTTP %d for %s (partial discarded, retry to start over)", resp.StatusCode, rawURL)
+
+	case resp.StatusCode == http.StatusOK:
+		f, err = os.Create(filepath.Clean(partPath)) // #nosec G304 -- derived from download path
+		if err != nil {
+			return fetched{}, err
+		}
+		offset = 0
+		m := partialMeta{URL: rawURL, ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+		if m.ifRange() != "" {
+			_ = savePartialMeta(partPath, m)
+		} else {
+			// No validator: a later resume could splice two different files.
+			_ = os.Remove(partialMetaPath(partPath))
+		}
+
+	default:
+		return fetched{}, NewHTTPError(resp, rawURL)
+	}
+
+	n, copyErr := io.Copy(io.MultiWriter(f, h), resp.Body)
+	if closeErr := f.Close(); closeErr != nil && copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		return fetched{}, fmt.Errorf("read body: %w", copyErr)
+	}
+
+	_ = os.Remove(partialMetaPath(partPath))
+	return fetched{
+		n:           offset + n,
+		sha256hex:   hex.EncodeToString(h.Sum(nil)),
+		contentType: resp.Header.Get("Content-Type"),
+		filename:    FilenameFromContentDisposition(resp.Header.Get("Content-Disposition")),
+		validators:  validatorsOf(resp),
+		resumed:     resumed,
+	}, nil
+}
+
+// fetched collects what streamToFile or streamResumable learned about a
+// download.
+type fetched struct {
+	sha256hex   string
+	digest      string // FetchOptions.Expected-type digest, when streamed
+	contentType string
+	filename    string
+	validators  cache.Validators
+	n           int64
+	resumed     bool
+}
+
+// validatorsOf returns resp's ETag and Last-Modified.
+func validatorsOf(resp *http.Response) cache.Validators {
+	return cache.Validators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+}
+
+// hashPrefix feeds the first n bytes of path into h.
+func hashPrefix(path string, n int64, h hash.Hash) error {
+	f, err := os.Open(filepath.Clean(path)) // #nosec G304 -- derived from download path
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	copied, err := io.Copy(h, io.LimitReader(f, n))
+	if err != nil {
+		return err
+	}
+	if copied != n {
+		return errors.New("partial file shorter than expected")
+	}
+	return nil
+}
+
+// contentRangeStart parses the first byte position of "bytes START-END/TOTAL".
+func contentRangeStart(cr string) (int64, bool) {
+	spec, ok := strings.CutPrefix(cr, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
+	return v, err == nil
+}
+
+// contentRangeTotal parses TOTAL from "bytes */TOTAL" or "bytes S-E/TOTAL".
+func contentRangeTotal(cr string) (int64, bool) {
+	_, total, ok := strings.Cut(cr, "/")
+	if !ok || total == "*" {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64)
+	return v, err == nil
+}
@@ -0,0 +1,174 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/btraven00/hapiq/pkg/cache"
+)
+
+// rangeServer serves body with the given ETag and honours Range/If-Range via
+// http.ServeContent. While cutAt > 0 the first GET is aborted after cutAt
+// bytes, simulating a dropped connection. Requested ranges are recorded.
+type rangeServer struct {
+	body   []byte
+	etag   string
+	cutAt  int
+	gets   int32
+	ranges []string
+}
+
+func (s *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := atomic.AddInt32(&s.gets, 1)
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	w.Header().Set("ETag", s.etag)
+	if n == 1 && s.cutAt > 0 {
+		w.Header().Set("Content-Length", strconv.Itoa(len(s.body)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(s.body[:s.cutAt])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(s.body))
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestFetch_ResumeAfterInterruptNoCache(t *testing.T) {
+	body := []byte(strings.Repeat("ACGT", 4096))
+	rs := &rangeServer{body: body, etag: `"v1"`, cutAt: 5000}
+	srv := httptest.NewServer(rs)
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "reads.fastq")
+	opts := FetchOptions{Client: srv.Client(), Resume: true}
+
+	if _, err := Fetch(context.Background(), srv.URL, dest, opts); err == nil {
+		t.Fatal("first Fetch: expected error from interrupted transfer")
+	}
+	if info, err := os.Stat(dest + ".part"); err != nil || info.Size() != 5000 {
+		t.Fatalf("partial after interrupt: %v (size %v), want 5000 bytes kept", err, info)
+	}
+
+	fr, err := Fetch(context.Background(), srv.URL, dest, opts)
+	if err != nil {
+		t.Fatalf("resumed Fetch: %v", err)
+	}
+	if !fr.Resumed {
+		t.Error("Resumed = false, want true")
+	}
+	if got := rs.ranges[len(rs.ranges)-1]; got != "bytes=5000-" {
+		t.Errorf("resume Range = %q, want %q", got, "bytes=5000-")
+	}
+	if fr.SHA256 != sha256Hex(body) || fr.N != int64(len(body)) {
+		t.Errorf("result sha256=%s n=%d, want sha256=%s n=%d", fr.SHA256, fr.N, sha256Hex(body), len(body))
+	}
+	if got, _ := os.ReadFile(dest); !bytes.Equal(got, body) {
+		t.Error("resumed file content does not match")
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Error("partial file left behind after successful resume")
+	}
+	if _, err := os.Stat(dest + ".part.json"); !os.IsNotExist(err) {
+		t.Error("partial metadata left behind after successful resume")
+	}
+}
+
+func TestFetch_ResumeRestartsWhenETagChanges(t *testing.T) {
+	body := []byte(strings.Repeat("new content ", 500))
+	rs := &rangeServer{body: body, etag: `"v2"`}
+	srv := httptest.NewServer(rs)
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "matrix.loom")
+	part := dest + ".part"
+	if err := os.WriteFile(part, []byte("old content from v1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := savePartialMeta(part, partialMeta{URL: srv.URL, ETag: `"v1"`}); err != nil {
+		t.Fatal(err)
+	}
+
+	fr, err := Fetch(context.Background(), srv.URL, dest, FetchOptions{Client: srv.Client(), Resume: true})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if fr.Resumed {
+		t.Error("Resumed = true, want false (If-Range must fail on a changed ETag)")
+	}
+	if got, _ := os.ReadFile(dest); !bytes.Equal(got, body) {
+		t.Error("file content is not the new representation")
+	}
+	if fr.SHA256 != sha256Hex(body) {
+		t.Errorf("SHA256 = %s, want %s", fr.SHA256, sha256Hex(body))
+	}
+}
+
+func TestFetch_ResumeIntoCache(t *testing.T) {
+	body := []byte(strings.Repeat("0123456789", 2000))
+	rs := &rangeServer{body: body, etag: `"blob"`, cutAt: 12345}
+	srv := httptest.NewServer(rs)
+	defer srv.Close()
+
+	c, err := cache.Open(cache.Config{Dir: t.TempDir(), LinkStrategy: cache.StrategyCopy})
+	if err != nil {
+		t.Fatalf("cache.Open: %v", err)
+	}
+	defer c.Close()
+	ctx := cache.WithCache(context.Background(), c)
+	opts := FetchOptions{Client: srv.Client(), Resume: true}
+	dest := filepath.Join(t.TempDir(), "out.bin")
+
+	if _, err := Fetch(ctx, srv.URL, dest, opts); err == nil {
+		t.Fatal("first Fetch: expected error from interrupted transfer")
+	}
+	if info, err := os.Stat(c.PartialPath(srv.URL)); err != nil || info.Size() != 12345 {
+		t.Fatalf("cache partial after interrupt: %v", err)
+	}
+
+	fr, err := Fetch(ctx, srv.URL, dest, opts)
+	if err != nil {
+		t.Fatalf("resumed Fetch: %v", err)
+	}
+	if !fr.Resumed || fr.SHA256 != sha256Hex(body) {
+		t.Errorf("Resumed=%v SHA256=%s, want resumed blob %s", fr.Resumed, fr.SHA256, sha256Hex(body))
+	}
+	if _, _, hit, _ := c.Get(context.Background(), srv.URL); !hit {
+		t.Error("resumed blob was not promoted into the cache")
+	}
+	if _, err := os.Stat(c.PartialPath(srv.URL)); !os.IsNotExist(err) {
+		t.Error("cache partial left behind after promotion")
+	}
+}
+
+func TestFetch_WithoutResumeDiscardsPartial(t *testing.T) {
+	body := []byte(strings.Repeat("x", 8192))
+	rs := &rangeServer{body: body, etag: `"v1"`, cutAt: 100}
+	srv := httptest.NewServer(rs)
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "out.bin")
+	if _, err := Fetch(context.Background(), srv.URL, dest, FetchOptions{Client: srv.Client()}); err == nil {
+		t.Fatal("expected error from interrupted transfer")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("truncated destination left behind without --resume")
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Error("partial created without --resume")
+	}
+}
@@ -0,0 +1,234 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Retry defaults, overridable under [retry] (all sources) and
+// [retry.<source>] (one source) in the config file:
+//
+//	[retry]
+//	max_attempts    = 4
+//	initial_backoff = "1s"
+//	max_backoff     = "30s"
+//
+//	[retry.zenodo]
+//	max_attempts = 8
+const (
+	defaultRetryAttempts       = 4
+	defaultRetryInitialBackoff = time.Second
+	defaultRetryMaxBackoff     = 30 * time.Second
+)
+
+// RegisterDefaults sets Viper defaults for the retry and ratelimit keys.
+func RegisterDefaults() {
+	viper.SetDefault("retry.max_attempts", defaultRetryAttempts)
+	viper.SetDefault("retry.initial_backoff", defaultRetryInitialBackoff.String())
+	viper.SetDefault("retry.max_backoff", defaultRetryMaxBackoff.String())
+	viper.SetDefault("ratelimit.enabled", true)
+	viper.SetDefault("ratelimit.burst", 1)
+	viper.SetDefault("ratelimit.default_rps", 0)
+}
+
+// RetryPolicy controls how Retry re-attempts a failed operation. The zero
+// value performs a single attempt.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	// InitialBackoff is the base delay before the second attempt; it doubles
+	// on each further attempt (with jitter) up to MaxBackoff.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts. A Retry-After longer than
+	// this ends the retries rather than stalling the download.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy returns the built-in policy used when no config is set.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    defaultRetryAttempts,
+		InitialBackoff: defaultRetryInitialBackoff,
+		MaxBackoff:     defaultRetryMaxBackoff,
+	}
+}
+
+// RetryPolicyFor resolves the retry policy for source: built-in defaults,
+// then the [retry] keys, then [retry.<source>].
+func RetryPolicyFor(source string) RetryPolicy {
+	p := DefaultRetryPolicy()
+	for _, prefix := range []string{"retry.", "retry." + strings.ToLower(source) + "."} {
+		if viper.IsSet(prefix + "max_attempts") {
+			if n := viper.GetInt(prefix + "max_attempts"); n > 0 {
+				p.MaxAttempts = n
+			}
+		}
+		if d, ok := viperDuration(prefix + "initial_backoff"); ok {
+			p.InitialBackoff = d
+		}
+		if d, ok := viperDuration(prefix + "max_backoff"); ok {
+			p.MaxBackoff = d
+		}
+	}
+	return p
+}
+
+func viperDuration(key string) (time.Duration, bool) {
+	if !viper.IsSet(key) {
+		return 0, false
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(viper.GetString(key)))
+	if err != nil || d < 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// HTTPError reports a non-success HTTP status from a download. Retry uses
+// StatusCode to decide whether another attempt can help and RetryAfter to
+// pace it.
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	// RetryAfter is the parsed Retry-After header, or zero when absent.
+	RetryAfter time.Duration
+}
+
+// NewHTTPError builds an HTTPError from resp, parsing Retry-After.
+func NewHTTPError(resp *http.Response, rawURL string) *HTTPError {
+	return &HTTPError{
+		URL:        rawURL,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d for %s", e.StatusCode, e.URL)
+}
+
+// parseRetryAfter accepts delta-seconds or an HTTP-date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// IsRetryable reports whether err is a transient failure worth another
+// attempt: 408/429/5xx responses (except 501), timeouts, and connections
+// that were refused, reset or cut short.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var he *HTTPError
+	if errors.As(err, &he) {
+		switch he.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests:
+			return true
+		case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+			return false
+		}
+		return he.StatusCode >= 500
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+
+	for _, target := range []error{
+		io.ErrUnexpectedEOF,
+		syscall.ECONNRESET,
+		syscall.ECONNREFUSED,
+		syscall.ECONNABORTED,
+		syscall.EPIPE,
+		syscall.ENETUNREACH,
+		syscall.EHOSTUNREACH,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Retry runs op until it succeeds, returns a non-retryable error, the policy
+// is exhausted, or ctx is done. Delays grow exponentially from
+// InitialBackoff with equal jitter (half fixed, half random) so parallel
+// downloads do not retry in lockstep. A Retry-After from the server takes
+// precedence over the computed delay. label names the operation in the
+// progress message printed before each retry.
+func Retry(ctx context.Context, p RetryPolicy, label string, op func() error) error {
+	backoff := p.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= p.MaxAttempts || !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := jitter(backoff)
+		var he *HTTPError
+		if errors.As(err, &he) && he.RetryAfter > 0 {
+			if p.MaxBackoff > 0 && he.RetryAfter > p.MaxBackoff {
+				return fmt.Errorf("%w (server asked to retry after %s)", err, he.RetryAfter)
+			}
+			delay = he.RetryAfter
+		}
+
+		_, _ = fmt.Fprintf(os.Stderr, "🔄 retry %d/%d for %s in %s: %v\n",
+			attempt, p.MaxAttempts-1, label, delay.Round(time.Millisecond), err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if backoff *= 2; p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
+
+// jitter returns a random duration in [d/2, d].
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half+1) //nolint:gosec // backoff jitter, not security sensitive
+}
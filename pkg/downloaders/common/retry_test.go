@@ -0,0 +1,192 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"500", &HTTPError{StatusCode: 500}, true},
+		{"503 wrapped", fmt.Errorf("fetch: %w", &HTTPError{StatusCode: 503}), true},
+		{"429", &HTTPError{StatusCode: 429}, true},
+		{"408", &HTTPError{StatusCode: 408}, true},
+		{"501", &HTTPError{StatusCode: 501}, false},
+		{"404", &HTTPError{StatusCode: 404}, false},
+		{"403", &HTTPError{StatusCode: 403}, false},
+		{"timeout", &net.OpError{Op: "read", Err: timeoutError{}}, true},
+		{"connection reset", fmt.Errorf("read body: %w", &net.OpError{Op: "read", Err: syscall.ECONNRESET}), true},
+		{"connection refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{"network unreachable", &net.OpError{Op: "dial", Err: syscall.ENETUNREACH}, true},
+		{"unexpected EOF", fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), true},
+		{"dns temporary", &net.DNSError{Err: "server misbehaving", IsTemporary: true}, true},
+		{"dns not found", &net.DNSError{Err: "no such host", IsNotFound: true}, false},
+		{"canceled", context.Canceled, false},
+		{"plain", errors.New("invalid format"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"7", 7 * time.Second},
+		{"-1", 0},
+		{"Mon, 01 Jan 2024 12:00:30 GMT", 30 * time.Second},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyFor(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	RegisterDefaults()
+
+	if got := RetryPolicyFor("zenodo"); got != DefaultRetryPolicy() {
+		t.Errorf("defaults: got %+v, want %+v", got, DefaultRetryPolicy())
+	}
+
+	viper.Set("retry.max_backoff", "10s")
+	viper.Set("retry.zenodo.max_attempts", 8)
+	viper.Set("retry.zenodo.initial_backoff", "250ms")
+
+	got := RetryPolicyFor("Zenodo")
+	want := RetryPolicy{MaxAttempts: 8, InitialBackoff: 250 * time.Millisecond, MaxBackoff: 10 * time.Second}
+	if got != want {
+		t.Errorf("zenodo override: got %+v, want %+v", got, want)
+	}
+	if got := RetryPolicyFor("figshare"); got.MaxAttempts != defaultRetryAttempts || got.MaxBackoff != 10*time.Second {
+		t.Errorf("figshare must only see [retry] keys, got %+v", got)
+	}
+}
+
+func TestFetch_RetriesTransientStatus(t *testing.T) {
+	var gets int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		if gets < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("payload"))
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "out.bin")
+	p := RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+	fr, err := Fetch(context.Background(), srv.URL, dest, FetchOptions{Client: srv.Client(), Retry: p})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if gets != 3 || fr.N != int64(len("payload")) {
+		t.Errorf("gets=%d n=%d, want 3 attempts and 7 bytes", gets, fr.N)
+	}
+}
+
+func TestFetch_DoesNotRetryClientError(t *testing.T) {
+	var gets int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "out.bin")
+	p := RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond}
+	_, err := Fetch(context.Background(), srv.URL, dest, FetchOptions{Client: srv.Client(), Retry: p})
+	var he *HTTPError
+	if !errors.As(err, &he) || he.StatusCode != http.StatusNotFound {
+		t.Fatalf("err = %v, want *HTTPError 404", err)
+	}
+	if gets != 1 {
+		t.Errorf("gets = %d, want 1", gets)
+	}
+}
+
+func TestRetry_HonoursRetryAfter(t *testing.T) {
+	var gets int
+	var gap time.Duration
+	var last time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		if gets == 1 {
+			last = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		gap = time.Since(last)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "out.bin")
+	p := RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Second}
+	if _, err := Fetch(context.Background(), srv.URL, dest, FetchOptions{Client: srv.Client(), Retry: p}); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if gap < 900*time.Millisecond {
+		t.Errorf("second attempt after %s, want >= Retry-After (1s)", gap)
+	}
+}
+
+func TestRetry_RetryAfterBeyondMaxBackoffStops(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond, MaxBackoff: time.Second}, "x", func() error {
+		calls++
+		return &HTTPError{URL: "x", StatusCode: 503, RetryAfter: time.Hour}
+	})
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+	var he *HTTPError
+	if !errors.As(err, &he) {
+		t.Errorf("err = %v, want wrapped *HTTPError", err)
+	}
+}
+
+func TestJitterBounds(t *testing.T) {
+	for range 100 {
+		if d := jitter(time.Second); d < 500*time.Millisecond || d > time.Second {
+			t.Fatalf("jitter(1s) = %s, want within [500ms, 1s]", d)
+		}
+	}
+}
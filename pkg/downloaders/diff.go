@@ -0,0 +1,162 @@
+package downloaders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// FieldChange is one metadata field whose value differs upstream.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// MetadataDiff describes how a dataset's upstream metadata has changed since
+// it was recorded in a witness file.
+type MetadataDiff struct {
+	Fields       []FieldChange `json:"fields,omitempty"`
+	FilesAdded   []string      `json:"files_added,omitempty"`
+	FilesRemoved []string      `json:"files_removed,omitempty"`
+	FilesChanged []string      `json:"files_changed,omitempty"`
+	// LatestVersion is set when the source reports a newer version of the
+	// record than the one fetched (Custom["latest_version"], e.g. Zenodo).
+	LatestVersion string `json:"latest_version,omitempty"`
+}
+
+// Empty reports whether nothing has changed.
+func (d MetadataDiff) Empty() bool {
+	return len(d.Fields) == 0 && len(d.FilesAdded) == 0 && len(d.FilesRemoved) == 0 &&
+		len(d.FilesChanged) == 0 && d.LatestVersion == ""
+}
+
+// UpdateChecker is an optional capability a Downloader implements when the
+// details `hapiq outdated` compares (such as the modification date) cost
+// requests that GetMetadata does not make.
+type UpdateChecker interface {
+	// CheckMetadata returns GetMetadata's result plus those details.
+	CheckMetadata(ctx context.Context, id string) (*Metadata, error)
+}
+
+// revisionKeys are the Custom entries that change when a record is revised.
+var revisionKeys = []string{"etag", "revision"}
+
+// DiffMetadata compares recorded metadata with a fresh GetMetadata result.
+// Fields missing on either side are not reported, so witnesses written by
+// older versions do not show spurious changes. File lists are compared when
+// both sides carry Custom["files"] (Zenodo, figshare).
+func DiffMetadata(old, cur *Metadata) MetadataDiff {
+	var d MetadataDiff
+	if old == nil || cur == nil {
+		return d
+	}
+	oldC, curC := normalizeCustom(old.Custom), normalizeCustom(cur.Custom)
+
+	add := func(field, a, b string) {
+		if a != "" && b != "" && a != b {
+			d.Fields = append(d.Fields, FieldChange{Field: field, Old: a, New: b})
+		}
+	}
+	add("title", old.Title, cur.Title)
+	add("version", old.Version, cur.Version)
+	add("doi", old.DOI, cur.DOI)
+	add("license", old.License, cur.License)
+	add("last_modified", formatTime(old.LastModified), formatTime(cur.LastModified))
+	add("file_count", formatCount(int64(old.FileCount)), formatCount(int64(cur.FileCount)))
+	add("total_size", formatCount(old.TotalSize), formatCount(cur.TotalSize))
+	for _, k := range revisionKeys {
+		add(k, customString(oldC, k), customString(curC, k))
+	}
+
+	if latest := customString(curC, "latest_version"); latest != "" && latest != cur.ID {
+		d.LatestVersion = latest
+	}
+
+	oldFiles, okOld := fileSignatures(oldC)
+	curFiles, okCur := fileSignatures(curC)
+	if okOld && okCur {
+		for name, sig := range curFiles {
+			if prev, ok := oldFiles[name]; !ok {
+				d.FilesAdded = append(d.FilesAdded, name)
+			} else if prev != sig {
+				d.FilesChanged = append(d.FilesChanged, name)
+			}
+		}
+		for name := range oldFiles {
+			if _, ok := curFiles[name]; !ok {
+				d.FilesRemoved = append(d.FilesRemoved, name)
+			}
+		}
+		sort.Strings(d.FilesAdded)
+		sort.Strings(d.FilesRemoved)
+		sort.Strings(d.FilesChanged)
+	}
+	return d
+}
+
+// normalizeCustom round-trips m through JSON so in-memory values compare
+// equal to those read back from hapiq.json (ints become float64, typed slices
+// become []any).
+func normalizeCustom(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return m
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return m
+	}
+	return out
+}
+
+func customString(m map[string]any, key string) string {
+	if v, ok := m[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// fileSignatures maps each entry of Custom["files"] to its size and digest.
+// Zenodo names files by "key", figshare by "name".
+func fileSignatures(m map[string]any) (map[string]string, bool) {
+	list, ok := m["files"].([]any)
+	if !ok {
+		return nil, false
+	}
+	out := make(map[string]string, len(list))
+	for _, item := range list {
+		f, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		name := customString(f, "key")
+		if name == "" {
+			name = customString(f, "name")
+		}
+		if name == "" {
+			continue
+		}
+		out[name] = customString(f, "size") + " " + customString(f, "checksum") + customString(f, "md5")
+	}
+	return out, true
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatCount(n int64) string {
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,78 @@
+package downloaders
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// roundTrip simulates metadata that was written to and read back from hapiq.json.
+func roundTrip(t *testing.T, m *Metadata) *Metadata {
+	t.Helper()
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out Metadata
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return &out
+}
+
+func TestDiffMetadata_Unchanged(t *testing.T) {
+	m := &Metadata{
+		ID: "123", Title: "t", Version: "1", FileCount: 2, LastModified: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Custom: map[string]any{"revision": 3, "files": []map[string]any{{"key": "a.csv", "size": int64(10), "checksum": "md5:x"}}},
+	}
+	if d := DiffMetadata(roundTrip(t, m), m); !d.Empty() {
+		t.Errorf("diff = %+v, want empty", d)
+	}
+}
+
+func TestDiffMetadata_Changes(t *testing.T) {
+	old := roundTrip(t, &Metadata{
+		ID: "123", Title: "t", Version: "1", LastModified: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Custom: map[string]any{"etag": `"abc"`, "files": []map[string]any{
+			{"key": "a.csv", "size": 10, "checksum": "md5:x"},
+			{"key": "b.csv", "size": 20, "checksum": "md5:y"},
+		}},
+	})
+	cur := &Metadata{
+		ID: "123", Title: "t", Version: "2", LastModified: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		Custom: map[string]any{"etag": `"def"`, "latest_version": "456", "files": []map[string]any{
+			{"key": "a.csv", "size": 11, "checksum": "md5:z"},
+			{"key": "c.csv", "size": 5, "checksum": "md5:w"},
+		}},
+	}
+
+	d := DiffMetadata(old, cur)
+	fields := map[string]FieldChange{}
+	for _, f := range d.Fields {
+		fields[f.Field] = f
+	}
+	if fields["version"].New != "2" || fields["etag"].Old != `"abc"` || fields["last_modified"].New != "2024-06-01T00:00:00Z" {
+		t.Errorf("fields = %+v", d.Fields)
+	}
+	if _, ok := fields["title"]; ok {
+		t.Error("unchanged title reported")
+	}
+	if len(d.FilesAdded) != 1 || d.FilesAdded[0] != "c.csv" ||
+		len(d.FilesRemoved) != 1 || d.FilesRemoved[0] != "b.csv" ||
+		len(d.FilesChanged) != 1 || d.FilesChanged[0] != "a.csv" {
+		t.Errorf("files: added %v, removed %v, changed %v", d.FilesAdded, d.FilesRemoved, d.FilesChanged)
+	}
+	if d.LatestVersion != "456" {
+		t.Errorf("LatestVersion = %q, want 456", d.LatestVersion)
+	}
+}
+
+// TestDiffMetadata_MissingFieldsIgnored guards against witnesses from older
+// releases, which lack fields such as etag, reporting spurious changes.
+func TestDiffMetadata_MissingFieldsIgnored(t *testing.T) {
+	old := &Metadata{ID: "x", Title: "t"}
+	cur := &Metadata{ID: "x", Title: "t", LastModified: time.Now(), Custom: map[string]any{"etag": "e"}}
+	if d := DiffMetadata(old, cur); !d.Empty() {
+		t.Errorf("diff = %+v, want empty", d)
+	}
+}
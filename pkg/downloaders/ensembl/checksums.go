@@ -0,0 +1,90 @@
+package ensembl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	neturl "net/url"
+	"strings"
+	"sync"
+
+	"github.com/btraven00/hapiq/pkg/downloaders/common"
+)
+
+// checksumsIndex caches the parsed CHECKSUMS file of each FTP directory, so
+// species sharing a collection directory fetch it once.
+type checksumsIndex struct {
+	mu   sync.Mutex
+	dirs map[string]*checksumsDir // directory URL → its CHECKSUMS
+}
+
+// checksumsDir is one directory's CHECKSUMS, fetched on first use. Workers
+// asking for other directories are not held up while it downloads.
+type checksumsDir struct {
+	once sync.Once
+	sums map[string]string // file name → "sum blocks"
+}
+
+// lookup returns the BSD sum Ensembl publishes for fileURL in the CHECKSUMS
+// file next to it. The zero Checksum means none is available; a missing or
+// unreadable CHECKSUMS file is not an error.
+func (x *checksumsIndex) lookup(ctx context.Context, client ProtocolClient, fileURL string) common.Checksum {
+	i := strings.LastIndex(fileURL, "/")
+	dir, name := fileURL[:i], fileURL[i+1:]
+
+	x.mu.Lock()
+	entry, ok := x.dirs[dir]
+	if !ok {
+		if x.dirs == nil {
+			x.dirs = map[string]*checksumsDir{}
+		}
+		entry = &checksumsDir{}
+		x.dirs[dir] = entry
+	}
+	x.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.sums, _ = fetchChecksums(ctx, client, dir+"/CHECKSUMS")
+	})
+	if v, ok := entry.sums[name]; ok {
+		return common.Checksum{Type: "sum", Value: v}
+	}
+	return common.Checksum{}
+}
+
+// fetchChecksums downloads and parses a CHECKSUMS file.
+func fetchChecksums(ctx context.Context, client ProtocolClient, url string) (map[string]string, error) {
+	if u, err := neturl.Parse(url); err == nil && u.Scheme == "ftp" {
+		if err := common.WaitHost(ctx, u.Hostname(), false); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := client.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Body == nil {
+		return nil, fmt.Errorf("empty response for %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("Status %d when downloading %s", resp.StatusCode, url)
+	}
+	return parseChecksums(resp.Body)
+}
+
+// parseChecksums parses the output of BSD sum(1) as published by Ensembl:
+// one "<checksum> <1K blocks> <file name>" line per file.
+func parseChecksums(r io.Reader) (map[string]string, error) {
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		sums[fields[2]] = fields[0] + " " + fields[1]
+	}
+	return sums, scanner.Err()
+}
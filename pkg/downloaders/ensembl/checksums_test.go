@@ -0,0 +1,101 @@
+package ensembl
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeProtocolClient serves fixed bodies by URL and counts requests.
+type fakeProtocolClient struct {
+	bodies map[string]string
+	gets   int
+}
+
+func (f *fakeProtocolClient) Head(context.Context, string) (*ProtocolResponse, error) {
+	return &ProtocolResponse{StatusCode: 200}, nil
+}
+
+func (f *fakeProtocolClient) Get(_ context.Context, url string) (*ProtocolResponse, error) {
+	f.gets++
+	body, ok := f.bodies[url]
+	if !ok {
+		return &ProtocolResponse{StatusCode: 404, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+	return &ProtocolResponse{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func (f *fakeProtocolClient) Close() error { return nil }
+
+func TestParseChecksums(t *testing.T) {
+	sums, err := parseChecksums(strings.NewReader("03762 1 a.pep.all.fa.gz\n38142     5 b.pep.all.fa.gz\n\nmalformed line here too\n"))
+	if err != nil {
+		t.Fatalf("parseChecksums: %v", err)
+	}
+	if len(sums) != 2 || sums["a.pep.all.fa.gz"] != "03762 1" || sums["b.pep.all.fa.gz"] != "38142 5" {
+		t.Errorf("sums = %v", sums)
+	}
+}
+
+func TestChecksumsIndex_Lookup(t *testing.T) {
+	client := &fakeProtocolClient{bodies: map[string]string{
+		"https://example.org/pep/CHECKSUMS": "03762 1 A.pep.all.fa.gz\n",
+	}}
+	var x checksumsIndex
+	ctx := context.Background()
+
+	got := x.lookup(ctx, client, "https://example.org/pep/A.pep.all.fa.gz")
+	if got.Type != "sum" || got.Value != "03762 1" {
+		t.Errorf("lookup = %+v", got)
+	}
+	if got := x.lookup(ctx, client, "https://example.org/pep/B.pep.all.fa.gz"); !got.IsZero() {
+		t.Errorf("unlisted file: lookup = %+v, want zero", got)
+	}
+	if got := x.lookup(ctx, client, "https://example.org/cds/A.cds.all.fa.gz"); !got.IsZero() {
+		t.Errorf("no CHECKSUMS: lookup = %+v, want zero", got)
+	}
+	if client.gets != 2 {
+		t.Errorf("gets = %d, want one per directory", client.gets)
+	}
+}
+
+// blockingProtocolClient holds GETs of block until release is closed.
+type blockingProtocolClient struct {
+	fakeProtocolClient
+	block   string
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingProtocolClient) Get(ctx context.Context, url string) (*ProtocolResponse, error) {
+	if url == b.block {
+		close(b.started)
+		<-b.release
+		return &ProtocolResponse{StatusCode: 404, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+	return &ProtocolResponse{StatusCode: 200, Body: io.NopCloser(strings.NewReader("03762 1 A.cds.all.fa.gz\n"))}, nil
+}
+
+func TestChecksumsIndex_SlowDirectoryDoesNotBlockOthers(t *testing.T) {
+	client := &blockingProtocolClient{
+		block:   "https://example.org/pep/CHECKSUMS",
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	var x checksumsIndex
+	ctx := context.Background()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		x.lookup(ctx, client, "https://example.org/pep/A.pep.all.fa.gz")
+	}()
+	<-client.started
+
+	if got := x.lookup(ctx, client, "https://example.org/cds/A.cds.all.fa.gz"); got.Value != "03762 1" {
+		t.Errorf("lookup in another directory = %+v", got)
+	}
+	close(client.release)
+	<-done
+}
@@ -4,8 +4,10 @@ package ensembl
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	neturl "net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -97,22 +99,20 @@ func (d *EnsemblDownloader) downloadEnsemblData(ctx context.Context, req *Ensemb
 	semaphore := make(chan struct{}, maxConcurrent)
 	errChan := make(chan error, len(urls))
 	fileChan := make(chan *downloaders.FileInfo, len(urls))
-
-	// Add rate limiting for FTP downloads
-	var rateLimiter <-chan time.Time
-	if len(urls) > 0 && strings.HasPrefix(urls[0], "ftp://") {
-		// Rate limit FTP downloads to 1 per second to be server-friendly
-		rateLimiter = time.Tick(1 * time.Second)
-	}
+	var checksums checksumsIndex
 
 	for i, url := range urls {
 		go func(idx int, downloadURL string) {
 			semaphore <- struct{}{}        // Acquire semaphore
 			defer func() { <-semaphore }() // Release semaphore
 
-			// Rate limit FTP downloads
-			if rateLimiter != nil {
-				<-rateLimiter
+			// FTP bypasses the HTTP transport, so take the host's token
+			// here (ftp.ensembl.org / ftp.ensemblgenomes.org: 1 req/s).
+			if u, err := neturl.Parse(downloadURL); err == nil && u.Scheme == "ftp" {
+				if err := common.WaitHost(ctx, u.Hostname(), false); err != nil {
+					errChan <- err
+					return
+				}
 			}
 
 			fileName := filepath.Base(downloadURL)
@@ -139,6 +139,15 @@ func (d *EnsemblDownloader) downloadEnsemblData(ctx context.Context, req *Ensemb
 				return
 			}
 
+			// Verify against the CHECKSUMS file Ensembl publishes per directory.
+			if want := checksums.lookup(ctx, d.protoClient, downloadURL); !want.IsZero() {
+				if fileInfo.Verification, err = common.VerifyFile(targetPath, want); err != nil {
+					_ = os.Remove(targetPath)
+					errChan <- err
+					return
+				}
+			}
+
 			fileChan <- fileInfo
 			errChan <- nil
 		}(i, url)
@@ -149,7 +158,10 @@ func (d *EnsemblDownloader) downloadEnsemblData(ctx context.Context, req *Ensemb
 	for i := 0; i < len(urls); i++ {
 		select {
 		case err := <-errChan:
-			if err != nil {
+			var mismatch *common.ChecksumMismatchError
+			if errors.As(err, &mismatch) {
+				result.Errors = append(result.Errors, mismatch.Error())
+			} else if err != nil {
 				downloadErrors = append(downloadErrors, err.Error())
 			}
 		case file := <-fileChan:
@@ -181,10 +193,10 @@ func (d *EnsemblDownloader) downloadEnsemblData(ctx context.Context, req *Ensemb
 			FilesTotal:      len(urls),
 			FilesDownloaded: len(result.Files),
 			FilesSkipped:    0,
-			FilesFailed:     len(downloadErrors),
+			FilesFailed:     len(downloadErrors) + len(result.Errors),
 			AverageSpeed:    0, // Will be calculated later
 			MaxConcurrent:   maxConcurrent,
-			ResumedDownload: false,
+			ResumedDownload: downloaders.AnyResumed(result.Files),
 		},
 		Options: options,
 	}
@@ -413,11 +425,23 @@ func (d *EnsemblDownloader) handleFungiAssembly(species SpeciesInfo, assembly st
 	return assembly
 }
 
-// downloadFileWithProgress downloads a file with progress tracking.
+// downloadFileWithProgress downloads a file with progress tracking, retrying
+// transient failures according to the "ensembl" retry policy.
 // TODO(cache): integrate local cache — Ensembl uses a custom ProtocolClient that
 // supports both HTTP and FTP. Cache integration requires wrapping protoClient.Get
 // with a cache-check/put layer similar to common.Fetch, computing sha256 inline.
 func (d *EnsemblDownloader) downloadFileWithProgress(ctx context.Context, url, targetPath, filename string, size int64, tracker *common.ProgressTracker) (*downloaders.FileInfo, error) {
+	var fileInfo *downloaders.FileInfo
+	err := common.Retry(ctx, common.RetryPolicyFor(d.GetSourceType()), filename, func() error {
+		var err error
+		fileInfo, err = d.downloadFileOnce(ctx, url, targetPath, filename, size, tracker)
+		return err
+	})
+	return fileInfo, err
+}
+
+// downloadFileOnce is a single attempt of downloadFileWithProgress.
+func (d *EnsemblDownloader) downloadFileOnce(ctx context.Context, url, targetPath, filename string, size int64, tracker *common.ProgressTracker) (*downloaders.FileInfo, error) {
 	resp, err := d.protoClient.Get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file: %w", err)
@@ -430,7 +454,7 @@ func (d *EnsemblDownloader) downloadFileWithProgress(ctx context.Context, url, t
 	}
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Status %d when downloading file", resp.StatusCode)
+		return nil, &common.HTTPError{URL: url, StatusCode: resp.StatusCode}
 	}
 
 	if resp.Body == nil {
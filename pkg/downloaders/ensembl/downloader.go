@@ -84,11 +84,12 @@ func WithFTPConcurrency(maxConns int) Option {
 }
 
 // WithRateLimit sets rate limiting for FTP downloads (requests per second).
+//
+// Deprecated: FTP downloads share the process-wide per-host limiter; set
+// [ratelimit.hosts] "ftp.ensembl.org" in the config file instead. This
+// option has no effect.
 func WithRateLimit(rps float64) Option {
-	return func(d *EnsemblDownloader) {
-		// This could be stored and used in download logic
-		// For now, we'll keep the default 1 req/sec
-	}
+	return func(d *EnsemblDownloader) {}
 }
 
 // GetSourceType returns the source type identifier.
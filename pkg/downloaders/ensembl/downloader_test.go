@@ -3,8 +3,14 @@ package ensembl
 
 import (
 	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/spf13/viper"
 )
 
 func TestNewEnsemblDownloader(t *testing.T) {
@@ -449,3 +455,44 @@ func BenchmarkEnsemblDownloader_cleanEnsemblID(b *testing.B) {
 		_ = d.cleanEnsemblID("  ENSEMBL:BACTERIA:47:PEP  ")
 	}
 }
+
+// flakyProtocolClient fails the first failures GETs with a 503.
+type flakyProtocolClient struct {
+	fakeProtocolClient
+	failures int
+}
+
+func (f *flakyProtocolClient) Get(ctx context.Context, url string) (*ProtocolResponse, error) {
+	if f.failures > 0 {
+		f.failures--
+		f.gets++
+		return &ProtocolResponse{StatusCode: 503, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+	return f.fakeProtocolClient.Get(ctx, url)
+}
+
+func TestDownloadFileWithProgress_Retries(t *testing.T) {
+	viper.Set("retry.ensembl.max_attempts", 3)
+	viper.Set("retry.ensembl.initial_backoff", "1ms")
+	t.Cleanup(viper.Reset)
+
+	const url = "https://ftp.ensembl.org/pub/release-110/fasta/x/pep/X.pep.all.fa.gz"
+	client := &flakyProtocolClient{
+		fakeProtocolClient: fakeProtocolClient{bodies: map[string]string{url: ">p1\nMK\n"}},
+		failures:           2,
+	}
+	d := NewEnsemblDownloader()
+	d.protoClient = client
+
+	target := filepath.Join(t.TempDir(), "X.pep.all.fa.gz")
+	info, err := d.downloadFileWithProgress(context.Background(), url, target, "X.pep.all.fa.gz", -1, nil)
+	if err != nil {
+		t.Fatalf("downloadFileWithProgress: %v", err)
+	}
+	if got, _ := os.ReadFile(target); string(got) != ">p1\nMK\n" || info.Size != 7 {
+		t.Errorf("content = %q, size %d", got, info.Size)
+	}
+	if client.gets != 3 {
+		t.Errorf("gets = %d, want 3 (two 503s, then success)", client.gets)
+	}
+}
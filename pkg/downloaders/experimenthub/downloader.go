@@ -251,7 +251,22 @@ func (d *ExperimentHubDownloader) Download(ctx context.Context, req *downloaders
 	return result, nil
 }
 
+// downloadFile fetches url into targetPath, retrying transient failures
+// according to the "experimenthub" retry policy.
 func (d *ExperimentHubDownloader) downloadFile(ctx context.Context, url, targetPath, fallbackName string) (*downloaders.FileInfo, string, error) {
+	var (
+		info *downloaders.FileInfo
+		name string
+	)
+	err := common.Retry(ctx, common.RetryPolicyFor(d.GetSourceType()), url, func() error {
+		var err error
+		info, name, err = d.fetchFile(ctx, url, targetPath, fallbackName)
+		return err
+	})
+	return info, name, err
+}
+
+func (d *ExperimentHubDownloader) fetchFile(ctx context.Context, url, targetPath, fallbackName string) (*downloaders.FileInfo, string, error) {
 	c := cache.FromContext(ctx)
 
 	// Cache hit: materialize the blob to targetPath without a network round-trip.
@@ -284,7 +299,7 @@ func (d *ExperimentHubDownloader) downloadFile(ctx context.Context, url, targetP
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, "", fmt.Errorf("HTTP %d for %s", resp.StatusCode, url)
+		return nil, "", common.NewHTTPError(resp, url)
 	}
 
 	name := fallbackName
@@ -422,7 +437,7 @@ func newHTTPClient(timeout time.Duration) *http.Client {
 	}
 	_ = timeout // honored by per-request context, not as a flat client cap
 	return &http.Client{
-		Transport: tr,
+		Transport: common.NewRateLimitedTransport(tr),
 		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
 			if len(via) > 10 {
 				return fmt.Errorf("too many redirects")
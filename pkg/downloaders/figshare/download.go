@@ -4,6 +4,7 @@ package figshare
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -89,9 +90,14 @@ func (d *FigshareDownloader) downloadArticle(ctx context.Context, id, targetDir
 			progressTracker.StartFile(file.Name, file.Size)
 		}
 
-		fileInfo, err := d.downloadFileWithProgress(ctx, file.DownloadURL, targetPath, file.Name, file.Size, progressTracker)
+		fileInfo, err := d.downloadFileWithProgress(ctx, file.DownloadURL, targetPath, file.Name, file.Size, file.upstreamChecksum(), progressTracker, options != nil && options.Resume)
 		if err != nil {
-			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to download %s: %v", file.Name, err))
+			var mismatch *common.ChecksumMismatchError
+			if errors.As(err, &mismatch) {
+				result.Errors = append(result.Errors, mismatch.Error())
+			} else {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("failed to download %s: %v", file.Name, err))
+			}
 
 			if progressTracker != nil {
 				progressTracker.FailFile(file.Name, err)
@@ -100,16 +106,15 @@ func (d *FigshareDownloader) downloadArticle(ctx context.Context, id, targetDir
 			continue
 		}
 
+		if progressTracker != nil && fileInfo.Resumed {
+			progressTracker.ResumeFile(file.Name)
+		}
+
 		// Use the original filename from Figshare
 		fileInfo.OriginalName = file.Name
 
-		// Verify checksum if available
-		if file.MD5 != "" && fileInfo.Checksum != "" {
-			// Convert SHA256 to MD5 for comparison (simplified - in practice you'd compute MD5)
-			if d.verbose {
-				fmt.Printf("✅ Checksum available for: %s\n", file.Name)
-			}
-			// Note: We could implement MD5 verification here
+		if d.verbose && fileInfo.Verification != nil {
+			fmt.Printf("✅ MD5 verified: %s\n", file.Name)
 		}
 
 		// Make path relative to target directory
@@ -320,14 +325,33 @@ func (d *FigshareDownloader) getArticleFiles(ctx context.Context, articleID int)
 }
 
 // downloadFileWithProgress downloads a file with progress tracking.
-func (d *FigshareDownloader) downloadFileWithProgress(ctx context.Context, url, targetPath, filename string, size int64, tracker *common.ProgressTracker) (*downloaders.FileInfo, error) {
+func (d *FigshareDownloader) downloadFileWithProgress(ctx context.Context, url, targetPath, filename string, size int64, want common.Checksum, tracker *common.ProgressTracker, resume bool) (*downloaders.FileInfo, error) {
 	// Use the existing downloadFile method if no progress tracking needed
 	if tracker == nil {
-		return d.downloadFile(ctx, url, targetPath)
+		return d.downloadFile(ctx, url, targetPath, want, resume)
 	}
 
 	// Download with progress tracking
-	return d.downloadFileWithProgressTracking(ctx, url, targetPath, filename, size, tracker)
+	fi, err := d.downloadFileWithProgressTracking(ctx, url, targetPath, filename, size, tracker)
+	if err != nil || want.IsZero() {
+		return fi, err
+	}
+	if fi.Verification, err = common.VerifyFile(targetPath, want); err != nil {
+		_ = os.Remove(targetPath)
+		return nil, err
+	}
+	return fi, nil
+}
+
+// upstreamChecksum returns the MD5 Figshare publishes for f, preferring the
+// one it computed over the one the depositor supplied.
+func (f FigshareFile) upstreamChecksum() common.Checksum {
+	for _, v := range []string{f.MD5, f.SuppliedMD5} {
+		if v != "" {
+			return common.Checksum{Type: "md5", Value: strings.ToLower(v)}
+		}
+	}
+	return common.Checksum{}
 }
 
 // estimateDownloadSize calculates the total size of files to be downloaded.
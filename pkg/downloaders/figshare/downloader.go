@@ -16,6 +16,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/btraven00/hapiq/pkg/cache"
 	"github.com/btraven00/hapiq/pkg/downloaders"
 	"github.com/btraven00/hapiq/internal/version"
 	"github.com/btraven00/hapiq/pkg/downloaders/common"
@@ -26,6 +27,7 @@ type FigshareDownloader struct {
 	client  *http.Client
 	baseURL string
 	apiURL  string
+	token   string
 	timeout time.Duration
 	verbose bool
 }
@@ -72,6 +74,28 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithToken sets a personal token, giving access to private and
+// ACL-restricted articles the token's owner may read. Empty means anonymous.
+func WithToken(token string) Option {
+	return func(d *FigshareDownloader) {
+		d.token = token
+	}
+}
+
+// HasCredentials reports whether a token is configured.
+func (d *FigshareDownloader) HasCredentials() bool {
+	return d.token != ""
+}
+
+// authorize adds the token to req if it targets figshare itself. The token
+// travels in a header, never the URL, so it cannot leak into witnesses or
+// the cache.
+func (d *FigshareDownloader) authorize(req *http.Request) {
+	if d.token != "" {
+		common.SetAuthorization(req, "token "+d.token, "figshare.com", common.HostOf(d.apiURL))
+	}
+}
+
 // GetSourceType returns the source type identifier.
 func (d *FigshareDownloader) GetSourceType() string {
 	return "figshare"
@@ -334,7 +358,7 @@ func (d *FigshareDownloader) Download(ctx context.Context, req *downloaders.Down
 			FilesFailed:     0,
 			AverageSpeed:    downloaders.Speed(result.BytesDownloaded, result.Duration),
 			MaxConcurrent:   1,
-			ResumedDownload: false,
+			ResumedDownload: downloaders.AnyResumed(result.Files),
 		},
 		Options: req.Options,
 	}
@@ -634,8 +658,12 @@ func (d *FigshareDownloader) confirmCollection(ctx context.Context, collection *
 }
 
 // downloadFile downloads a single file with progress tracking.
-func (d *FigshareDownloader) downloadFile(ctx context.Context, url, targetPath string) (*downloaders.FileInfo, error) {
-	result, err := common.Fetch(ctx, url, targetPath, common.FetchOptions{Client: d.client})
+func (d *FigshareDownloader) downloadFile(ctx context.Context, url, targetPath string, want common.Checksum, resume bool) (*downloaders.FileInfo, error) {
+	var headers map[string]string
+	if d.token != "" && common.HostWithin(url, "figshare.com", common.HostOf(d.apiURL)) {
+		headers = map[string]string{"Authorization": "token " + d.token}
+	}
+	result, err := common.Fetch(ctx, url, targetPath, common.FetchOptions{Client: d.client, ExtraHeaders: headers, Retry: common.RetryPolicyFor(d.GetSourceType()), Freshness: cache.FreshnessFor(d.GetSourceType()), Resume: resume, Expected: want})
 	if err != nil {
 		return nil, fmt.Errorf("fetch %s: %w", filepath.Base(targetPath), err)
 	}
@@ -649,6 +677,8 @@ func (d *FigshareDownloader) downloadFile(ctx context.Context, url, targetPath s
 		SourceURL:    url,
 		ContentType:  result.ContentType,
 		CacheHit:     result.Hit,
+		Resumed:      result.Resumed,
+		Verification: result.Verification,
 	}, nil
 }
 
@@ -658,6 +688,7 @@ func (d *FigshareDownloader) downloadFileWithProgressTracking(ctx context.Contex
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	d.authorize(req)
 
 	resp, err := d.client.Do(req)
 	if err != nil {
@@ -707,31 +738,43 @@ func (d *FigshareDownloader) downloadFileWithProgressTracking(ctx context.Contex
 	}, nil
 }
 
-// apiRequest makes a request to the Figshare API.
+// apiRequest makes a request to the Figshare API. With a token, articles the
+// public endpoint hides (private, embargoed or shared with the token's owner)
+// are retried through the account endpoint.
 func (d *FigshareDownloader) apiRequest(ctx context.Context, endpoint string, result interface{}) error {
+	status, err := d.doAPIRequest(ctx, endpoint, result)
+	if (status == http.StatusNotFound || status == http.StatusForbidden) &&
+		d.token != "" && strings.HasPrefix(endpoint, "articles/") {
+		_, err = d.doAPIRequest(ctx, "account/"+endpoint, result)
+	}
+	return err
+}
+
+func (d *FigshareDownloader) doAPIRequest(ctx context.Context, endpoint string, result interface{}) (int, error) {
 	url := fmt.Sprintf("%s/%s", d.apiURL, endpoint)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, http.NoBody)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	req.Header.Set("Accept", "application/json")
+	d.authorize(req)
 
 	resp, err := d.client.Do(req)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API request failed: HTTP %d", resp.StatusCode)
+		return resp.StatusCode, fmt.Errorf("API request failed: HTTP %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return resp.StatusCode, err
 	}
 
-	return json.Unmarshal(body, result)
+	return resp.StatusCode, json.Unmarshal(body, result)
 }
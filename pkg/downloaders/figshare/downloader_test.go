@@ -638,3 +638,43 @@ func (t *mockFigshareTransport) RoundTrip(req *http.Request) (*http.Response, er
 
 	return http.DefaultTransport.RoundTrip(req)
 }
+
+func TestFigshareDownloader_PrivateArticleWithToken(t *testing.T) {
+	var publicAuth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v2/account/articles/"):
+			if r.Header.Get("Authorization") != "token secret" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id": 42, "title": "Embargoed", "version": 1, "files": []}`))
+		default:
+			publicAuth = append(publicAuth, r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &mockFigshareTransport{server: server}}
+
+	anonymous := NewFigshareDownloader(WithHTTPClient(client))
+	if anonymous.HasCredentials() {
+		t.Error("HasCredentials() = true without a token")
+	}
+	if _, err := anonymous.GetMetadata(context.Background(), "42"); err == nil {
+		t.Error("anonymous GetMetadata() succeeded for a private article")
+	}
+
+	authed := NewFigshareDownloader(WithHTTPClient(client), WithToken("secret"))
+	metadata, err := authed.GetMetadata(context.Background(), "42")
+	if err != nil {
+		t.Fatalf("GetMetadata() with token failed: %v", err)
+	}
+	if metadata.Title != "Embargoed" {
+		t.Errorf("Title = %q, want Embargoed", metadata.Title)
+	}
+	if publicAuth[len(publicAuth)-1] != "token secret" {
+		t.Errorf("public API request carried Authorization %q, want the token", publicAuth[len(publicAuth)-1])
+	}
+}
@@ -13,19 +13,11 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/btraven00/hapiq/pkg/downloaders"
 	"github.com/btraven00/hapiq/pkg/downloaders/common"
 )
 
-// Rate limiting for E-utilities (3 requests per second without API key, 10 with API key).
-var (
-	lastEUtilsRequest         time.Time
-	eUtilsRateLimit           = 400 * time.Millisecond // More conservative: 2.5 requests/second
-	eUtilsRateLimitWithAPIKey = 120 * time.Millisecond // 8 requests/second (conservative 10/sec limit)
-)
-
 // ELinkResponse represents the response from ELink utility for finding related records.
 type ELinkResponse struct {
 	XMLName  xml.Name  `xml:"eLinkResult"`
@@ -274,7 +266,7 @@ func (d *GEODownloader) downloadSample(ctx context.Context, id, targetDir string
 			fmt.Printf("⬇️  Downloading: %s\n", filename)
 		}
 
-		fileInfo, err := d.downloadFileWithRetry(ctx, fileURL, targetPath)
+		fileInfo, err := d.downloadFile(ctx, fileURL, targetPath)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to download %s: %v", filename, err))
 			continue
@@ -310,7 +302,7 @@ func (d *GEODownloader) downloadPlatform(ctx context.Context, id, targetDir stri
 		fmt.Printf("⬇️  Downloading platform annotation: %s\n", filename)
 	}
 
-	fileInfo, err := d.downloadFileWithRetry(ctx, annotationURL, targetPath)
+	fileInfo, err := d.downloadFile(ctx, annotationURL, targetPath)
 	if err != nil {
 		// Try alternative soft format
 		softURL := fmt.Sprintf("%s/platforms/%s/%s/%s.soft.gz", d.ftpBaseURL, d.getGPLSubdir(id), id, id)
@@ -321,7 +313,7 @@ func (d *GEODownloader) downloadPlatform(ctx context.Context, id, targetDir stri
 			fmt.Printf("🔄 Trying alternative SOFT format for platform data\n")
 		}
 
-		fileInfo, err = d.downloadFileWithRetry(ctx, softURL, softTargetPath)
+		fileInfo, err = d.downloadFile(ctx, softURL, softTargetPath)
 		if err != nil {
 			return fmt.Errorf("failed to download platform data: %w", err)
 		}
@@ -356,7 +348,7 @@ func (d *GEODownloader) downloadDataset(ctx context.Context, id, targetDir strin
 		fmt.Printf("⬇️  Downloading dataset: %s\n", filename)
 	}
 
-	fileInfo, err := d.downloadFileWithRetry(ctx, softURL, targetPath)
+	fileInfo, err := d.downloadFile(ctx, softURL, targetPath)
 	if err != nil {
 		return fmt.Errorf("failed to download dataset: %w", err)
 	}
@@ -398,7 +390,7 @@ func (d *GEODownloader) downloadSeriesMetadata(ctx context.Context, id, targetDi
 			fmt.Printf("📄 Downloading metadata: %s\n", filename)
 		}
 
-		fileInfo, err := d.downloadFileWithRetry(ctx, url, targetPath)
+		fileInfo, err := d.downloadFile(ctx, url, targetPath)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to download %s: %v", filename, err))
 			continue
@@ -460,7 +452,7 @@ func (d *GEODownloader) downloadSeriesSupplementary(ctx context.Context, id, tar
 			fmt.Printf("📎 Downloading: %s\n", filename)
 		}
 
-		fileInfo, err := d.downloadFileWithRetry(ctx, fileURL, targetPath)
+		fileInfo, err := d.downloadFile(ctx, fileURL, targetPath)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to download %s: %v", filename, err))
 			continue
@@ -501,9 +493,6 @@ func (d *GEODownloader) extractSamplesFromMatrixFile(ctx context.Context, series
 	// Series matrix URL pattern
 	matrixURL := fmt.Sprintf("%s/series/%s/%s/matrix/%s_series_matrix.txt.gz", d.ftpBaseURL, d.getGSESubdir(seriesID), seriesID, seriesID)
 
-	// Rate limit requests
-	d.rateLimitEUtils()
-
 	req, err := http.NewRequestWithContext(ctx, "GET", matrixURL, http.NoBody)
 	if err != nil {
 		return nil, err
@@ -556,9 +545,6 @@ func (d *GEODownloader) extractSampleIDsFromMatrixContent(content string) []stri
 
 // searchSamplesForSeries searches for samples that belong to a series.
 func (d *GEODownloader) searchSamplesForSeries(ctx context.Context, seriesID string) ([]string, error) {
-	// Rate limit E-utilities requests
-	d.rateLimitEUtils()
-
 	// Search for samples that reference this series
 	searchTerm := fmt.Sprintf("%s[Series Accession]", seriesID)
 
@@ -571,9 +557,6 @@ func (d *GEODownloader) searchSamplesForSeries(ctx context.Context, seriesID str
 
 	searchURL := fmt.Sprintf("https://eutils.ncbi.nlm.nih.gov/entrez/eutils/esearch.fcgi?%s", params.Encode())
 
-	// Apply rate limiting before making request
-	d.rateLimitEUtils()
-
 	content, err := d.makeEUtilsRequest(ctx, searchURL)
 	if err != nil {
 		return nil, err
@@ -677,62 +660,6 @@ func (d *GEODownloader) generateSampleFileURLs(sampleID string, metadata *downlo
 	return urls
 }
 
-// downloadFileWithRetry downloads a file with retry logic for common network issues.
-func (d *GEODownloader) downloadFileWithRetry(ctx context.Context, url, targetPath string) (*downloaders.FileInfo, error) {
-	maxRetries := 3
-
-	var lastErr error
-
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		fileInfo, err := d.downloadFile(ctx, url, targetPath)
-		if err == nil {
-			return fileInfo, nil
-		}
-
-		lastErr = err
-
-		// Check if it's a network error worth retrying
-		if attempt < maxRetries && isRetryableError(err) {
-			if d.verbose {
-				fmt.Printf("🔄 Retry %d/%d for %s: %v\n", attempt, maxRetries, filepath.Base(url), err)
-			}
-
-			// Exponential backoff
-			backoff := time.Duration(attempt) * time.Second
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(backoff):
-				continue
-			}
-		}
-
-		break
-	}
-
-	return nil, lastErr
-}
-
-// isRetryableError determines if an error is worth retrying.
-func isRetryableError(err error) bool {
-	errStr := err.Error()
-	retryablePatterns := []string{
-		"timeout",
-		"connection reset",
-		"temporary failure",
-		"network is unreachable",
-		"no such host",
-	}
-
-	for _, pattern := range retryablePatterns {
-		if strings.Contains(strings.ToLower(errStr), pattern) {
-			return true
-		}
-	}
-
-	return false
-}
-
 // shouldDownloadFile determines if a file should be downloaded based on options.
 func (d *GEODownloader) shouldDownloadFile(filename string, options *downloaders.DownloadOptions) bool {
 	return downloaders.ShouldDownload(filename, -1, options)
@@ -806,29 +733,8 @@ func (d *GEODownloader) getGDSSubdir(gdsID string) string {
 	return "GDS" + prefix + "nnn"
 }
 
-// rateLimitEUtils implements rate limiting for E-utilities requests.
-func (d *GEODownloader) rateLimitEUtils() {
-	now := time.Now()
-	elapsed := now.Sub(lastEUtilsRequest)
-
-	// Use different rate limits based on API key availability
-	rateLimit := eUtilsRateLimit
-	if d.apiKey != "" {
-		rateLimit = eUtilsRateLimitWithAPIKey
-	}
-
-	if elapsed < rateLimit {
-		time.Sleep(rateLimit - elapsed)
-	}
-
-	lastEUtilsRequest = time.Now()
-}
-
 // sampleHasFiles checks if a sample has individual supplementary files.
 func (d *GEODownloader) sampleHasFiles(ctx context.Context, sampleID string) bool {
-	// Rate limit to avoid overwhelming the server
-	d.rateLimitEUtils()
-
 	sampleURL := fmt.Sprintf("%s/samples/%s/%s/", d.ftpBaseURL, d.getGSMSubdir(sampleID), sampleID)
 
 	req, err := http.NewRequestWithContext(ctx, "HEAD", sampleURL, http.NoBody)
@@ -848,9 +754,6 @@ func (d *GEODownloader) sampleHasFiles(ctx context.Context, sampleID string) boo
 }
 
 func (d *GEODownloader) fetchPageContent(ctx context.Context, url string) ([]byte, error) {
-	// Rate limit requests
-	d.rateLimitEUtils()
-
 	req, err := http.NewRequestWithContext(ctx, "GET", url, http.NoBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -876,9 +779,6 @@ func (d *GEODownloader) fetchPageContent(ctx context.Context, url string) ([]byt
 
 // getDirectoryListing attempts to parse an FTP directory listing to get filenames.
 func (d *GEODownloader) getDirectoryListing(ctx context.Context, dirURL string) ([]string, error) {
-	// Rate limit requests
-	d.rateLimitEUtils()
-
 	req, err := http.NewRequestWithContext(ctx, "GET", dirURL, http.NoBody)
 	if err != nil {
 		return nil, err
@@ -35,7 +35,9 @@ type GEODownloader struct {
 // NewGEODownloader creates a new GEO downloader.
 func NewGEODownloader(options ...Option) *GEODownloader {
 	d := &GEODownloader{
-		client:     &http.Client{Timeout: 30 * time.Second},
+		// E-utilities are rate limited per host (3 req/s, 10 with an API
+		// key); see common.WaitHost.
+		client:     &http.Client{Timeout: 30 * time.Second, Transport: common.NewRateLimitedTransport(nil)},
 		baseURL:    "https://www.ncbi.nlm.nih.gov/geo",
 		ftpBaseURL: "https://ftp.ncbi.nlm.nih.gov/geo",
 		timeout:    30 * time.Second,
@@ -87,6 +89,11 @@ func (d *GEODownloader) GetSourceType() string {
 	return "geo"
 }
 
+// HasCredentials reports whether an NCBI API key is configured.
+func (d *GEODownloader) HasCredentials() bool {
+	return d.apiKey != ""
+}
+
 // Validate checks if the ID is a valid GEO accession.
 func (d *GEODownloader) Validate(ctx context.Context, id string) (*downloaders.ValidationResult, error) {
 	result := &downloaders.ValidationResult{
@@ -394,6 +401,10 @@ func (d *GEODownloader) Download(ctx context.Context, req *downloaders.DownloadR
 		result.Success = false
 	}
 
+	// Record the series' last update date so `hapiq outdated` has a
+	// baseline to compare against.
+	d.addLastUpdate(ctx, metadata)
+
 	// Create witness file
 	witness := &downloaders.WitnessFile{
 		HapiqVersion: version.String(),
@@ -414,7 +425,7 @@ func (d *GEODownloader) Download(ctx context.Context, req *downloaders.DownloadR
 			FilesFailed:     0,
 			AverageSpeed:    downloaders.Speed(result.BytesDownloaded, result.Duration),
 			MaxConcurrent:   1,
-			ResumedDownload: false,
+			ResumedDownload: downloaders.AnyResumed(result.Files),
 		},
 		Options: req.Options,
 	}
@@ -499,9 +510,16 @@ func (d *GEODownloader) confirmCollection(ctx context.Context, collection *downl
 	return common.AskUserConfirmation("Continue with download?")
 }
 
-// downloadFile downloads a single file with progress tracking.
+// downloadFile downloads a single file, retrying transient failures
+// according to the "geo" retry policy.
 func (d *GEODownloader) downloadFile(ctx context.Context, url, targetPath string) (*downloaders.FileInfo, error) {
-	return d.downloadFileWithProgress(ctx, url, targetPath, filepath.Base(targetPath), -1, nil)
+	var fileInfo *downloaders.FileInfo
+	err := common.Retry(ctx, common.RetryPolicyFor(d.GetSourceType()), filepath.Base(url), func() error {
+		var err error
+		fileInfo, err = d.downloadFileWithProgress(ctx, url, targetPath, filepath.Base(targetPath), -1, nil)
+		return err
+	})
+	return fileInfo, err
 }
 
 // downloadFileWithProgress downloads a file with optional progress tracking.
@@ -539,7 +557,7 @@ func (d *GEODownloader) downloadFileWithProgress(ctx context.Context, url, targe
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return nil, common.NewHTTPError(resp, url)
 	}
 
 	if size <= 0 && resp.ContentLength > 0 {
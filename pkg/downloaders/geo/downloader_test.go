@@ -319,8 +319,8 @@ type mockEUtilsTransport struct {
 }
 
 func (t *mockEUtilsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// Redirect E-utilities calls to our mock server
-	if strings.Contains(req.URL.Host, "eutils.ncbi.nlm.nih.gov") {
+	// Redirect E-utilities and GEO page calls to our mock server
+	if strings.HasSuffix(req.URL.Host, "ncbi.nlm.nih.gov") {
 		// Parse the mock server URL to get host and port
 		mockURL := strings.TrimPrefix(t.server.URL, "http://")
 		req.URL.Scheme = "http"
@@ -330,6 +330,46 @@ func (t *mockEUtilsTransport) RoundTrip(req *http.Request) (*http.Response, erro
 	return http.DefaultTransport.RoundTrip(req)
 }
 
+// TestGEODownloader_CheckMetadata_LastUpdate checks that the series' last
+// update date is fetched for update checks only, not by GetMetadata.
+func TestGEODownloader_CheckMetadata_LastUpdate(t *testing.T) {
+	var softHits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "esearch"):
+			_, _ = w.Write([]byte(`<eSearchResult><Count>1</Count><IdList><Id>200123456</Id></IdList></eSearchResult>`))
+		case strings.Contains(r.URL.Path, "esummary"):
+			_, _ = w.Write([]byte(`<eSummaryResult><DocSum><Id>200123456</Id><Item Name="title" Type="String">T</Item></DocSum></eSummaryResult>`))
+		case strings.Contains(r.URL.Path, "acc.cgi"):
+			softHits++
+			_, _ = w.Write([]byte("^SERIES = GSE123456\n!Series_last_update_date = Jan 05 2021\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &mockEUtilsTransport{server: server}, Timeout: 30 * time.Second}
+	downloader := NewGEODownloader(WithHTTPClient(client))
+	ctx := context.Background()
+
+	metadata, err := downloader.GetMetadata(ctx, "GSE123456")
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if softHits != 0 || !metadata.LastModified.IsZero() {
+		t.Errorf("GetMetadata fetched the SOFT record (%d requests, last modified %v)", softHits, metadata.LastModified)
+	}
+
+	metadata, err = downloader.CheckMetadata(ctx, "GSE123456")
+	if err != nil {
+		t.Fatalf("CheckMetadata: %v", err)
+	}
+	if want := time.Date(2021, 1, 5, 0, 0, 0, 0, time.UTC); softHits != 1 || !metadata.LastModified.Equal(want) {
+		t.Errorf("CheckMetadata: %d SOFT requests, last modified %v; want 1, %v", softHits, metadata.LastModified, want)
+	}
+}
+
 func TestGEODownloader_parseEUtilsDate(t *testing.T) {
 	downloader := NewGEODownloader()
 
@@ -553,38 +593,3 @@ func TestGEODownloader_shouldDownloadFile(t *testing.T) {
 		})
 	}
 }
-
-func TestGEODownloader_isRetryableError(t *testing.T) {
-	tests := []struct {
-		errorMsg string
-		expected bool
-	}{
-		{"connection timeout", true},
-		{"connection reset by peer", true},
-		{"temporary failure", true},
-		{"network is unreachable", true},
-		{"no such host", true},
-		{"file not found", false},
-		{"permission denied", false},
-		{"invalid format", false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.errorMsg, func(t *testing.T) {
-			err := &testError{msg: tt.errorMsg}
-			result := isRetryableError(err)
-			if result != tt.expected {
-				t.Fatalf("isRetryableError('%s') = %t, expected %t", tt.errorMsg, result, tt.expected)
-			}
-		})
-	}
-}
-
-// testError implements error interface for testing.
-type testError struct {
-	msg string
-}
-
-func (e *testError) Error() string {
-	return e.msg
-}
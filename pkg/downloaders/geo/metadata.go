@@ -148,6 +148,47 @@ func (d *GEODownloader) getSeriesMetadata(ctx context.Context, id string) (*down
 	return metadata, nil
 }
 
+// CheckMetadata implements downloaders.UpdateChecker: GetMetadata plus, for
+// a series, the last update date, which ESummary lacks and only the brief
+// SOFT record has.
+func (d *GEODownloader) CheckMetadata(ctx context.Context, id string) (*downloaders.Metadata, error) {
+	metadata, err := d.GetMetadata(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	d.addLastUpdate(ctx, metadata)
+	return metadata, nil
+}
+
+// addLastUpdate sets metadata.LastModified for a series if it is unset.
+// Failures are ignored: the date only feeds `hapiq outdated`.
+func (d *GEODownloader) addLastUpdate(ctx context.Context, metadata *downloaders.Metadata) {
+	if !strings.HasPrefix(metadata.ID, "GSE") || !metadata.LastModified.IsZero() {
+		return
+	}
+	if updated, err := d.seriesLastUpdate(ctx, metadata.ID); err == nil {
+		metadata.LastModified = updated
+	}
+}
+
+// seriesLastUpdate reads !Series_last_update_date from the brief SOFT text of
+// a series, e.g. "!Series_last_update_date = Jan 05 2021".
+func (d *GEODownloader) seriesLastUpdate(ctx context.Context, gse string) (time.Time, error) {
+	pageURL := fmt.Sprintf("%s/query/acc.cgi?acc=%s&targ=self&form=text&view=brief", d.baseURL, gse)
+	content, err := d.makeEUtilsRequest(ctx, pageURL)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) != "!Series_last_update_date" {
+			continue
+		}
+		return time.Parse("Jan 02 2006", strings.TrimSpace(value))
+	}
+	return time.Time{}, fmt.Errorf("no last update date for %s", gse)
+}
+
 // getSampleMetadata retrieves metadata for a GEO Sample (GSM) using E-utilities.
 func (d *GEODownloader) getSampleMetadata(ctx context.Context, id string) (*downloaders.Metadata, error) {
 	// Search for the GSM to get the UID
@@ -337,9 +378,6 @@ func (d *GEODownloader) searchGEORecord(ctx context.Context, accession string) (
 
 	searchURL := fmt.Sprintf("https://eutils.ncbi.nlm.nih.gov/entrez/eutils/esearch.fcgi?%s", params.Encode())
 
-	// Apply rate limiting before making request
-	d.rateLimitEUtils()
-
 	// Make request
 	content, err := d.makeEUtilsRequest(ctx, searchURL)
 	if err != nil {
@@ -376,9 +414,6 @@ func (d *GEODownloader) getSummary(ctx context.Context, database, uid string) (*
 
 	summaryURL := fmt.Sprintf("https://eutils.ncbi.nlm.nih.gov/entrez/eutils/esummary.fcgi?%s", params.Encode())
 
-	// Apply rate limiting before making request
-	d.rateLimitEUtils()
-
 	// Make request
 	content, err := d.makeEUtilsRequest(ctx, summaryURL)
 	if err != nil {
@@ -411,9 +446,6 @@ func (d *GEODownloader) getRelatedSamples(ctx context.Context, uid string) ([]st
 
 	linkURL := fmt.Sprintf("https://eutils.ncbi.nlm.nih.gov/entrez/eutils/elink.fcgi?%s", params.Encode())
 
-	// Apply rate limiting before making request
-	d.rateLimitEUtils()
-
 	// Make request
 	_, err := d.makeEUtilsRequest(ctx, linkURL)
 	if err != nil {
@@ -438,9 +470,6 @@ func (d *GEODownloader) getLinkedPubMed(ctx context.Context, uid string) ([]stri
 
 	linkURL := fmt.Sprintf("https://eutils.ncbi.nlm.nih.gov/entrez/eutils/elink.fcgi?%s", params.Encode())
 
-	// Apply rate limiting before making request
-	d.rateLimitEUtils()
-
 	// Make request
 	_, err := d.makeEUtilsRequest(ctx, linkURL)
 	if err != nil {
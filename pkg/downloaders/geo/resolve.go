@@ -98,8 +98,6 @@ func (d *GEODownloader) extractSRPFromGEOPage(ctx context.Context, gseAccession
 		gseAccession,
 	)
 
-	d.rateLimitEUtils()
-
 	content, err := d.makeEUtilsRequest(ctx, pageURL)
 	if err != nil {
 		return "", err
@@ -130,8 +128,6 @@ func (d *GEODownloader) searchSRAByStudy(ctx context.Context, studyAccession str
 
 	searchURL := "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/esearch.fcgi?" + params.Encode()
 
-	d.rateLimitEUtils()
-
 	content, err := d.makeEUtilsRequest(ctx, searchURL)
 	if err != nil {
 		return nil, err
@@ -187,8 +183,6 @@ func (d *GEODownloader) searchDB(ctx context.Context, db, term string) (string,
 
 	searchURL := "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/esearch.fcgi?" + params.Encode()
 
-	d.rateLimitEUtils()
-
 	content, err := d.makeEUtilsRequest(ctx, searchURL)
 	if err != nil {
 		return "", err
@@ -217,8 +211,6 @@ func (d *GEODownloader) elink(ctx context.Context, dbfrom, dbto, id string) ([]s
 
 	linkURL := "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/elink.fcgi?" + params.Encode()
 
-	d.rateLimitEUtils()
-
 	content, err := d.makeEUtilsRequest(ctx, linkURL)
 	if err != nil {
 		return nil, err
@@ -274,8 +266,6 @@ func (d *GEODownloader) fetchSRARunsBatch(ctx context.Context, uids []string) ([
 
 	summaryURL := "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/esummary.fcgi?" + params.Encode()
 
-	d.rateLimitEUtils()
-
 	content, err := d.makeEUtilsRequest(ctx, summaryURL)
 	if err != nil {
 		return nil, err
@@ -86,8 +86,6 @@ func (d *GEODownloader) esearch(ctx context.Context, query string, retmax int) (
 
 	searchURL := "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/esearch.fcgi?" + params.Encode()
 
-	d.rateLimitEUtils()
-
 	content, err := d.makeEUtilsRequest(ctx, searchURL)
 	if err != nil {
 		return nil, 0, err
@@ -113,8 +111,6 @@ func (d *GEODownloader) getBatchSummaries(ctx context.Context, uids []string) ([
 
 	summaryURL := "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/esummary.fcgi?" + params.Encode()
 
-	d.rateLimitEUtils()
-
 	content, err := d.makeEUtilsRequest(ctx, summaryURL)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,327 @@
+// Package gsa downloads raw sequencing reads from the Genome Sequence Archive
+// (GSA) at China's National Genomics Data Center (NGDC,
+// https://ngdc.cncb.ac.cn/gsa). File lists and MD5s come from the GSA run
+// info export (gsa/search/getRunInfo, as used by iSeq); files are fetched
+// over HTTPS from download.cncb.ac.cn.
+//
+// Supported input accessions:
+//   - PRJCA*        – NGDC BioProject (all runs in the project)
+//   - CRA*, HRA*    – GSA / GSA-Human studies (all runs)
+//   - CRX*, HRX*    – experiments (→ all runs)
+//   - CRR*, HRR*    – individual runs
+//
+// Files land in one directory per run, as with the SRA downloader. GSA-Human
+// runs are controlled access; the run info lists them without a download
+// URL and they are reported as warnings.
+package gsa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btraven00/hapiq/internal/version"
+	"github.com/btraven00/hapiq/pkg/cache"
+	"github.com/btraven00/hapiq/pkg/downloaders"
+	"github.com/btraven00/hapiq/pkg/downloaders/common"
+	"github.com/btraven00/hapiq/pkg/validators/domains/bio/accessions"
+)
+
+// GSADownloader implements Downloader for NGDC GSA datasets.
+type GSADownloader struct {
+	client  *http.Client
+	baseURL string // overrides apiBase; used in tests
+	timeout time.Duration
+	verbose bool
+}
+
+// Option configures the GSADownloader.
+type Option func(*GSADownloader)
+
+// WithTimeout sets the HTTP timeout.
+func WithTimeout(t time.Duration) Option {
+	return func(d *GSADownloader) {
+		d.timeout = t
+		d.client.Timeout = t
+	}
+}
+
+// WithVerbose enables verbose output.
+func WithVerbose(v bool) Option {
+	return func(d *GSADownloader) { d.verbose = v }
+}
+
+// WithBaseURL overrides the GSA web application base URL (apiBase).
+// Intended for tests.
+func WithBaseURL(u string) Option {
+	return func(d *GSADownloader) { d.baseURL = strings.TrimRight(u, "/") }
+}
+
+// NewGSADownloader creates a new GSADownloader.
+func NewGSADownloader(opts ...Option) *GSADownloader {
+	d := &GSADownloader{
+		client:  &http.Client{Timeout: 60 * time.Second},
+		timeout: 60 * time.Second,
+	}
+	for _, o := range opts {
+		o(d)
+	}
+	return d
+}
+
+// GetSourceType returns the source type identifier.
+func (d *GSADownloader) GetSourceType() string { return "gsa" }
+
+// Validate checks that id is a GSA project, study, experiment or run.
+// Samples (SAMC*) are not accepted: run info is resolved from the project
+// hierarchy, not from BioSamples.
+func (d *GSADownloader) Validate(_ context.Context, id string) (*downloaders.ValidationResult, error) {
+	acc, ok := accessions.Classify(id)
+	result := &downloaders.ValidationResult{
+		ID:         accessions.Canonicalize(id),
+		SourceType: d.GetSourceType(),
+		Valid:      ok && acc.Database == accessions.DatabaseGSA,
+	}
+	if !result.Valid {
+		result.Errors = []string{fmt.Sprintf("unrecognized GSA accession format: %q (expected PRJCA, CRA, CRX or CRR)", id)}
+	}
+	return result, nil
+}
+
+// GetMetadata fetches run-level metadata from the GSA run info export.
+func (d *GSADownloader) GetMetadata(ctx context.Context, id string) (*downloaders.Metadata, error) {
+	clean := accessions.Canonicalize(id)
+	report, err := d.fetchRunInfo(ctx, clean)
+	if err != nil {
+		return nil, err
+	}
+	if len(report.Runs) == 0 {
+		return nil, fmt.Errorf("no runs found for %s in GSA", clean)
+	}
+
+	var totalBytes int64
+	fileCount := 0
+	for _, r := range report.Runs {
+		for _, f := range r.Files {
+			totalBytes += f.Bytes
+			fileCount++
+		}
+	}
+
+	meta := &downloaders.Metadata{
+		Source:    d.GetSourceType(),
+		ID:        clean,
+		Title:     report.Title,
+		FileCount: fileCount,
+		TotalSize: totalBytes,
+		Custom:    map[string]any{"runs": report.Runs},
+	}
+	if meta.Title == "" {
+		meta.Title = fmt.Sprintf("%d GSA run(s) for %s", len(report.Runs), clean)
+	}
+	return meta, nil
+}
+
+// Download fetches the run files with MD5 verification.
+func (d *GSADownloader) Download(ctx context.Context, req *downloaders.DownloadRequest) (*downloaders.DownloadResult, error) {
+	start := time.Now()
+	result := &downloaders.DownloadResult{Files: []downloaders.FileInfo{}}
+
+	id := accessions.Canonicalize(req.ID)
+	report, err := d.fetchRunInfo(ctx, id)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result, nil
+	}
+	if len(report.Runs) == 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("no runs found for %s in GSA", id))
+		result.Success = true
+		return result, nil
+	}
+
+	opts := req.Options
+
+	type pendingFile struct {
+		run  RunInfo
+		file GSAFile
+	}
+	var pending []pendingFile
+	for _, run := range report.Runs {
+		if len(run.Files) == 0 {
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("%s has no public download URL (controlled access?)", run.RunAccession))
+		}
+		for _, f := range run.Files {
+			if f.URL == "" {
+				result.Warnings = append(result.Warnings,
+					fmt.Sprintf("%s/%s has no public download URL (controlled access?)", run.RunAccession, f.FileName()))
+				continue
+			}
+			if opts == nil || downloaders.ShouldDownload(f.FileName(), f.Bytes, opts) {
+				pending = append(pending, pendingFile{run, f})
+			}
+		}
+	}
+	if opts != nil && opts.LimitFiles > 0 && len(pending) > opts.LimitFiles {
+		if d.verbose {
+			fmt.Printf("ℹ️  --limit-files %d: downloading %d of %d files\n",
+				opts.LimitFiles, opts.LimitFiles, len(pending))
+		}
+		pending = pending[:opts.LimitFiles]
+	}
+
+	// Dry-run: enumerate without downloading.
+	if opts != nil && opts.DryRun {
+		for _, p := range pending {
+			result.Files = append(result.Files, downloaders.FileInfo{
+				OriginalName: p.file.FileName(),
+				SourceURL:    p.file.URL,
+				Size:         p.file.Bytes,
+				Checksum:     p.file.MD5,
+				ChecksumType: "md5",
+			})
+		}
+		result.Success = true
+		return result, nil
+	}
+
+	if err := common.EnsureDirectory(req.OutputDir); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result, nil
+	}
+
+	concurrency := 2 // conservative default for large FASTQ files
+	if opts != nil && opts.MaxConcurrent > 0 {
+		concurrency = opts.MaxConcurrent
+	}
+
+	type dlResult struct {
+		fi  *downloaders.FileInfo
+		err error
+		msg string
+	}
+
+	sem := make(chan struct{}, concurrency)
+	dlResults := make(chan dlResult, len(pending))
+	var wg sync.WaitGroup
+
+	for _, p := range pending {
+		p := p
+		runDir := filepath.Join(req.OutputDir, p.run.RunAccession)
+		if err := common.EnsureDirectory(runDir); err != nil {
+			dlResults <- dlResult{err: err, msg: fmt.Sprintf("mkdir %s", runDir)}
+			continue
+		}
+
+		targetPath := filepath.Join(runDir, p.file.FileName())
+
+		if opts != nil && opts.SkipExisting {
+			if _, err := os.Stat(targetPath); err == nil {
+				if d.verbose {
+					fmt.Printf("⏭️  Skipping existing: %s\n", p.file.FileName())
+				}
+				continue
+			}
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if d.verbose {
+				fmt.Printf("⬇️  %s (%s)\n", p.file.FileName(), common.FormatBytes(p.file.Bytes))
+			}
+
+			fi, err := d.downloadWithMD5(ctx, p.file.URL, targetPath, p.file.MD5, opts != nil && opts.Resume)
+			if err != nil {
+				dlResults <- dlResult{err: err, msg: p.file.FileName()}
+			} else {
+				dlResults <- dlResult{fi: fi}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(dlResults)
+	}()
+
+	for r := range dlResults {
+		var mismatch *common.ChecksumMismatchError
+		if errors.As(r.err, &mismatch) {
+			result.Errors = append(result.Errors, mismatch.Error())
+		} else if r.err != nil {
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("failed to download %s: %v", r.msg, r.err))
+		} else if r.fi != nil {
+			result.Files = append(result.Files, *r.fi)
+			result.BytesDownloaded += r.fi.Size
+		}
+	}
+
+	result.Duration = time.Since(start)
+	result.BytesTotal = result.BytesDownloaded
+	result.Success = len(result.Errors) == 0
+
+	if req.Metadata != nil {
+		witness := &downloaders.WitnessFile{
+			HapiqVersion:  version.String(),
+			DownloadTime:  start,
+			Source:        d.GetSourceType(),
+			OriginalID:    req.ID,
+			Metadata:      req.Metadata,
+			Files:         make([]downloaders.FileWitness, len(result.Files)),
+			DownloadStats: &downloaders.DownloadStats{Duration: result.Duration, BytesDownloaded: result.BytesDownloaded},
+			Options:       req.Options,
+		}
+		for i, f := range result.Files {
+			witness.Files[i] = downloaders.FileWitness(f)
+		}
+		if err := common.WriteWitnessFile(req.OutputDir, witness); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("witness file: %v", err))
+		} else {
+			result.WitnessFile = filepath.Join(req.OutputDir, "hapiq.json")
+		}
+	}
+
+	return result, nil
+}
+
+// downloadWithMD5 fetches url to targetPath through common.Fetch, which
+// verifies the GSA-provided MD5 (evicting and re-fetching a bad cached blob).
+func (d *GSADownloader) downloadWithMD5(ctx context.Context, url, targetPath, expectedMD5 string, resume bool) (*downloaders.FileInfo, error) {
+	expectedMD5 = strings.ToLower(expectedMD5)
+	fetchOpts := common.FetchOptions{Client: d.client, Retry: common.RetryPolicyFor(d.GetSourceType()), Freshness: cache.FreshnessFor(d.GetSourceType()), Resume: resume}
+	if expectedMD5 != "" {
+		fetchOpts.Expected = common.Checksum{Type: "md5", Value: expectedMD5}
+	}
+	fr, err := common.Fetch(ctx, url, targetPath, fetchOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	fi := &downloaders.FileInfo{
+		Path:         targetPath,
+		OriginalName: filepath.Base(targetPath),
+		Size:         fr.N,
+		Checksum:     fr.SHA256,
+		ChecksumType: "sha256",
+		SourceURL:    url,
+		DownloadTime: time.Now(),
+		CacheHit:     fr.Hit,
+		Resumed:      fr.Resumed,
+		Verification: fr.Verification,
+	}
+	if expectedMD5 != "" {
+		fi.Checksum, fi.ChecksumType = expectedMD5, "md5"
+	}
+	return fi, nil
+}
@@ -0,0 +1,205 @@
+package gsa
+
+import (
+	"context"
+	"crypto/md5" // #nosec G501 -- matches GSA-provided checksum format
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/btraven00/hapiq/pkg/downloaders"
+)
+
+func md5Hex(b []byte) string {
+	sum := md5.Sum(b) // #nosec G401 -- test fixture md5
+	return hex.EncodeToString(sum[:])
+}
+
+// newFakeGSAServer serves the GSA search page and getRunInfo CSV for
+// CRA000001 with one paired run and one controlled-access run, plus the run
+// files under /gsa/.
+func newFakeGSAServer(t *testing.T, files map[string][]byte, badMD5 string) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/search":
+			if r.URL.Query().Get("searchTerm") != "CRA000001" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = io.WriteString(w, `<form><input type="hidden" id="totalDatas" value="2"/></form>`)
+		case r.URL.Path == "/search/getRunInfo" && r.Method == http.MethodPost:
+			if r.FormValue("searchTerm") != "CRA000001" || r.FormValue("totalDatas") != "2" {
+				t.Errorf("getRunInfo form = %v", r.Form)
+			}
+			cw := csv.NewWriter(w)
+			_ = cw.Write([]string{"Run", "Experiment", "Accession", "BioSample", "Layout", "FileSize1", "DownLoad1", "MD5_1", "FileSize2", "DownLoad2", "MD5_2"})
+			row := []string{"CRR000001", "CRX000001", "CRA000001", "SAMC000001", "PAIRED"}
+			for _, name := range []string{"CRR000001_f1.fq.gz", "CRR000001_r2.fq.gz"} {
+				sum := md5Hex(files[name])
+				if name == badMD5 {
+					sum = strings.Repeat("0", 32)
+				}
+				row = append(row, strconv.Itoa(len(files[name])), srv.URL+"/gsa/CRA000001/CRR000001/"+name, strings.ToUpper(sum))
+			}
+			_ = cw.Write(row)
+			_ = cw.Write([]string{"CRR000002", "CRX000002", "CRA000001", "SAMC000002", "SINGLE", "10", "", "", "", "", ""})
+			cw.Flush()
+		case strings.HasPrefix(r.URL.Path, "/gsa/"):
+			_, _ = w.Write(files[filepath.Base(r.URL.Path)])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestParseRunInfo(t *testing.T) {
+	in := "Run,Experiment,Layout,DownLoad1,MD5_1\n" +
+		"CRR000010,CRX000010,SINGLE,ftp://download.big.ac.cn/gsa/CRA000010/CRR000010/CRR000010.fq.gz,0123456789abcdef0123456789abcdef\n" +
+		"not a run,,,,\n"
+	runs, err := parseRunInfo(strings.NewReader(in))
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("parseRunInfo = %+v, %v", runs, err)
+	}
+	r := runs[0]
+	if r.RunAccession != "CRR000010" || r.ExperimentAccession != "CRX000010" || r.Layout != "SINGLE" || len(r.Files) != 1 {
+		t.Fatalf("run = %+v", r)
+	}
+	f := r.Files[0]
+	if f.URL != "https://download.cncb.ac.cn/gsa/CRA000010/CRR000010/CRR000010.fq.gz" {
+		t.Errorf("URL = %s, want the HTTPS mirror", f.URL)
+	}
+	if f.FileName() != "CRR000010.fq.gz" || f.MD5 != "0123456789abcdef0123456789abcdef" {
+		t.Errorf("file = %+v", f)
+	}
+}
+
+// TestFetchRunInfo_Integration queries the live GSA run info for a small
+// public study. Skipped with -short.
+func TestFetchRunInfo_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping network test in -short mode")
+	}
+	d := NewGSADownloader()
+	report, err := d.fetchRunInfo(context.Background(), "CRA000001")
+	if err != nil {
+		t.Fatalf("fetchRunInfo: %v", err)
+	}
+	if len(report.Runs) == 0 {
+		t.Fatal("no runs for CRA000001")
+	}
+	for _, r := range report.Runs {
+		if !runAccRE.MatchString(r.RunAccession) {
+			t.Errorf("run accession %q", r.RunAccession)
+		}
+		for _, f := range r.Files {
+			if !strings.HasPrefix(f.URL, "https://") || f.FileName() == "" {
+				t.Errorf("%s: file %+v", r.RunAccession, f)
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	d := NewGSADownloader()
+	for _, id := range []string{"PRJCA000001", "CRA000001", "crx000001", "CRR000001", "HRA000001"} {
+		res, err := d.Validate(context.Background(), id)
+		if err != nil || !res.Valid {
+			t.Errorf("Validate(%q) = %+v, %v; want valid", id, res, err)
+		}
+	}
+	for _, id := range []string{"SRR000001", "PRJNA1", "SAMC000001", "GSE1"} {
+		if res, _ := d.Validate(context.Background(), id); res.Valid {
+			t.Errorf("Validate(%q) valid; want rejected", id)
+		}
+	}
+}
+
+func TestDownload_PerRunLayoutAndWitness(t *testing.T) {
+	files := map[string][]byte{
+		"CRR000001_f1.fq.gz": []byte("forward reads"),
+		"CRR000001_r2.fq.gz": []byte("reverse reads"),
+	}
+	srv := newFakeGSAServer(t, files, "")
+	d := NewGSADownloader(WithBaseURL(srv.URL))
+	ctx := context.Background()
+
+	meta, err := d.GetMetadata(ctx, "CRA000001")
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if meta.FileCount != 2 || meta.TotalSize != int64(len("forward reads")+len("reverse reads")) {
+		t.Errorf("metadata = %+v", meta)
+	}
+
+	out := t.TempDir()
+	res, err := d.Download(ctx, &downloaders.DownloadRequest{ID: "CRA000001", OutputDir: out, Metadata: meta})
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if !res.Success || len(res.Files) != 2 {
+		t.Fatalf("result = %+v", res)
+	}
+	if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0], "CRR000002") {
+		t.Errorf("warnings = %v, want one for the controlled-access run", res.Warnings)
+	}
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(out, "CRR000001", name))
+		if err != nil || string(got) != string(want) {
+			t.Errorf("%s: got %q, %v", name, got, err)
+		}
+	}
+
+	var w downloaders.WitnessFile
+	raw, err := os.ReadFile(filepath.Join(out, "hapiq.json"))
+	if err != nil {
+		t.Fatalf("witness: %v", err)
+	}
+	if err := json.Unmarshal(raw, &w); err != nil {
+		t.Fatalf("witness: %v", err)
+	}
+	if w.Source != "gsa" || len(w.Files) != 2 || w.Files[0].ChecksumType != "md5" {
+		t.Errorf("witness = %+v", w)
+	}
+	if v := w.Files[0].Verification; v == nil || !v.Verified || v.Method != "md5" || v.Expected != v.Actual {
+		t.Errorf("file verification = %+v", v)
+	}
+	if w.Verification == nil || !w.Verification.Verified || w.Verification.Method != "upstream md5" {
+		t.Errorf("witness verification = %+v", w.Verification)
+	}
+}
+
+func TestDownload_MD5Mismatch(t *testing.T) {
+	files := map[string][]byte{
+		"CRR000001_f1.fq.gz": []byte("forward reads"),
+		"CRR000001_r2.fq.gz": []byte("reverse reads"),
+	}
+	srv := newFakeGSAServer(t, files, "CRR000001_r2.fq.gz")
+	d := NewGSADownloader(WithBaseURL(srv.URL))
+
+	out := t.TempDir()
+	res, err := d.Download(context.Background(), &downloaders.DownloadRequest{ID: "CRA000001", OutputDir: out})
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if len(res.Files) != 1 {
+		t.Errorf("files = %d, want 1", len(res.Files))
+	}
+	if res.Success || len(res.Errors) != 1 || !strings.Contains(res.Errors[0], "md5 mismatch") {
+		t.Errorf("errors = %v, want one md5 mismatch", res.Errors)
+	}
+	if _, err := os.Stat(filepath.Join(out, "CRR000001", "CRR000001_r2.fq.gz")); !os.IsNotExist(err) {
+		t.Error("corrupt file left on disk")
+	}
+}
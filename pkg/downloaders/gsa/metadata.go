@@ -0,0 +1,281 @@
+package gsa
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// apiBase is the GSA web application. Run metadata comes from the same two
+// endpoints iSeq uses (https://github.com/BioOmics/iSeq):
+//
+//   - GET  {apiBase}/search?searchTerm=ACC reports the number of matching
+//     runs in its hidden "totalDatas" input;
+//   - POST {apiBase}/search/getRunInfo with searchTerm, totalDatas and
+//     downLoadCount returns those runs as CSV, one row per run, including
+//     the FTP/HTTPS paths and MD5s of the run's files.
+//
+// NGDC does not document the CSV columns, so rows are read by value rather
+// than by header name: accessions, URLs and 32-digit hex MD5s are recognised
+// by their shape.
+const apiBase = "https://ngdc.cncb.ac.cn/gsa"
+
+// defaultRunCount is sent as totalDatas when the search page does not
+// report a count.
+const defaultRunCount = 10000
+
+// RunInfo holds GSA metadata for one run.
+type RunInfo struct {
+	RunAccession        string    `json:"run"`
+	ExperimentAccession string    `json:"experiment,omitempty"`
+	SampleAccession     string    `json:"sample,omitempty"`
+	StudyAccession      string    `json:"study,omitempty"`
+	Layout              string    `json:"layout,omitempty"` // PAIRED or SINGLE
+	Files               []GSAFile `json:"files"`
+}
+
+// GSAFile holds metadata for one file of a run as listed by getRunInfo.
+type GSAFile struct {
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	MD5   string `json:"md5,omitempty"`
+	Bytes int64  `json:"size,omitempty"`
+}
+
+// FileName returns the file's base name, falling back to the URL path when
+// the run info omits it.
+func (f GSAFile) FileName() string {
+	if f.Name != "" {
+		return path.Base(f.Name)
+	}
+	if u, err := url.Parse(f.URL); err == nil {
+		return path.Base(u.Path)
+	}
+	return ""
+}
+
+// runReport is the run info for an accession.
+type runReport struct {
+	Accession string    `json:"accession"`
+	Title     string    `json:"title"`
+	Runs      []RunInfo `json:"runs"`
+}
+
+var (
+	runAccRE        = regexp.MustCompile(`^[CH]RR\d+$`)
+	experimentAccRE = regexp.MustCompile(`^[CH]RX\d+$`)
+	studyAccRE      = regexp.MustCompile(`^[CH]RA\d+$`)
+	sampleAccRE     = regexp.MustCompile(`^SAMC\d+$`)
+	md5RE           = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+	totalDatasRE    = regexp.MustCompile(`id=["']totalDatas["'][^>]*value=["'](\d+)["']|value=["'](\d+)["'][^>]*id=["']totalDatas["']`)
+)
+
+// fetchRunInfo resolves a PRJCA/CRA/CRX/CRR (or HRA/HRX/HRR) accession to its
+// runs and their files through GSA's getRunInfo export.
+func (d *GSADownloader) fetchRunInfo(ctx context.Context, accession string) (*runReport, error) {
+	base := d.baseURL
+	if base == "" {
+		base = apiBase
+	}
+
+	total := d.runCount(ctx, base, accession)
+	form := url.Values{
+		"searchTerm":    {accession},
+		"totalDatas":    {strconv.Itoa(total)},
+		"downLoadCount": {strconv.Itoa(total)},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", base+"/search/getRunInfo", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "text/csv, */*")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GSA run info request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("GSA accession %s not found", accession)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("GSA run info HTTP %d for %s: %s", resp.StatusCode, accession, strings.TrimSpace(string(body)))
+	}
+
+	runs, err := parseRunInfo(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parse GSA run info: %w", err)
+	}
+	return &runReport{Accession: accession, Runs: runs}, nil
+}
+
+// runCount reads the number of runs matching accession from the search page,
+// falling back to defaultRunCount.
+func (d *GSADownloader) runCount(ctx context.Context, base, accession string) int {
+	u := base + "/search?searchTerm=" + url.QueryEscape(accession)
+	req, err := http.NewRequestWithContext(ctx, "GET", u, http.NoBody)
+	if err != nil {
+		return defaultRunCount
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return defaultRunCount
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return defaultRunCount
+	}
+	page, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return defaultRunCount
+	}
+	if m := totalDatasRE.FindSubmatch(page); m != nil {
+		digits := m[1]
+		if len(digits) == 0 {
+			digits = m[2]
+		}
+		if n, err := strconv.Atoi(string(digits)); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultRunCount
+}
+
+// parseRunInfo reads getRunInfo CSV. Every cell holding an FTP or HTTP(S)
+// URL is a file of the row's run; MD5s and columns whose header mentions
+// "size" are paired with the URLs in column order. Rows of the same run are
+// merged.
+func parseRunInfo(r io.Reader) ([]RunInfo, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sizeCols []int
+	for i, h := range header {
+		if strings.Contains(strings.ToLower(h), "size") {
+			sizeCols = append(sizeCols, i)
+		}
+	}
+
+	var runs []RunInfo
+	index := map[string]int{}
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		run, ok := parseRunRow(rec, sizeCols)
+		if !ok {
+			continue
+		}
+		if i, seen := index[run.RunAccession]; seen {
+			runs[i].Files = append(runs[i].Files, run.Files...)
+			continue
+		}
+		index[run.RunAccession] = len(runs)
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// parseRunRow extracts one run from a CSV record; ok is false for rows
+// without a run accession.
+func parseRunRow(rec []string, sizeCols []int) (RunInfo, bool) {
+	var run RunInfo
+	var urls, md5s []string
+	for _, cell := range rec {
+		cell = strings.TrimSpace(cell)
+		switch {
+		case cell == "":
+		case runAccRE.MatchString(cell):
+			if run.RunAccession == "" {
+				run.RunAccession = cell
+			}
+		case experimentAccRE.MatchString(cell):
+			run.ExperimentAccession = cell
+		case studyAccRE.MatchString(cell):
+			run.StudyAccession = cell
+		case sampleAccRE.MatchString(cell):
+			run.SampleAccession = cell
+		case strings.EqualFold(cell, "PAIRED"), strings.EqualFold(cell, "SINGLE"):
+			run.Layout = strings.ToUpper(cell)
+		case md5RE.MatchString(cell):
+			md5s = append(md5s, strings.ToLower(cell))
+		case isFileURL(cell):
+			urls = append(urls, downloadURL(cell))
+		}
+	}
+	if run.RunAccession == "" {
+		return run, false
+	}
+
+	var sizes []int64
+	for _, i := range sizeCols {
+		if i < len(rec) {
+			if n, err := strconv.ParseInt(strings.TrimSpace(rec[i]), 10, 64); err == nil {
+				sizes = append(sizes, n)
+			}
+		}
+	}
+	for i, u := range urls {
+		f := GSAFile{URL: u}
+		f.Name = f.FileName()
+		if len(md5s) == len(urls) {
+			f.MD5 = md5s[i]
+		}
+		if len(sizes) == len(urls) {
+			f.Bytes = sizes[i]
+		}
+		run.Files = append(run.Files, f)
+	}
+	return run, true
+}
+
+func isFileURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" || path.Base(u.Path) == "/" || path.Base(u.Path) == "." {
+		return false
+	}
+	switch u.Scheme {
+	case "ftp", "http", "https":
+		return true
+	}
+	return false
+}
+
+// downloadURL rewrites GSA's FTP paths (ftp://download.big.ac.cn/gsa/...) to
+// the HTTPS mirror of the same tree, which common.Fetch can retrieve and
+// resume. Other URLs are returned unchanged.
+func downloadURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "ftp" {
+		return s
+	}
+	switch strings.ToLower(u.Hostname()) {
+	case "download.big.ac.cn", "download.cncb.ac.cn", "download2.big.ac.cn", "download2.cncb.ac.cn":
+		u.Scheme, u.Host, u.User = "https", "download.cncb.ac.cn", nil
+		return u.String()
+	}
+	return s
+}
@@ -2,13 +2,16 @@ package hca
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 
+	"github.com/btraven00/hapiq/pkg/cache"
 	"github.com/btraven00/hapiq/pkg/downloaders"
 	"github.com/btraven00/hapiq/internal/version"
 	"github.com/btraven00/hapiq/pkg/downloaders/common"
@@ -169,8 +172,12 @@ func (d *HCADownloader) Download(ctx context.Context, req *downloaders.DownloadR
 			fmt.Fprintf(os.Stderr, "⬇️  %s (%s)\n", f.Name, common.FormatBytes(f.Size))
 		}
 
-		fi, err := d.downloadFile(ctx, f.AzulURL, targetPath)
-		if err != nil {
+		fi, err := d.downloadFile(ctx, f.AzulURL, targetPath, f.upstreamChecksum(), opts != nil && opts.Resume)
+		var mismatch *common.ChecksumMismatchError
+		if errors.As(err, &mismatch) {
+			result.Errors = append(result.Errors, mismatch.Error())
+			continue
+		} else if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("failed %s: %v", f.Name, err))
 			continue
 		}
@@ -216,8 +223,10 @@ func (d *HCADownloader) Download(ctx context.Context, req *downloaders.DownloadR
 	return result, nil
 }
 
-func (d *HCADownloader) downloadFile(ctx context.Context, rawURL, targetPath string) (*downloaders.FileInfo, error) {
-	result, err := common.Fetch(ctx, rawURL, targetPath, common.FetchOptions{Client: d.client})
+// downloadFile fetches rawURL to targetPath, verifying it against the
+// digest Azul declares for the file when there is one.
+func (d *HCADownloader) downloadFile(ctx context.Context, rawURL, targetPath string, want common.Checksum, resume bool) (*downloaders.FileInfo, error) {
+	result, err := common.Fetch(ctx, rawURL, targetPath, common.FetchOptions{Client: d.client, Retry: common.RetryPolicyFor(d.GetSourceType()), Freshness: cache.FreshnessFor(d.GetSourceType()), Resume: resume, Expected: want})
 	if err != nil {
 		return nil, err
 	}
@@ -231,5 +240,19 @@ func (d *HCADownloader) downloadFile(ctx context.Context, rawURL, targetPath str
 		DownloadTime: time.Now(),
 		ContentType:  result.ContentType,
 		CacheHit:     result.Hit,
+		Resumed:      result.Resumed,
+		Verification: result.Verification,
 	}, nil
 }
+
+// upstreamChecksum returns the digest Azul publishes for f: sha256 when
+// present, otherwise crc32c.
+func (f File) upstreamChecksum() common.Checksum {
+	switch {
+	case f.SHA256 != "":
+		return common.Checksum{Type: "sha256", Value: strings.ToLower(f.SHA256)}
+	case f.CRC32C != "":
+		return common.Checksum{Type: "crc32c", Value: strings.ToLower(f.CRC32C)}
+	}
+	return common.Checksum{}
+}
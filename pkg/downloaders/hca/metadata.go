@@ -30,6 +30,7 @@ type File struct {
 	UUID               string   `json:"uuid"`
 	Version            string   `json:"version"`
 	SHA256             string   `json:"sha256"`
+	CRC32C             string   `json:"crc32c"`
 	AzulURL            string   `json:"azul_url"`
 	DRSURI             string   `json:"drs_uri"`
 	FileSource         string   `json:"fileSource"`
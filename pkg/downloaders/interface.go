@@ -43,6 +43,7 @@ type DownloadOptions struct {
 	Subset   []string `json:"subset,omitempty"`   // only download these sub-items (e.g. specific GSMs within a GSE)
 	Organism string   `json:"organism,omitempty"` // skip datasets whose organism doesn't match (case-insensitive partial)
 	DryRun   bool     `json:"dry_run,omitempty"`  // enumerate files without downloading
+	Version  string   `json:"version,omitempty"`  // dataset version to fetch: "latest" (default), a version, or "all" (Zenodo)
 
 	// Testing / throttling
 	LimitFiles int  `json:"limit_files,omitempty"`  // stop after downloading this many files (0 = no limit)
@@ -125,6 +126,10 @@ type FileInfo struct {
 	ContentType  string    `json:"content_type,omitempty"`
 	Size         int64     `json:"size"`
 	CacheHit     bool      `json:"cache_hit,omitempty"`
+	Resumed      bool      `json:"resumed,omitempty"`
+	// Verification is the check against the repository's published digest,
+	// when it publishes one.
+	Verification *Verification `json:"verification,omitempty"`
 }
 
 // DatasetRecord captures provenance for a single dataset/accession within a
@@ -150,8 +155,12 @@ type WitnessFile struct {
 	Source        string           `json:"source"`
 	OriginalID    string           `json:"original_id"`
 	ResolvedURL   string           `json:"resolved_url,omitempty"`
-	Files         []FileWitness    `json:"files"`
-	Collections   []Collection     `json:"collections,omitempty"`
+	// ResolvedID is the exact record OriginalID resolved to when it names
+	// a moving target, e.g. a Zenodo concept DOI resolved to its latest
+	// version. manifest gen pins it.
+	ResolvedID  string        `json:"resolved_id,omitempty"`
+	Files       []FileWitness `json:"files"`
+	Collections []Collection  `json:"collections,omitempty"`
 	// Datasets accumulates per-accession provenance when multiple distinct
 	// datasets are downloaded into the same output directory.
 	Datasets []DatasetRecord `json:"datasets,omitempty"`
@@ -168,6 +177,10 @@ type FileWitness struct {
 	ContentType  string    `json:"content_type,omitempty"`
 	Size         int64     `json:"size"`
 	CacheHit     bool      `json:"cache_hit,omitempty"`
+	Resumed      bool      `json:"resumed,omitempty"`
+	// Verification is the check against the repository's published digest,
+	// when it publishes one.
+	Verification *Verification `json:"verification,omitempty"`
 }
 
 // DownloadStats contains performance and operational statistics.
@@ -184,6 +197,17 @@ type DownloadStats struct {
 	ResumedDownload bool          `json:"resumed_download"`
 }
 
+// AnyResumed reports whether any of files continued an earlier partial
+// download, for DownloadStats.ResumedDownload.
+func AnyResumed(files []FileInfo) bool {
+	for _, f := range files {
+		if f.Resumed {
+			return true
+		}
+	}
+	return false
+}
+
 // Speed returns bytes/second, returning 0 instead of +Inf when duration is zero.
 func Speed(bytes int64, d time.Duration) float64 {
 	if d <= 0 {
@@ -192,7 +216,8 @@ func Speed(bytes int64, d time.Duration) float64 {
 	return float64(bytes) / d.Seconds()
 }
 
-// Verification contains integrity verification information.
+// Verification contains integrity verification information. Per file it
+// records the upstream digest check; on WitnessFile it summarises all files.
 type Verification struct {
 	VerifyTime time.Time `json:"verify_time"`
 	Method     string    `json:"method"`
@@ -5,15 +5,21 @@ package downloaders
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+
+	"github.com/btraven00/hapiq/pkg/validators/domains/bio/accessions"
 )
 
 // Registry manages the collection of available downloaders.
 type Registry struct {
 	downloaders map[string]Downloader
 	aliases     map[string]string
-	mu          sync.RWMutex
+	// fallbacks are catch-all sources (e.g. url) that AutoDetect only picks
+	// when no other source accepts the ID.
+	fallbacks map[string]bool
+	mu        sync.RWMutex
 }
 
 // NewRegistry creates a new downloader registry.
@@ -21,6 +27,7 @@ func NewRegistry() *Registry {
 	return &Registry{
 		downloaders: make(map[string]Downloader),
 		aliases:     make(map[string]string),
+		fallbacks:   make(map[string]bool),
 	}
 }
 
@@ -48,6 +55,22 @@ func (r *Registry) Register(downloader Downloader) error {
 	return nil
 }
 
+// RegisterFallback adds a catch-all downloader. It is used normally when
+// named explicitly, but AutoDetect only chooses it when no other downloader
+// accepts the ID, so that e.g. a figshare URL goes to figshare rather than
+// the generic URL downloader.
+func (r *Registry) RegisterFallback(downloader Downloader) error {
+	if err := r.Register(downloader); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallbacks[downloader.GetSourceType()] = true
+
+	return nil
+}
+
 // RegisterAlias creates an alias for an existing downloader.
 func (r *Registry) RegisterAlias(alias, sourceType string) error {
 	if alias == "" || sourceType == "" {
@@ -155,6 +178,20 @@ func (r *Registry) GetMetadata(ctx context.Context, sourceType, id string) (*Met
 	return downloader.GetMetadata(ctx, id)
 }
 
+// CheckMetadata retrieves metadata for an update check, through the
+// downloader's UpdateChecker when it has one.
+func (r *Registry) CheckMetadata(ctx context.Context, sourceType, id string) (*Metadata, error) {
+	downloader, err := r.Get(sourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	if c, ok := downloader.(UpdateChecker); ok {
+		return c.CheckMetadata(ctx, id)
+	}
+	return downloader.GetMetadata(ctx, id)
+}
+
 // Download performs a download using the appropriate downloader.
 func (r *Registry) Download(ctx context.Context, sourceType string, req *DownloadRequest) (*DownloadResult, error) {
 	downloader, err := r.Get(sourceType)
@@ -165,25 +202,94 @@ func (r *Registry) Download(ctx context.Context, sourceType string, req *Downloa
 	return downloader.Download(ctx, req)
 }
 
-// AutoDetect attempts to determine the source type from an ID.
+// accessionSources maps an accession's database to the source type that
+// normally serves it. SRA, ENA and DDBJ share the INSDC run hierarchy.
+// BioProject and BioSample span several archives and have no source of
+// their own, so they go through ordinary detection.
+var accessionSources = map[accessions.Database]string{
+	accessions.DatabaseSRA:  "sra",
+	accessions.DatabaseENA:  "sra",
+	accessions.DatabaseDDBJ: "sra",
+	accessions.DatabaseGEO:  "geo",
+	accessions.DatabaseGSA:  "gsa",
+}
+
+// UnsupportedAccessionError is returned by AutoDetect when the ID is a
+// recognised accession that no registered downloader accepts.
+type UnsupportedAccessionError struct {
+	Accession accessions.Accession
+}
+
+func (e *UnsupportedAccessionError) Error() string {
+	a := e.Accession
+	msg := fmt.Sprintf("%s is a %s (%s database, %s level), but no registered downloader handles it",
+		a.ID, a.Description, a.Database, a.Level)
+	if u := a.PrimaryURL(); u != "" {
+		msg += "; browse it at " + u
+	}
+	return msg
+}
+
+// AmbiguousIDError is returned by AutoDetect when more than one downloader
+// accepts the ID. Candidates are sorted by source type.
+type AmbiguousIDError struct {
+	ID         string
+	Candidates []string
+}
+
+func (e *AmbiguousIDError) Error() string {
+	return fmt.Sprintf("'%s' is accepted by several sources (%s); specify the source explicitly",
+		e.ID, strings.Join(e.Candidates, ", "))
+}
+
+// AutoDetect attempts to determine the source type from an ID. IDs that
+// classify as a known accession (see package accessions) are offered to the
+// source serving that database first. Otherwise every downloader is asked;
+// exactly one must accept the ID (fallbacks only count when nothing else
+// does), or an *AmbiguousIDError lists the candidates.
 func (r *Registry) AutoDetect(ctx context.Context, id string) (string, *ValidationResult, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	acc, isAccession := accessions.Classify(id)
+	if isAccession {
+		if sourceType, ok := accessionSources[acc.Database]; ok {
+			if downloader, ok := r.downloaders[sourceType]; ok {
+				if result, err := downloader.Validate(ctx, id); err == nil && result.Valid {
+					return sourceType, result, nil
+				}
+			}
+		}
+	}
+
 	var lastErr error
 
 	var candidates []string
 
-	// Try each downloader to see which one accepts the ID
-	for sourceType, downloader := range r.downloaders {
-		result, err := downloader.Validate(ctx, id)
+	sourceTypes := make([]string, 0, len(r.downloaders))
+	for sourceType := range r.downloaders {
+		sourceTypes = append(sourceTypes, sourceType)
+	}
+	sort.Strings(sourceTypes)
+
+	// Ask every downloader so that ambiguity can be reported
+	var accepted, fallbacks []string
+	results := make(map[string]*ValidationResult)
+	for _, sourceType := range sourceTypes {
+		result, err := r.downloaders[sourceType].Validate(ctx, id)
 		if err != nil {
 			lastErr = err
 			continue
 		}
 
 		if result.Valid {
-			return sourceType, result, nil
+			results[sourceType] = result
+			if r.fallbacks[sourceType] {
+				fallbacks = append(fallbacks, sourceType)
+			} else {
+				accepted = append(accepted, sourceType)
+			}
+			continue
 		}
 
 		// Keep track of potential candidates that didn't fail validation
@@ -193,6 +299,17 @@ func (r *Registry) AutoDetect(ctx context.Context, id string) (string, *Validati
 		}
 	}
 
+	if len(accepted) == 0 {
+		accepted = fallbacks
+	}
+	switch len(accepted) {
+	case 0:
+	case 1:
+		return accepted[0], results[accepted[0]], nil
+	default:
+		return "", nil, &AmbiguousIDError{ID: id, Candidates: accepted}
+	}
+
 	// If no exact match, but we have candidates, return the first one with a warning
 	if len(candidates) > 0 {
 		downloader := r.downloaders[candidates[0]]
@@ -209,6 +326,10 @@ func (r *Registry) AutoDetect(ctx context.Context, id string) (string, *Validati
 		return candidates[0], result, nil
 	}
 
+	if isAccession {
+		return "", nil, &UnsupportedAccessionError{Accession: acc}
+	}
+
 	if lastErr != nil {
 		return "", nil, fmt.Errorf("no downloader could handle ID '%s': %w", id, lastErr)
 	}
@@ -224,6 +345,11 @@ func Register(downloader Downloader) error {
 	return DefaultRegistry.Register(downloader)
 }
 
+// RegisterFallback adds a catch-all downloader to the default registry.
+func RegisterFallback(downloader Downloader) error {
+	return DefaultRegistry.RegisterFallback(downloader)
+}
+
 // RegisterAlias creates an alias in the default registry.
 func RegisterAlias(alias, sourceType string) error {
 	return DefaultRegistry.RegisterAlias(alias, sourceType)
@@ -249,6 +375,12 @@ func GetMetadata(ctx context.Context, sourceType, id string) (*Metadata, error)
 	return DefaultRegistry.GetMetadata(ctx, sourceType, id)
 }
 
+// CheckMetadata retrieves metadata for an update check using the default
+// registry.
+func CheckMetadata(ctx context.Context, sourceType, id string) (*Metadata, error) {
+	return DefaultRegistry.CheckMetadata(ctx, sourceType, id)
+}
+
 // Download performs a download using the default registry.
 func Download(ctx context.Context, sourceType string, req *DownloadRequest) (*DownloadResult, error) {
 	return DefaultRegistry.Download(ctx, sourceType, req)
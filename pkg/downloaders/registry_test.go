@@ -4,7 +4,9 @@ package downloaders
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -376,6 +378,92 @@ func TestRegistry_AutoDetect(t *testing.T) {
 	}
 }
 
+func TestRegistry_AutoDetectAccessions(t *testing.T) {
+	registry := NewRegistry()
+
+	// Both accept SRR000001; the accession classifier must route it to sra.
+	greedy := NewMockDownloader("aaa")
+	greedy.AddValidID("SRR000001")
+	sra := NewMockDownloader("sra")
+	sra.AddValidID("SRR000001")
+	for _, d := range []*MockDownloader{greedy, sra} {
+		if err := registry.Register(d); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+	for range 10 {
+		sourceType, _, err := registry.AutoDetect(ctx, "SRR000001")
+		if err != nil || sourceType != "sra" {
+			t.Fatalf("AutoDetect(SRR000001) = %q, %v; want sra", sourceType, err)
+		}
+	}
+
+	// BioProjects are not routed to sra: with two takers they are ambiguous.
+	greedy.AddValidID("PRJNA000001")
+	sra.AddValidID("PRJNA000001")
+	var ambiguous *AmbiguousIDError
+	if _, _, err := registry.AutoDetect(ctx, "PRJNA000001"); !errors.As(err, &ambiguous) {
+		t.Errorf("AutoDetect(PRJNA000001) err = %v, want *AmbiguousIDError", err)
+	}
+
+	// A recognised accession without a downloader gets a descriptive error.
+	_, _, err := registry.AutoDetect(ctx, "prjca123456")
+	var unsupported *UnsupportedAccessionError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("AutoDetect(prjca123456) err = %v, want *UnsupportedAccessionError", err)
+	}
+	if unsupported.Accession.ID != "PRJCA123456" || unsupported.Accession.Level != "project" {
+		t.Errorf("Accession = %+v", unsupported.Accession)
+	}
+	if !strings.Contains(err.Error(), "GSA project") {
+		t.Errorf("error %q does not name the accession type", err)
+	}
+}
+
+func TestRegistry_AutoDetectAmbiguousAndFallback(t *testing.T) {
+	registry := NewRegistry()
+
+	zenodo := NewMockDownloader("zenodo")
+	zenodo.AddValidID("12345678")
+	figshare := NewMockDownloader("figshare")
+	figshare.AddValidID("12345678")
+	figshare.AddValidID("https://figshare.com/articles/1")
+	url := NewMockDownloader("url")
+	url.AddValidID("https://figshare.com/articles/1")
+	url.AddValidID("https://example.com/a.h5ad")
+
+	for _, d := range []*MockDownloader{zenodo, figshare} {
+		if err := registry.Register(d); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+	if err := registry.RegisterFallback(url); err != nil {
+		t.Fatalf("RegisterFallback: %v", err)
+	}
+
+	ctx := context.Background()
+
+	_, _, err := registry.AutoDetect(ctx, "12345678")
+	var ambiguous *AmbiguousIDError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("AutoDetect(12345678) err = %v, want *AmbiguousIDError", err)
+	}
+	if got := strings.Join(ambiguous.Candidates, ","); got != "figshare,zenodo" {
+		t.Errorf("Candidates = %s, want figshare,zenodo", got)
+	}
+
+	// A specific downloader wins over the fallback...
+	if sourceType, _, err := registry.AutoDetect(ctx, "https://figshare.com/articles/1"); err != nil || sourceType != "figshare" {
+		t.Errorf("AutoDetect(figshare URL) = %q, %v; want figshare", sourceType, err)
+	}
+	// ...and the fallback is used when nothing else matches.
+	if sourceType, _, err := registry.AutoDetect(ctx, "https://example.com/a.h5ad"); err != nil || sourceType != "url" {
+		t.Errorf("AutoDetect(plain URL) = %q, %v; want url", sourceType, err)
+	}
+}
+
 func TestDefaultRegistry(t *testing.T) {
 	// Test that default registry functions work
 	mockDownloader := NewMockDownloader("default_test")
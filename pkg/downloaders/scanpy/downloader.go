@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/btraven00/hapiq/internal/version"
+	"github.com/btraven00/hapiq/pkg/cache"
 	"github.com/btraven00/hapiq/pkg/downloaders"
 	"github.com/btraven00/hapiq/pkg/downloaders/common"
 )
@@ -147,7 +148,12 @@ func (d *Downloader) Download(ctx context.Context, req *downloaders.DownloadRequ
 			_, _ = fmt.Fprintf(os.Stderr, "⬇️  %s\n", f.URL)
 		}
 
-		fr, err := common.Fetch(ctx, f.URL, targetPath, common.FetchOptions{Client: d.client})
+		fr, err := common.Fetch(ctx, f.URL, targetPath, common.FetchOptions{
+			Client:    d.client,
+			Retry:     common.RetryPolicyFor(d.GetSourceType()),
+			Freshness: cache.FreshnessFor(d.GetSourceType()),
+			Resume:    opts != nil && opts.Resume,
+		})
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("failed %s: %v", fname, err))
 			continue
@@ -161,6 +167,7 @@ func (d *Downloader) Download(ctx context.Context, req *downloaders.DownloadRequ
 			Checksum:     fr.SHA256,
 			ChecksumType: "sha256",
 			CacheHit:     fr.Hit,
+			Resumed:      fr.Resumed,
 			DownloadTime: time.Now(),
 			ContentType:  fr.ContentType,
 		}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/btraven00/hapiq/pkg/cache"
 	"github.com/btraven00/hapiq/pkg/downloaders"
 	"github.com/btraven00/hapiq/internal/version"
 	"github.com/btraven00/hapiq/pkg/downloaders/common"
@@ -187,7 +188,7 @@ func (d *ScPerturbDownloader) Download(ctx context.Context, req *downloaders.Dow
 			fmt.Printf("⬇️  %s → %s\n", ds.FullIndex, ds.DownloadURL())
 		}
 
-		fi, err := d.downloadFile(ctx, ds.DownloadURL(), targetPath)
+		fi, err := d.downloadFile(ctx, ds.DownloadURL(), targetPath, opts != nil && opts.Resume)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("failed %s: %v", fname, err))
 			continue
@@ -228,8 +229,8 @@ func (d *ScPerturbDownloader) Download(ctx context.Context, req *downloaders.Dow
 	return result, nil
 }
 
-func (d *ScPerturbDownloader) downloadFile(ctx context.Context, rawURL, targetPath string) (*downloaders.FileInfo, error) {
-	result, err := common.Fetch(ctx, rawURL, targetPath, common.FetchOptions{Client: d.client})
+func (d *ScPerturbDownloader) downloadFile(ctx context.Context, rawURL, targetPath string, resume bool) (*downloaders.FileInfo, error) {
+	result, err := common.Fetch(ctx, rawURL, targetPath, common.FetchOptions{Client: d.client, Retry: common.RetryPolicyFor(d.GetSourceType()), Freshness: cache.FreshnessFor(d.GetSourceType()), Resume: resume})
 	if err != nil {
 		return nil, fmt.Errorf("fetch %s: %w", filepath.Base(targetPath), err)
 	}
@@ -243,6 +244,7 @@ func (d *ScPerturbDownloader) downloadFile(ctx context.Context, rawURL, targetPa
 		DownloadTime: time.Now(),
 		ContentType:  result.ContentType,
 		CacheHit:     result.Hit,
+		Resumed:      result.Resumed,
 	}, nil
 }
 
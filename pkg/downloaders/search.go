@@ -4,9 +4,10 @@ import "context"
 
 // SearchOptions configures a dataset search operation.
 type SearchOptions struct {
-	Organism  string // filter by organism (added as a query field operator)
-	EntryType string // filter by entry type (e.g. "GSE", "GSM")
-	Limit     int    // maximum number of results (0 → source default)
+	Organism   string // filter by organism (added as a query field operator)
+	EntryType  string // filter by entry type (e.g. "GSE", "GSM")
+	Collection string // restrict to a sub-collection (e.g. BioStudies "arrayexpress")
+	Limit      int    // maximum number of results (0 → source default)
 }
 
 // SearchResult represents a single search hit from a data repository.
@@ -18,6 +19,7 @@ type SearchResult struct {
 	DatasetType string `json:"dataset_type,omitempty"`
 	Date        string `json:"date,omitempty"`
 	SampleCount int    `json:"sample_count,omitempty"`
+	FileCount   int    `json:"file_count,omitempty"`
 	FileSize    int64  `json:"file_size,omitempty"` // total bytes of all files in the dataset
 }
 
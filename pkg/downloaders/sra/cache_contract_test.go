@@ -3,6 +3,7 @@ package sra
 import (
 	"context"
 	"crypto/md5" // #nosec G501 -- matches ENA-provided checksum format
+	"crypto/sha256"
 	"encoding/hex"
 	"net/http"
 	"net/http/httptest"
@@ -15,8 +16,8 @@ import (
 )
 
 // TestSRADownloadWithMD5HonorsCache verifies that SRADownloader.downloadWithMD5
-// — which implements its own inline cache flow rather than delegating to
-// common.Fetch — honors the blob cache across runs.
+// — which wraps common.Fetch with an MD5 check — honors the blob cache across
+// runs.
 func TestSRADownloadWithMD5HonorsCache(t *testing.T) {
 	const blobPath = "/vol1/fastq/SRR000001/SRR000001.fastq.gz"
 	content := []byte("SRA blob content for cache contract test")
@@ -43,7 +44,7 @@ func TestSRADownloadWithMD5HonorsCache(t *testing.T) {
 	url := srv.URL + blobPath
 
 	dest1 := filepath.Join(t.TempDir(), "out.fastq.gz")
-	fi1, err := d.downloadWithMD5(ctx, url, dest1, expectedMD5)
+	fi1, err := d.downloadWithMD5(ctx, url, dest1, expectedMD5, false)
 	if err != nil {
 		t.Fatalf("run 1: %v", err)
 	}
@@ -58,7 +59,7 @@ func TestSRADownloadWithMD5HonorsCache(t *testing.T) {
 	}
 
 	dest2 := filepath.Join(t.TempDir(), "out.fastq.gz")
-	fi2, err := d.downloadWithMD5(ctx, url, dest2, expectedMD5)
+	fi2, err := d.downloadWithMD5(ctx, url, dest2, expectedMD5, false)
 	if err != nil {
 		t.Fatalf("run 2: %v", err)
 	}
@@ -87,7 +88,7 @@ func TestSRADownloadWithMD5NoCache(t *testing.T) {
 
 	d := NewSRADownloader()
 	dest := filepath.Join(t.TempDir(), "out.fastq.gz")
-	fi, err := d.downloadWithMD5(context.Background(), srv.URL+"/blob", dest, expectedMD5)
+	fi, err := d.downloadWithMD5(context.Background(), srv.URL+"/blob", dest, expectedMD5, false)
 	if err != nil {
 		t.Fatalf("no-cache: %v", err)
 	}
@@ -98,3 +99,53 @@ func TestSRADownloadWithMD5NoCache(t *testing.T) {
 		t.Fatal("no-cache: content mismatch")
 	}
 }
+
+// TestSRADownloadWithMD5EvictsMismatchedBlob verifies that a cached blob whose
+// MD5 does not match the ENA record is evicted and re-fetched from the network.
+func TestSRADownloadWithMD5EvictsMismatchedBlob(t *testing.T) {
+	content := []byte("the bytes ENA currently serves")
+	sum := md5.Sum(content) // #nosec G401 -- test fixture md5
+	expectedMD5 := hex.EncodeToString(sum[:])
+
+	var hits int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt64(&hits, 1)
+		_, _ = w.Write(content)
+	}))
+	t.Cleanup(srv.Close)
+	url := srv.URL + "/vol1/fastq/SRR000002/SRR000002.fastq.gz"
+
+	c, err := cache.Open(cache.Config{Dir: t.TempDir(), LinkStrategy: cache.StrategyCopy})
+	if err != nil {
+		t.Fatalf("cache.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	// Seed the cache with stale bytes under the same URL.
+	stale, err := c.NewTmpFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = stale.WriteString("stale mirror bytes")
+	_ = stale.Close()
+	staleSum := sha256.Sum256([]byte("stale mirror bytes"))
+	if err := c.Put(context.Background(), url, stale.Name(), hex.EncodeToString(staleSum[:])); err != nil {
+		t.Fatalf("seed Put: %v", err)
+	}
+
+	d := NewSRADownloader()
+	dest := filepath.Join(t.TempDir(), "out.fastq.gz")
+	fi, err := d.downloadWithMD5(cache.WithCache(context.Background(), c), url, dest, expectedMD5, false)
+	if err != nil {
+		t.Fatalf("downloadWithMD5: %v", err)
+	}
+	if fi.CacheHit || fi.Checksum != expectedMD5 {
+		t.Errorf("FileInfo = %+v, want network fetch with md5 %s", fi, expectedMD5)
+	}
+	if got, _ := os.ReadFile(dest); string(got) != string(content) {
+		t.Error("content mismatch after re-fetch")
+	}
+	if h := atomic.LoadInt64(&hits); h != 1 {
+		t.Errorf("server hits = %d, want 1", h)
+	}
+}
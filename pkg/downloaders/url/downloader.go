@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/btraven00/hapiq/internal/version"
+	"github.com/btraven00/hapiq/pkg/cache"
 	"github.com/btraven00/hapiq/pkg/downloaders"
 	"github.com/btraven00/hapiq/pkg/downloaders/common"
 	"github.com/btraven00/hapiq/pkg/downloaders/sharepoint"
@@ -96,6 +97,13 @@ func (d *URLDownloader) GetMetadata(ctx context.Context, id string) (*downloader
 	if resp.ContentLength > 0 {
 		meta.TotalSize = resp.ContentLength
 	}
+	// Validators let `hapiq outdated` tell whether the file has changed.
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		meta.Custom = map[string]any{"etag": etag}
+	}
+	if lm, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		meta.LastModified = lm
+	}
 	return meta, nil
 }
 
@@ -157,7 +165,12 @@ func (d *URLDownloader) Download(ctx context.Context, req *downloaders.DownloadR
 		_, _ = fmt.Fprintf(os.Stderr, "⬇️  %s\n", rawURL)
 	}
 
-	fr, err := common.Fetch(ctx, rawURL, targetPath, common.FetchOptions{Client: d.client})
+	fr, err := common.Fetch(ctx, rawURL, targetPath, common.FetchOptions{
+		Client:    d.client,
+		Retry:     common.RetryPolicyFor(d.GetSourceType()),
+		Freshness: cache.FreshnessFor(d.GetSourceType()),
+		Resume:    opts != nil && opts.Resume,
+	})
 	if err != nil {
 		result.Errors = append(result.Errors, err.Error())
 		return result, nil
@@ -170,6 +183,9 @@ func (d *URLDownloader) Download(ctx context.Context, req *downloaders.DownloadR
 		if better := common.SanitizeFilename(fr.Filename); better != "" && better != filepath.Base(targetPath) {
 			newPath := filepath.Join(req.OutputDir, better)
 			if err := os.Rename(targetPath, newPath); err == nil {
+				if c := cache.FromContext(ctx); c != nil {
+					_ = c.MoveRef(ctx, targetPath, newPath)
+				}
 				targetPath = newPath
 				filename = fr.Filename
 			} else {
@@ -187,6 +203,7 @@ func (d *URLDownloader) Download(ctx context.Context, req *downloaders.DownloadR
 		Checksum:     fr.SHA256,
 		ChecksumType: "sha256",
 		CacheHit:     fr.Hit,
+		Resumed:      fr.Resumed,
 		DownloadTime: time.Now(),
 		ContentType:  fr.ContentType,
 	}
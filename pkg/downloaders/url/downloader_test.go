@@ -11,6 +11,7 @@ import (
 
 	"github.com/btraven00/hapiq/pkg/cache"
 	"github.com/btraven00/hapiq/pkg/downloaders"
+	"github.com/spf13/viper"
 )
 
 func TestGetSourceType(t *testing.T) {
@@ -248,8 +249,14 @@ func TestDownload_ContentDisposition(t *testing.T) {
 }
 
 func TestDownload_ServerError(t *testing.T) {
+	viper.Set("retry.url.max_attempts", 2)
+	viper.Set("retry.url.initial_backoff", "1ms")
+	t.Cleanup(viper.Reset)
+
+	gets := 0
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
+			gets++
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 	}))
@@ -270,6 +277,9 @@ func TestDownload_ServerError(t *testing.T) {
 	if len(result.Errors) == 0 {
 		t.Error("Download() no errors reported on server 500")
 	}
+	if gets != 2 {
+		t.Errorf("GET count = %d, want 2 (retry.url.max_attempts)", gets)
+	}
 }
 
 func TestDownload_ForceOverwritesExisting(t *testing.T) {
@@ -393,14 +403,20 @@ func TestDownload_ContentDispositionOnGET(t *testing.T) {
 }
 
 // TestDownload_ContentDispositionFromCacheHit verifies that the
-// Content-Disposition filename survives a cache hit, where no HTTP GET occurs
-// and the filename must be recovered from the cache index.
+// Content-Disposition filename survives a cache hit, where the origin only
+// answers 304 and the filename must be recovered from the cache index.
 func TestDownload_ContentDispositionFromCacheHit(t *testing.T) {
 	const body = "payload"
-	getCount := 0
+	getCount, notModified := 0, 0
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
 			getCount++
+			w.Header().Set("ETag", `"v1"`)
 			w.Header().Set("Content-Disposition", `attachment; filename="real_name.zip"`)
 			_, _ = w.Write([]byte(body))
 		}
@@ -433,8 +449,9 @@ func TestDownload_ContentDispositionFromCacheHit(t *testing.T) {
 		t.Fatalf("expected 1 GET on miss, got %d", getCount)
 	}
 
-	// Second download into a fresh dir: cache hit (no GET) must still produce
-	// the Content-Disposition filename, not the URL basename.
+	// Second download into a fresh dir: bare URLs are revalidated, and the
+	// 304 cache hit must still produce the Content-Disposition filename, not
+	// the URL basename.
 	dir2 := t.TempDir()
 	r2, err := d.Download(ctx, &downloaders.DownloadRequest{
 		ID: url, OutputDir: dir2,
@@ -443,8 +460,8 @@ func TestDownload_ContentDispositionFromCacheHit(t *testing.T) {
 	if err != nil || !r2.Success {
 		t.Fatalf("second Download failed: err=%v result=%+v", err, r2)
 	}
-	if getCount != 1 {
-		t.Errorf("expected no further GET on cache hit, got %d total", getCount)
+	if getCount != 1 || notModified != 1 {
+		t.Errorf("expected one conditional GET on cache hit, got %d full and %d conditional", getCount, notModified)
 	}
 	if len(r2.Files) != 1 || !r2.Files[0].CacheHit {
 		t.Errorf("expected a cache hit on second download, got %+v", r2.Files)
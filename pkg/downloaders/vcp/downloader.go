@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/btraven00/hapiq/pkg/cache"
 	"github.com/btraven00/hapiq/pkg/downloaders"
 	"github.com/btraven00/hapiq/internal/version"
 	"github.com/btraven00/hapiq/pkg/downloaders/common"
@@ -64,6 +65,9 @@ func NewVCPDownloader(opts ...Option) *VCPDownloader {
 // GetSourceType returns the source identifier.
 func (d *VCPDownloader) GetSourceType() string { return "vcp" }
 
+// HasCredentials reports whether a VCP token is configured.
+func (d *VCPDownloader) HasCredentials() bool { return d.c.token != "" }
+
 // Validate checks that the id is a 24-char hex VCP dataset ID.
 func (d *VCPDownloader) Validate(_ context.Context, id string) (*downloaders.ValidationResult, error) {
 	clean := strings.ToLower(strings.TrimSpace(id))
@@ -147,7 +151,7 @@ func (d *VCPDownloader) Download(ctx context.Context, req *downloaders.DownloadR
 		if d.verbose {
 			fmt.Printf("⬇️  %s\n", f.name)
 		}
-		fi, err := d.downloadFile(ctx, f.httpsURL, filepath.Join(req.OutputDir, f.name))
+		fi, err := d.downloadFile(ctx, f.httpsURL, filepath.Join(req.OutputDir, f.name), opts != nil && opts.Resume)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("failed %s: %v", f.name, err))
 			continue
@@ -237,7 +241,7 @@ func collectFiles(rec *DatasetRecord) []vcpFile {
 	return files
 }
 
-func (d *VCPDownloader) downloadFile(ctx context.Context, rawURL, targetPath string) (*downloaders.FileInfo, error) {
+func (d *VCPDownloader) downloadFile(ctx context.Context, rawURL, targetPath string, resume bool) (*downloaders.FileInfo, error) {
 	// Pre-flight HEAD to surface auth errors before touching the cache.
 	head, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, http.NoBody)
 	if err != nil {
@@ -250,7 +254,7 @@ func (d *VCPDownloader) downloadFile(ctx context.Context, rawURL, targetPath str
 		}
 	}
 
-	result, err := common.Fetch(ctx, rawURL, targetPath, common.FetchOptions{Client: d.c.http})
+	result, err := common.Fetch(ctx, rawURL, targetPath, common.FetchOptions{Client: d.c.http, Retry: common.RetryPolicyFor(d.GetSourceType()), Freshness: cache.FreshnessFor(d.GetSourceType()), Resume: resume})
 	if err != nil {
 		return nil, err
 	}
@@ -264,6 +268,7 @@ func (d *VCPDownloader) downloadFile(ctx context.Context, rawURL, targetPath str
 		DownloadTime: time.Now(),
 		ContentType:  result.ContentType,
 		CacheHit:     result.Hit,
+		Resumed:      result.Resumed,
 	}, nil
 }
 
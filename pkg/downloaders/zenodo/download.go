@@ -143,19 +143,28 @@ func (dm *DownloadManager) downloadWorker(ctx context.Context, wg *sync.WaitGrou
 	}
 }
 
-// ValidateDownload verifies the integrity of a downloaded file.
+// ValidateDownload verifies the integrity of a downloaded file against a
+// checksum in Zenodo's "type:hex" form; checksumType applies when
+// expectedChecksum carries no type prefix.
 func (d *ZenodoDownloader) ValidateDownload(filePath string, expectedChecksum string, checksumType string) error {
 	if expectedChecksum == "" {
 		return nil // No checksum to validate against
 	}
+	if checksumType != "" && !strings.Contains(expectedChecksum, ":") {
+		expectedChecksum = checksumType + ":" + expectedChecksum
+	}
+	expected, ok := common.ParseChecksum(expectedChecksum)
+	if !ok {
+		return fmt.Errorf("unsupported checksum %q", expectedChecksum)
+	}
 
-	actualChecksum, err := common.CalculateFileChecksum(filePath)
+	actualChecksum, err := common.FileChecksum(filePath, expected.Type)
 	if err != nil {
 		return fmt.Errorf("failed to calculate checksum: %w", err)
 	}
 
-	if !strings.EqualFold(actualChecksum, expectedChecksum) {
-		return fmt.Errorf("checksum mismatch: expected %s, got %s", expectedChecksum, actualChecksum)
+	if !common.ChecksumsEqual(expected.Type, expected.Value, actualChecksum) {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected.Value, actualChecksum)
 	}
 
 	return nil
@@ -5,15 +5,19 @@ package zenodo
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/btraven00/hapiq/pkg/cache"
 	"github.com/btraven00/hapiq/pkg/downloaders"
 	"github.com/btraven00/hapiq/internal/version"
 	"github.com/btraven00/hapiq/pkg/downloaders/common"
@@ -58,6 +62,7 @@ type ZenodoDownloader struct {
 	client  *http.Client
 	baseURL string
 	apiURL  string
+	token   *ZenodoAccessToken
 	timeout time.Duration
 	verbose bool
 }
@@ -104,6 +109,38 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithAccessToken sets a personal access token, giving access to restricted
+// and embargoed records the token's owner may read. Empty means anonymous.
+func WithAccessToken(token string) Option {
+	return func(d *ZenodoDownloader) {
+		if token == "" {
+			d.token = nil
+			return
+		}
+		d.token = &ZenodoAccessToken{AccessToken: token, TokenType: "Bearer"}
+	}
+}
+
+// HasCredentials reports whether an access token is configured.
+func (d *ZenodoDownloader) HasCredentials() bool {
+	return d.token != nil && !d.token.IsExpired()
+}
+
+// authorization returns the Authorization header value for requests to
+// Zenodo, or "" when no usable token is configured. The token travels in a
+// header, never the URL, so it cannot leak into witnesses or the cache.
+func (d *ZenodoDownloader) authorization() string {
+	if !d.HasCredentials() {
+		return ""
+	}
+	return d.token.TokenType + " " + d.token.AccessToken
+}
+
+// authorize adds the access token to req if it targets Zenodo itself.
+func (d *ZenodoDownloader) authorize(req *http.Request) {
+	common.SetAuthorization(req, d.authorization(), common.HostOf(d.apiURL))
+}
+
 // GetSourceType returns the source type identifier.
 func (d *ZenodoDownloader) GetSourceType() string {
 	return "zenodo"
@@ -144,9 +181,9 @@ func (d *ZenodoDownloader) Validate(ctx context.Context, id string) (*downloader
 	// Try to fetch metadata to verify the artifact exists and is accessible
 	_, err = d.getArtifactMetadata(ctx, identifier)
 	if err != nil {
-		if strings.Contains(err.Error(), "404") {
+		if apiStatus(err) == http.StatusNotFound {
 			result.Errors = append(result.Errors, fmt.Sprintf("%s not found", identifier.Type.String()))
-		} else if strings.Contains(err.Error(), "403") {
+		} else if apiStatus(err) == http.StatusForbidden {
 			result.Errors = append(result.Errors, fmt.Sprintf("access denied - %s may be private or restricted", identifier.Type.String()))
 		} else {
 			result.Errors = append(result.Errors, fmt.Sprintf("failed to validate %s: %v", identifier.Type.String(), err))
@@ -185,6 +222,10 @@ func (d *ZenodoDownloader) GetMetadata(ctx context.Context, id string) (*downloa
 		metadata.Custom = make(map[string]any)
 	}
 	metadata.Custom["artifact_type"] = identifier.Type.String()
+	if strconv.Itoa(record.ID) != identifier.ID {
+		// A concept record ID, redirected to its latest version.
+		metadata.Custom["artifact_type"] = ArtifactTypeConcept.String()
+	}
 	metadata.Custom["is_versioned"] = identifier.IsVersioned
 	metadata.Custom["original_identifier"] = identifier.OriginalText
 
@@ -202,32 +243,43 @@ func (d *ZenodoDownloader) Download(ctx context.Context, req *downloaders.Downlo
 		Duration: 0,
 	}
 
-	// Get the record metadata if not provided
-	var record *ZenodoRecord
-	var err error
-	if req.Metadata == nil {
-		record, err = d.getRecordMetadata(ctx, req.ID)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("failed to get metadata: %v", err))
-			result.Duration = time.Since(startTime)
-			return result, nil
-		}
-		result.Metadata = d.convertToMetadata(record, req.ID)
-	} else {
-		// Still get the record for file information
-		record, err = d.getRecordMetadata(ctx, req.ID)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("failed to get record details: %v", err))
-			result.Duration = time.Since(startTime)
-			return result, nil
-		}
+	var version string
+	if req.Options != nil {
+		version = req.Options.Version
+	}
+	if strings.EqualFold(version, versionAll) {
+		return d.downloadAllVersions(ctx, req, startTime), nil
+	}
+
+	id := req.ID
+	if clean, err := d.cleanZenodoID(req.ID); err == nil {
+		id = clean
 	}
 
+	// Resolve the record (a concept ID or --version picks a specific one).
+	record, err := d.resolveRecord(ctx, id, version)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to get record details: %v", err))
+		result.Duration = time.Since(startTime)
+		return result, nil
+	}
+	// Metadata passed in describes whatever GetMetadata resolved; rebuild it
+	// when a different version was selected.
+	if req.Metadata == nil || req.Metadata.Custom["record_id"] != strconv.Itoa(record.ID) {
+		result.Metadata = d.convertToMetadata(record, id)
+	}
+
+	return d.downloadRecord(ctx, req, record, result, startTime), nil
+}
+
+// downloadRecord downloads the files of an already resolved record into
+// req.OutputDir and writes its witness.
+func (d *ZenodoDownloader) downloadRecord(ctx context.Context, req *downloaders.DownloadRequest, record *ZenodoRecord, result *downloaders.DownloadResult, startTime time.Time) *downloaders.DownloadResult {
 	// Create output directory
 	if err := os.MkdirAll(req.OutputDir, 0o750); err != nil {
 		result.Errors = append(result.Errors, fmt.Sprintf("failed to create output directory: %v", err))
 		result.Duration = time.Since(startTime)
-		return result, nil
+		return result
 	}
 
 	// Filter files based on options
@@ -236,7 +288,7 @@ func (d *ZenodoDownloader) Download(ctx context.Context, req *downloaders.Downlo
 		result.Warnings = append(result.Warnings, "no files match the download criteria")
 		result.Success = true
 		result.Duration = time.Since(startTime)
-		return result, nil
+		return result
 	}
 
 	// Calculate total bytes
@@ -263,7 +315,7 @@ func (d *ZenodoDownloader) Download(ctx context.Context, req *downloaders.Downlo
 	}
 
 	// Create witness file
-	witness := d.createWitnessFile(result, req)
+	witness := d.createWitnessFile(result, req, record)
 	if err := common.WriteWitnessFile(req.OutputDir, witness); err != nil {
 		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to create witness file: %v", err))
 	} else {
@@ -273,7 +325,7 @@ func (d *ZenodoDownloader) Download(ctx context.Context, req *downloaders.Downlo
 	result.Success = len(result.Errors) == 0
 	result.Duration = time.Since(startTime)
 
-	return result, nil
+	return result
 }
 
 // parseZenodoIdentifier extracts and validates Zenodo identifiers, detecting artifact type.
@@ -389,6 +441,7 @@ func (d *ZenodoDownloader) getArtifactMetadata(ctx context.Context, identifier *
 
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", "hapiq/1.0")
+	d.authorize(req)
 
 	resp, err := d.client.Do(req)
 	if err != nil {
@@ -397,13 +450,15 @@ func (d *ZenodoDownloader) getArtifactMetadata(ctx context.Context, identifier *
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 404 {
-		return nil, d.formatNotFoundError(identifier)
+		return nil, &apiError{StatusCode: resp.StatusCode, err: d.formatNotFoundError(identifier)}
 	}
 	if resp.StatusCode == 403 {
-		return nil, fmt.Errorf("access denied (403) - %s may be private or restricted", identifier.Type.String())
+		return nil, &apiError{StatusCode: resp.StatusCode,
+			err: fmt.Errorf("access denied (403) - %s may be private or restricted", identifier.Type.String())}
 	}
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API returned status %d for %s %s", resp.StatusCode, identifier.Type.String(), identifier.ID)
+		return nil, &apiError{StatusCode: resp.StatusCode,
+			err: fmt.Errorf("API returned status %d for %s %s", resp.StatusCode, identifier.Type.String(), identifier.ID)}
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -429,6 +484,25 @@ func (d *ZenodoDownloader) getRecordMetadata(ctx context.Context, id string) (*Z
 	return d.getArtifactMetadata(ctx, identifier)
 }
 
+// apiError is a non-success status from the Zenodo API, so callers can
+// branch on the status rather than on the message.
+type apiError struct {
+	StatusCode int
+	err        error
+}
+
+func (e *apiError) Error() string { return e.err.Error() }
+func (e *apiError) Unwrap() error { return e.err }
+
+// apiStatus returns the HTTP status of an apiError in err's chain, or 0.
+func apiStatus(err error) int {
+	var ae *apiError
+	if errors.As(err, &ae) {
+		return ae.StatusCode
+	}
+	return 0
+}
+
 // formatNotFoundError provides context-aware error messages for different artifact types.
 func (d *ZenodoDownloader) formatNotFoundError(identifier *ZenodoIdentifier) error {
 	switch identifier.Type {
@@ -500,7 +574,12 @@ func (d *ZenodoDownloader) convertToMetadata(record *ZenodoRecord, id string) *d
 	// Add custom fields
 	metadata.Custom["conceptdoi"] = record.ConceptDOI
 	metadata.Custom["conceptrecid"] = record.ConceptRecID
+	metadata.Custom["record_id"] = strconv.Itoa(record.ID)
 	metadata.Custom["state"] = record.State
+	metadata.Custom["revision"] = record.Revision
+	if latest := path.Base(record.Links.Latest); record.Links.Latest != "" && latest != strconv.Itoa(record.ID) {
+		metadata.Custom["latest_version"] = latest
+	}
 	metadata.Custom["submitted"] = record.Submitted
 	metadata.Custom["published"] = record.Metadata.PublicationDate
 
@@ -598,9 +677,19 @@ func (d *ZenodoDownloader) downloadFile(ctx context.Context, file ZenodoFile, ou
 		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
 
+	// Zenodo publishes "md5:<hex>" per file; Fetch verifies it.
+	expected, _ := common.ParseChecksum(file.Checksum)
+	headers := map[string]string{"User-Agent": "hapiq/1.0"}
+	if auth := d.authorization(); auth != "" && common.HostWithin(file.Links.Self, common.HostOf(d.apiURL)) {
+		headers["Authorization"] = auth
+	}
 	result, err := common.Fetch(ctx, file.Links.Self, outputPath, common.FetchOptions{
 		Client:       d.client,
-		ExtraHeaders: map[string]string{"User-Agent": "hapiq/1.0"},
+		ExtraHeaders: headers,
+		Retry:        common.RetryPolicyFor(d.GetSourceType()),
+		Freshness:    cache.FreshnessFor(d.GetSourceType()),
+		Resume:       options != nil && options.Resume,
+		Expected:     expected,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("fetch %s: %w", file.Key, err)
@@ -616,16 +705,21 @@ func (d *ZenodoDownloader) downloadFile(ctx context.Context, file ZenodoFile, ou
 		ChecksumType: "sha256",
 		DownloadTime: time.Now(),
 		CacheHit:     result.Hit,
+		Resumed:      result.Resumed,
+		Verification: result.Verification,
 	}, nil
 }
 
 // createWitnessFile creates a witness file for provenance tracking.
-func (d *ZenodoDownloader) createWitnessFile(result *downloaders.DownloadResult, req *downloaders.DownloadRequest) *downloaders.WitnessFile {
+// The witness records the exact record downloaded, so a concept ID or a
+// version selector is pinned by `manifest gen`.
+func (d *ZenodoDownloader) createWitnessFile(result *downloaders.DownloadResult, req *downloaders.DownloadRequest, record *ZenodoRecord) *downloaders.WitnessFile {
+	recordID := strconv.Itoa(record.ID)
 	witness := &downloaders.WitnessFile{
 		HapiqVersion: version.String(),
 		Source:       d.GetSourceType(),
 		OriginalID:   req.ID,
-		ResolvedURL:  fmt.Sprintf("%s/record/%s", d.baseURL, req.ID),
+		ResolvedURL:  fmt.Sprintf("%s/record/%s", d.baseURL, recordID),
 		DownloadTime: time.Now(),
 		Metadata:     result.Metadata,
 		Options:      req.Options,
@@ -639,22 +733,17 @@ func (d *ZenodoDownloader) createWitnessFile(result *downloaders.DownloadResult,
 			FilesSkipped:    0, // TODO: Track this properly
 			FilesFailed:     len(result.Errors),
 			AverageSpeed:    downloaders.Speed(result.BytesDownloaded, result.Duration),
-			ResumedDownload: false, // TODO: Implement resume functionality
+			ResumedDownload: downloaders.AnyResumed(result.Files),
 		},
 	}
 
+	if recordID != req.ID {
+		witness.ResolvedID = recordID
+	}
+
 	// Convert FileInfo to FileWitness
 	for _, file := range result.Files {
-		witness.Files = append(witness.Files, downloaders.FileWitness{
-			Path:         file.Path,
-			OriginalName: file.OriginalName,
-			SourceURL:    file.SourceURL,
-			ContentType:  file.ContentType,
-			Size:         file.Size,
-			Checksum:     file.Checksum,
-			ChecksumType: file.ChecksumType,
-			DownloadTime: file.DownloadTime,
-		})
+		witness.Files = append(witness.Files, downloaders.FileWitness(file))
 	}
 
 	return witness
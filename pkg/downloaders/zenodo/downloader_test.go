@@ -3,6 +3,7 @@
 package zenodo
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"net/http"
@@ -11,9 +12,12 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/spf13/viper"
+
 	"github.com/btraven00/hapiq/pkg/downloaders"
 )
 
@@ -59,7 +63,7 @@ func createTestRecord(serverURL string) ZenodoRecord {
 				ID:       "file1",
 				Key:      "data.csv",
 				Size:     900, // "test,data\n" * 100 = 9 * 100 = 900 bytes
-				Checksum: "d41d8cd98f00b204e9800998ecf8427e",
+				Checksum: "md5:b5ec80660f775fbb9de952045cd8f008",
 				Type:     "csv",
 				Links: ZenodoFileLinks{
 					Self: serverURL + "/api/files/bucket1/data.csv",
@@ -69,7 +73,7 @@ func createTestRecord(serverURL string) ZenodoRecord {
 				ID:       "file2",
 				Key:      "readme.txt",
 				Size:     560, // "This is a test readme file.\n" * 20 = 28 * 20 = 560 bytes
-				Checksum: "098f6bcd4621d373cade4e832627b4f6",
+				Checksum: "md5:82560bb346916cb8dc88327790f4fb9d",
 				Type:     "txt",
 				Links: ZenodoFileLinks{
 					Self: serverURL + "/api/files/bucket1/readme.txt",
@@ -584,6 +588,73 @@ func TestZenodoDownloader_Download(t *testing.T) {
 	}
 }
 
+func TestZenodoDownloader_DownloadRecordsResume(t *testing.T) {
+	viper.Set("retry.zenodo.initial_backoff", "1ms")
+	t.Cleanup(viper.Reset)
+
+	body := []byte(strings.Repeat("test,data\n", 1000))
+	var gets int32
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/records/", func(w http.ResponseWriter, r *http.Request) {
+		record := createTestRecord(server.URL)
+		record.Files = record.Files[:1]
+		record.Files[0].Size = int64(len(body))
+		record.Files[0].Checksum = ""
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(record)
+	})
+	// The first GET drops after 4000 bytes; the retry must continue it.
+	mux.HandleFunc("/api/files/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Method == http.MethodGet && atomic.AddInt32(&gets, 1) == 1 {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(body[:4000])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	downloader := NewZenodoDownloader()
+	downloader.apiURL = server.URL + "/api/records"
+	downloader.baseURL = server.URL
+
+	req := &downloaders.DownloadRequest{
+		ID:        "123456",
+		OutputDir: t.TempDir(),
+		Options:   &downloaders.DownloadOptions{IncludeRaw: true, MaxConcurrent: 1, Resume: true},
+	}
+	result, err := downloader.Download(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if !result.Success || len(result.Files) != 1 {
+		t.Fatalf("Download() success=%v files=%d, errors: %v", result.Success, len(result.Files), result.Errors)
+	}
+	if !result.Files[0].Resumed {
+		t.Error("Files[0].Resumed = false, want true")
+	}
+
+	witness, err := os.ReadFile(filepath.Join(req.OutputDir, "hapiq.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var w downloaders.WitnessFile
+	if err := json.Unmarshal(witness, &w); err != nil {
+		t.Fatal(err)
+	}
+	if w.DownloadStats == nil || !w.DownloadStats.ResumedDownload {
+		t.Errorf("witness download_stats = %+v, want resumed_download", w.DownloadStats)
+	}
+	if len(w.Files) != 1 || !w.Files[0].Resumed {
+		t.Errorf("witness files = %+v, want one resumed file", w.Files)
+	}
+}
+
 func TestZenodoDownloader_ShouldDownloadFile(t *testing.T) {
 	downloader := NewZenodoDownloader()
 
@@ -828,3 +899,60 @@ func BenchmarkZenodoDownloader_ShouldDownloadFile(b *testing.B) {
 		_ = downloader.shouldDownloadFile(file, options)
 	}
 }
+
+func TestZenodoDownloader_AccessToken(t *testing.T) {
+	var foreignAuth string
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		foreignAuth = r.Header.Get("Authorization")
+		w.Write([]byte("external\n"))
+	}))
+	defer foreign.Close()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/records/7":
+			json.NewEncoder(w).Encode(ZenodoRecord{ID: 7, Title: "Restricted", Files: []ZenodoFile{
+				{Key: "data.txt", Links: ZenodoFileLinks{Self: server.URL + "/api/records/7/files/data.txt/content"}},
+				// Same port, different host name: must not receive the token.
+				{Key: "ext.txt", Links: ZenodoFileLinks{Self: strings.Replace(foreign.URL, "127.0.0.1", "localhost", 1) + "/ext.txt"}},
+			}})
+		default:
+			w.Write([]byte("restricted\n"))
+		}
+	}))
+	defer server.Close()
+
+	d := NewZenodoDownloader(WithAccessToken("tok"))
+	d.apiURL = server.URL + "/api/records"
+	d.baseURL = server.URL
+	if !d.HasCredentials() {
+		t.Fatal("HasCredentials() = false with a token")
+	}
+
+	out := t.TempDir()
+	result, err := d.Download(context.Background(), &downloaders.DownloadRequest{
+		ID: "7", OutputDir: out, Options: &downloaders.DownloadOptions{},
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("Download() failed: %v %v", err, result.Errors)
+	}
+	if _, err := os.Stat(filepath.Join(out, "ext.txt")); err != nil {
+		t.Fatalf("external file not downloaded: %v", err)
+	}
+	if foreignAuth != "" {
+		t.Errorf("token sent to a third-party host: %q", foreignAuth)
+	}
+
+	witness, err := os.ReadFile(filepath.Join(out, "hapiq.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(witness), "tok\"") || strings.Contains(string(witness), "Bearer") {
+		t.Error("access token written to hapiq.json")
+	}
+}
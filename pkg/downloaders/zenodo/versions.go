@@ -0,0 +1,229 @@
+package zenodo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btraven00/hapiq/pkg/downloaders"
+	"github.com/btraven00/hapiq/pkg/downloaders/common"
+)
+
+// Version selectors accepted in DownloadOptions.Version.
+const (
+	versionLatest = "latest"
+	versionAll    = "all"
+)
+
+// versionsPageSize is the page size of the versions listing.
+const versionsPageSize = 100
+
+// versionsResponse is the search envelope of /records/{id}/versions. Further
+// pages are linked from Links.Next.
+type versionsResponse struct {
+	Hits struct {
+		Hits  []ZenodoRecord `json:"hits"`
+		Total int            `json:"total"`
+	} `json:"hits"`
+	Links struct {
+		Next string `json:"next"`
+	} `json:"links"`
+}
+
+// resolveRecord returns the record id refers to, honoring a version selector.
+// id may be a record or a concept record ID. With no selector (or "latest")
+// a record ID resolves to itself and a concept ID to its latest version;
+// any other selector picks a version of the same concept by version string
+// or record ID.
+func (d *ZenodoDownloader) resolveRecord(ctx context.Context, id, version string) (*ZenodoRecord, error) {
+	if version == "" || strings.EqualFold(version, versionLatest) {
+		// Zenodo redirects a concept record ID to its latest version.
+		record, err := d.getRecordMetadata(ctx, id)
+		if err == nil || apiStatus(err) != http.StatusNotFound {
+			return record, err
+		}
+		versions, verr := d.listVersions(ctx, id)
+		if verr != nil || len(versions) == 0 {
+			return nil, err
+		}
+		return &versions[len(versions)-1], nil
+	}
+
+	versions, err := d.listVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versions {
+		if versionMatches(&versions[i], version) {
+			return &versions[i], nil
+		}
+	}
+	available := make([]string, 0, len(versions))
+	for i := range versions {
+		available = append(available, versionLabel(&versions[i]))
+	}
+	return nil, fmt.Errorf("version %q not found for Zenodo record %s (available: %s)",
+		version, id, strings.Join(available, ", "))
+}
+
+// listVersions returns every published version of the concept that id (a
+// record or concept record ID) belongs to, oldest first, following the
+// listing's next links across pages.
+func (d *ZenodoDownloader) listVersions(ctx context.Context, id string) ([]ZenodoRecord, error) {
+	url := fmt.Sprintf("%s/%s/versions?size=%d&sort=version&allversions=true", d.apiURL, id, versionsPageSize)
+	var versions []ZenodoRecord
+	total := 0
+	for url != "" {
+		page, err := d.getVersionsPage(ctx, url, id)
+		if err != nil {
+			return nil, err
+		}
+		if len(page.Hits.Hits) == 0 {
+			break
+		}
+		versions = append(versions, page.Hits.Hits...)
+		total = page.Hits.Total
+		url = page.Links.Next
+	}
+	if total > len(versions) {
+		return nil, fmt.Errorf("Zenodo listed %d of %d versions of %s", len(versions), total, id)
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versionBefore(&versions[i], &versions[j])
+	})
+	return versions, nil
+}
+
+// getVersionsPage fetches one page of a versions listing.
+func (d *ZenodoDownloader) getVersionsPage(ctx context.Context, url, id string) (*versionsResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "hapiq/1.0")
+	d.authorize(req)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &apiError{StatusCode: resp.StatusCode,
+			err: fmt.Errorf("API returned status %d listing versions of %s", resp.StatusCode, id)}
+	}
+
+	var vr versionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&vr); err != nil {
+		return nil, fmt.Errorf("failed to parse versions response: %w", err)
+	}
+	return &vr, nil
+}
+
+// versionBefore orders records by their (0-based) position in the concept,
+// falling back to record IDs, which grow with each new version.
+func versionBefore(a, b *ZenodoRecord) bool {
+	ra, rb := a.Metadata.Relations.Version, b.Metadata.Relations.Version
+	if len(ra) > 0 && len(rb) > 0 && ra[0].Index != rb[0].Index {
+		return ra[0].Index < rb[0].Index
+	}
+	return a.ID < b.ID
+}
+
+// versionMatches reports whether selector names r, by record ID or by its
+// version string with or without a leading "v".
+func versionMatches(r *ZenodoRecord, selector string) bool {
+	if selector == strconv.Itoa(r.ID) {
+		return true
+	}
+	v := r.Metadata.Version
+	return v != "" && strings.EqualFold(strings.TrimPrefix(strings.ToLower(v), "v"), strings.TrimPrefix(strings.ToLower(selector), "v"))
+}
+
+// versionLabel names r for messages and subdirectories: its version string
+// when it has one, else its record ID.
+func versionLabel(r *ZenodoRecord) string {
+	if r.Metadata.Version != "" {
+		return r.Metadata.Version
+	}
+	return strconv.Itoa(r.ID)
+}
+
+// versionDirs assigns each version a distinct subdirectory name.
+func versionDirs(versions []ZenodoRecord) []string {
+	dirs := make([]string, len(versions))
+	seen := map[string]bool{}
+	for i := range versions {
+		name := common.SanitizeFilename(versionLabel(&versions[i]))
+		if seen[name] {
+			name = fmt.Sprintf("%s_%d", name, versions[i].ID)
+		}
+		seen[name] = true
+		dirs[i] = name
+	}
+	return dirs
+}
+
+// downloadAllVersions downloads every version of req.ID's concept into its
+// own subdirectory of req.OutputDir, each with its own witness file.
+func (d *ZenodoDownloader) downloadAllVersions(ctx context.Context, req *downloaders.DownloadRequest, startTime time.Time) *downloaders.DownloadResult {
+	result := &downloaders.DownloadResult{Metadata: req.Metadata, Files: []downloaders.FileInfo{}}
+
+	id := req.ID
+	if clean, err := d.cleanZenodoID(req.ID); err == nil {
+		id = clean
+	}
+	versions, err := d.listVersions(ctx, id)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to list versions: %v", err))
+		result.Duration = time.Since(startTime)
+		return result
+	}
+
+	var opts downloaders.DownloadOptions
+	if req.Options != nil {
+		opts = *req.Options
+	}
+	opts.Version = ""
+
+	for i, dir := range versionDirs(versions) {
+		record := &versions[i]
+		recordID := strconv.Itoa(record.ID)
+		if d.verbose {
+			_, _ = fmt.Fprintf(os.Stderr, "Version %s (record %s)\n", versionLabel(record), recordID)
+		}
+
+		sub := d.downloadRecord(ctx, &downloaders.DownloadRequest{
+			ID:        recordID,
+			OutputDir: filepath.Join(req.OutputDir, dir),
+			Options:   &opts,
+		}, record, &downloaders.DownloadResult{
+			Metadata: d.convertToMetadata(record, recordID),
+			Files:    []downloaders.FileInfo{},
+		}, time.Now())
+
+		for _, e := range sub.Errors {
+			result.Errors = append(result.Errors, dir+": "+e)
+		}
+		for _, w := range sub.Warnings {
+			result.Warnings = append(result.Warnings, dir+": "+w)
+		}
+		result.Files = append(result.Files, sub.Files...)
+		result.BytesTotal += sub.BytesTotal
+		result.BytesDownloaded += sub.BytesDownloaded
+	}
+
+	result.Success = len(result.Errors) == 0
+	result.Duration = time.Since(startTime)
+	return result
+}
@@ -0,0 +1,186 @@
+package zenodo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btraven00/hapiq/pkg/downloaders"
+	"github.com/btraven00/hapiq/pkg/downloaders/common"
+)
+
+// mockVersionedServer serves concept 100 with versions 101 ("1.0") and 102
+// ("2.0"), one version per page of the listing. The concept ID redirects to
+// the latest version, as on Zenodo.
+func mockVersionedServer(t *testing.T) (*httptest.Server, *ZenodoDownloader) {
+	t.Helper()
+	var server *httptest.Server
+	record := func(id int, version string, index int) ZenodoRecord {
+		return ZenodoRecord{
+			ID:           id,
+			ConceptRecID: "100",
+			ConceptDOI:   "10.5281/zenodo.100",
+			Title:        "Versioned",
+			Metadata: ZenodoMetadata{
+				Title:     "Versioned",
+				Version:   version,
+				Relations: ZenodoRelations{Version: []ZenodoVersionRelation{{Index: index}}},
+			},
+			Files: []ZenodoFile{{
+				Key:   "data.txt",
+				Size:  int64(len(fmt.Sprintf("v%d\n", id))),
+				Links: ZenodoFileLinks{Self: fmt.Sprintf("%s/files/%d/data.txt", server.URL, id)},
+			}},
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/records/", func(w http.ResponseWriter, r *http.Request) {
+		id, versions := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/records/"), "/versions")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case versions:
+			var vr versionsResponse
+			// Newest first, as the API returns them.
+			if r.URL.Query().Get("page") == "2" {
+				vr.Hits.Hits = []ZenodoRecord{record(101, "1.0", 0)}
+			} else {
+				vr.Hits.Hits = []ZenodoRecord{record(102, "2.0", 1)}
+				vr.Links.Next = server.URL + r.URL.Path + "?page=2"
+			}
+			vr.Hits.Total = 2
+			_ = json.NewEncoder(w).Encode(vr)
+		case id == "100":
+			http.Redirect(w, r, "/api/records/102", http.StatusFound)
+		case id == "101":
+			_ = json.NewEncoder(w).Encode(record(101, "1.0", 0))
+		case id == "102":
+			_ = json.NewEncoder(w).Encode(record(102, "2.0", 1))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Split(strings.TrimPrefix(r.URL.Path, "/files/"), "/")[0]
+		_, _ = fmt.Fprintf(w, "v%s\n", id)
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	d := NewZenodoDownloader()
+	d.apiURL = server.URL + "/api/records"
+	d.baseURL = server.URL
+	return server, d
+}
+
+func TestResolveRecord(t *testing.T) {
+	_, d := mockVersionedServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		id, version string
+		want        int
+		wantErr     bool
+	}{
+		{id: "100", want: 102},
+		{id: "100", version: "latest", want: 102},
+		{id: "101", want: 101},
+		{id: "102", version: "1.0", want: 101},
+		{id: "100", version: "v2.0", want: 102},
+		{id: "100", version: "101", want: 101},
+		{id: "100", version: "3.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id+"@"+tt.version, func(t *testing.T) {
+			rec, err := d.resolveRecord(ctx, tt.id, tt.version)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "available: 1.0, 2.0") {
+					t.Errorf("err = %v, want not found listing available versions", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rec.ID != tt.want {
+				t.Errorf("resolved record %d, want %d", rec.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownload_ConceptPinsResolvedVersion(t *testing.T) {
+	_, d := mockVersionedServer(t)
+	out := t.TempDir()
+
+	result, err := d.Download(context.Background(), &downloaders.DownloadRequest{
+		ID: "100", OutputDir: out, Options: &downloaders.DownloadOptions{},
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("download failed: %v %v", err, result.Errors)
+	}
+	if got := result.Metadata.Custom["record_id"]; got != "102" {
+		t.Errorf("record_id = %v, want 102", got)
+	}
+	if got := result.Metadata.Custom["conceptdoi"]; got != "10.5281/zenodo.100" {
+		t.Errorf("conceptdoi = %v", got)
+	}
+
+	w, err := common.LoadWitnessFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.OriginalID != "100" || w.ResolvedID != "102" {
+		t.Errorf("witness original_id=%q resolved_id=%q, want 100 and 102", w.OriginalID, w.ResolvedID)
+	}
+}
+
+func TestDownload_AllVersions(t *testing.T) {
+	_, d := mockVersionedServer(t)
+	out := t.TempDir()
+
+	result, err := d.Download(context.Background(), &downloaders.DownloadRequest{
+		ID: "100", OutputDir: out, Options: &downloaders.DownloadOptions{Version: "all"},
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("download failed: %v %v", err, result.Errors)
+	}
+	if len(result.Files) != 2 {
+		t.Fatalf("downloaded %d files, want 2", len(result.Files))
+	}
+
+	for dir, want := range map[string]string{"1.0": "v101\n", "2.0": "v102\n"} {
+		data, err := os.ReadFile(filepath.Join(out, dir, "data.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("%s/data.txt = %q, want %q", dir, data, want)
+		}
+		if _, err := common.LoadWitnessFile(filepath.Join(out, dir)); err != nil {
+			t.Errorf("%s: missing witness: %v", dir, err)
+		}
+	}
+}
+
+func TestListVersions_Incomplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var vr versionsResponse
+		vr.Hits.Hits = []ZenodoRecord{{ID: 101}}
+		vr.Hits.Total = 3
+		_ = json.NewEncoder(w).Encode(vr)
+	}))
+	defer server.Close()
+
+	d := NewZenodoDownloader()
+	d.apiURL = server.URL + "/api/records"
+	if _, err := d.listVersions(context.Background(), "100"); err == nil || !strings.Contains(err.Error(), "1 of 3") {
+		t.Errorf("listVersions error = %v, want a short listing reported", err)
+	}
+}
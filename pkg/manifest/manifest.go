@@ -1,19 +1,14 @@
 // Package manifest defines a YAML-based DSL for declaring datasets to download
 // and verify in bulk. A manifest lists entries; each entry maps a canonical
-// accession (compact "source:id" form) to a folder identifier and an optional
-// list of expected files with hashes for post-download verification.
+// accession (compact "source:id" form, or a bare ID whose source is detected
+// at run time) to a folder identifier and an optional list of expected files
+// with hashes for post-download verification.
 package manifest
 
 import (
 	"bytes"
-	"crypto/md5"  // #nosec G501 -- used only for upstream checksum verification
-	"crypto/sha1" // #nosec G505 -- used only for upstream checksum verification
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"hash"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -21,6 +16,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/btraven00/hapiq/pkg/downloaders"
+	"github.com/btraven00/hapiq/pkg/downloaders/common"
 )
 
 // Entry declares one dataset to download into <parent>/<identifier>.
@@ -52,6 +48,7 @@ type Options struct {
 	ExcludeSupplementary bool     `yaml:"exclude_supplementary,omitempty"`
 	IncludeSRA           bool     `yaml:"include_sra,omitempty"`
 	LimitFiles           int      `yaml:"limit_files,omitempty"`
+	Version              string   `yaml:"version,omitempty"`
 }
 
 // Load parses a manifest YAML file from disk. The top-level document is a
@@ -78,7 +75,7 @@ func Load(path string) ([]Entry, error) {
 		if e.Accession == "" && e.URL == "" {
 			return nil, fmt.Errorf("entry[%d] %q: missing accession or url", i, e.Identifier)
 		}
-		if e.Accession != "" {
+		if e.Accession != "" && HasSourcePrefix(e.Accession) {
 			if _, _, err := SplitAccession(e.Accession); err != nil {
 				return nil, fmt.Errorf("entry[%d] %q: %w", i, e.Identifier, err)
 			}
@@ -88,14 +85,26 @@ func Load(path string) ([]Entry, error) {
 }
 
 // ResolveSource returns (source, id) for an entry, handling both the
-// "accession: source:id" form and the "url: https://..." shorthand.
+// "accession: source:id" form and the "url: https://..." shorthand. For a
+// bare accession (no source prefix) source is empty and the caller is
+// expected to auto-detect it.
 func ResolveSource(e Entry) (source, id string, err error) {
 	if e.URL != "" {
 		return "url", e.URL, nil
 	}
+	if !HasSourcePrefix(e.Accession) {
+		return "", strings.TrimSpace(e.Accession), nil
+	}
 	return SplitAccession(e.Accession)
 }
 
+// HasSourcePrefix reports whether acc uses the "source:id" form. Bare IDs
+// (GSE123456, 10.5281/zenodo.123) and URLs (https://...) do not.
+func HasSourcePrefix(acc string) bool {
+	idx := strings.Index(acc, ":")
+	return idx >= 0 && !strings.HasPrefix(acc[idx+1:], "//")
+}
+
 // SplitAccession parses "source:id" into its parts.
 func SplitAccession(acc string) (source, id string, err error) {
 	idx := strings.Index(acc, ":")
@@ -121,6 +130,9 @@ func FromWitness(witnessPath string) (*Entry, error) {
 	}
 	if w.Source == "url" {
 		entry.URL = w.OriginalID
+	} else if w.ResolvedID != "" {
+		// Pin the exact version that was downloaded, not the moving target.
+		entry.Accession = fmt.Sprintf("%s:%s", w.Source, w.ResolvedID)
 	} else {
 		entry.Accession = fmt.Sprintf("%s:%s", w.Source, w.OriginalID)
 	}
@@ -153,6 +165,8 @@ func RenderEntry(e *Entry) (string, error) {
 
 // VerifyFile checks a file at path against an "<algo>:<hex>" hash spec. An
 // empty spec is a no-op (returns nil). Unknown algorithms return an error.
+// Any algorithm common.NewHasher supports is accepted (md5, sha1, sha256,
+// sha512, crc32c, sum).
 func VerifyFile(path, spec string) error {
 	if spec == "" {
 		return nil
@@ -161,27 +175,15 @@ func VerifyFile(path, spec string) error {
 	if !ok || want == "" {
 		return fmt.Errorf("invalid hash spec %q (want algo:hex)", spec)
 	}
-	var h hash.Hash
-	switch strings.ToLower(algo) {
-	case "md5":
-		h = md5.New() // #nosec G401 -- checksum verification, not security
-	case "sha1":
-		h = sha1.New() // #nosec G401 -- checksum verification, not security
-	case "sha256":
-		h = sha256.New()
-	default:
+	algo = strings.ToLower(algo)
+	if _, err := common.NewHasher(algo); err != nil {
 		return fmt.Errorf("unsupported hash algorithm %q", algo)
 	}
-	f, err := os.Open(filepath.Clean(path)) // #nosec G304 -- user-specified file to verify
+	got, err := common.FileChecksum(path, algo)
 	if err != nil {
-		return fmt.Errorf("open %s: %w", path, err)
-	}
-	defer f.Close()
-	if _, err := io.Copy(h, f); err != nil {
 		return fmt.Errorf("read %s: %w", path, err)
 	}
-	got := hex.EncodeToString(h.Sum(nil))
-	if !strings.EqualFold(got, want) {
+	if !common.ChecksumsEqual(algo, want, got) {
 		return fmt.Errorf("hash mismatch for %s: want %s:%s, got %s:%s",
 			path, algo, want, algo, got)
 	}
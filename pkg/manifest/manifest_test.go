@@ -3,6 +3,7 @@ package manifest
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -95,11 +96,25 @@ func TestLoad_MissingIdentifier(t *testing.T) {
 func TestLoad_InvalidAccessionFormat(t *testing.T) {
 	path := writeManifest(t, `
 - identifier: bad
-  accession: no-colon-here
+  accession: "geo:"
 `)
 	_, err := Load(path)
 	if err == nil {
-		t.Error("Load() should fail for accession with no colon")
+		t.Error("Load() should fail for accession with an empty id")
+	}
+}
+
+func TestLoad_BareAccession(t *testing.T) {
+	path := writeManifest(t, `
+- identifier: bare
+  accession: GSE123456
+`)
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if entries[0].Accession != "GSE123456" {
+		t.Errorf("Accession = %q, want %q", entries[0].Accession, "GSE123456")
 	}
 }
 
@@ -161,6 +176,35 @@ func TestResolveSource_URLPreferredOverAccession(t *testing.T) {
 	}
 }
 
+func TestResolveSource_BareAccession(t *testing.T) {
+	for _, acc := range []string{"GSE123456", " 10.5281/zenodo.123 ", "https://figshare.com/articles/x/1"} {
+		src, id, err := ResolveSource(Entry{Identifier: "x", Accession: acc})
+		if err != nil {
+			t.Fatalf("ResolveSource(%q) error: %v", acc, err)
+		}
+		if src != "" {
+			t.Errorf("ResolveSource(%q) source = %q, want empty for auto-detection", acc, src)
+		}
+		if id != strings.TrimSpace(acc) {
+			t.Errorf("ResolveSource(%q) id = %q", acc, id)
+		}
+	}
+}
+
+func TestHasSourcePrefix(t *testing.T) {
+	tests := map[string]bool{
+		"geo:GSE123456":                    true,
+		"url:https://example.com/file.csv": true,
+		"GSE123456":                        false,
+		"https://example.com/file.csv":     false,
+	}
+	for acc, want := range tests {
+		if got := HasSourcePrefix(acc); got != want {
+			t.Errorf("HasSourcePrefix(%q) = %v, want %v", acc, got, want)
+		}
+	}
+}
+
 func TestSplitAccession(t *testing.T) {
 	tests := []struct {
 		acc     string
@@ -246,3 +290,27 @@ func TestFromWitness_RegularSource(t *testing.T) {
 		t.Errorf("URL should be empty for non-url source, got %q", entry.URL)
 	}
 }
+
+func TestFromWitness_PinsResolvedID(t *testing.T) {
+	dir := t.TempDir()
+	witness := `{
+		"source": "zenodo",
+		"original_id": "10.5281/zenodo.100",
+		"resolved_id": "103",
+		"download_time": "2024-01-01T00:00:00Z",
+		"hapiq_version": "dev",
+		"files": [],
+		"download_stats": {}
+	}`
+	if err := os.WriteFile(filepath.Join(dir, "hapiq.json"), []byte(witness), 0o644); err != nil {
+		t.Fatalf("write witness: %v", err)
+	}
+
+	entry, err := FromWitness(filepath.Join(dir, "hapiq.json"))
+	if err != nil {
+		t.Fatalf("FromWitness() error: %v", err)
+	}
+	if entry.Accession != "zenodo:103" {
+		t.Errorf("Accession = %q, want zenodo:103 (the resolved version)", entry.Accession)
+	}
+}
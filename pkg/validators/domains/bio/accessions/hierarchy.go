@@ -0,0 +1,48 @@
+package accessions
+
+// hierarchies lists each archive's chain from project down to run. Samples
+// are linked to experiments rather than sitting above them, so they hang off
+// their study instead (see sampleParents).
+var hierarchies = [][]AccessionType{
+	{ProjectBioProject, StudySRA, ExperimentSRA, RunSRA},
+	{ProjectGSA, StudyGSA, ExperimentGSA, RunGSA},
+	{SeriesGEO},
+}
+
+// sampleParents maps a sample type to the last type above it.
+var sampleParents = map[AccessionType]AccessionType{
+	SampleSRA: StudySRA,
+	SampleGEO: SeriesGEO,
+}
+
+// GetAccessionHierarchy returns the ancestors of t followed by t itself, e.g.
+// RunSRA → [ProjectBioProject, StudySRA, ExperimentSRA, RunSRA]. BioSamples
+// are shared across archives and have no fixed parent. Unknown types return
+// nil.
+func GetAccessionHierarchy(t AccessionType) []AccessionType {
+	if t == SampleBioSample {
+		return []AccessionType{t}
+	}
+	if parent, ok := sampleParents[t]; ok {
+		return append(GetAccessionHierarchy(parent), t)
+	}
+	for _, chain := range hierarchies {
+		for i, ct := range chain {
+			if ct == t {
+				return append([]AccessionType(nil), chain[:i+1]...)
+			}
+		}
+	}
+	return nil
+}
+
+// IsDataLevel reports whether accessions of type t point at sequence data
+// (runs) rather than descriptive metadata.
+func IsDataLevel(t AccessionType) bool {
+	for _, p := range patterns {
+		if p.Type == t {
+			return p.Level == LevelRun
+		}
+	}
+	return false
+}
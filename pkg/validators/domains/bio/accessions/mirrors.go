@@ -0,0 +1,74 @@
+package accessions
+
+import "fmt"
+
+// Mirror is one archive that serves records of a database.
+type Mirror struct {
+	Name   string `json:"name"`
+	Region string `json:"region"`
+	// URL is the browse URL template; %s is replaced by the accession.
+	URL string `json:"url"`
+}
+
+// INSDC members exchange SRA/ENA/DDBJ records daily, so any of the three
+// can serve an accession regardless of where it was submitted.
+var (
+	mirrorNCBI = Mirror{Name: "NCBI SRA", Region: "usa", URL: "https://www.ncbi.nlm.nih.gov/sra/%s"}
+	mirrorENA  = Mirror{Name: "EBI ENA", Region: "europe", URL: "https://www.ebi.ac.uk/ena/browser/view/%s"}
+	mirrorDDBJ = Mirror{Name: "DDBJ Search", Region: "japan", URL: "https://ddbj.nig.ac.jp/search?query=%s"}
+)
+
+var mirrors = map[Database][]Mirror{
+	DatabaseSRA:  {mirrorNCBI, mirrorENA, mirrorDDBJ},
+	DatabaseENA:  {mirrorENA, mirrorNCBI, mirrorDDBJ},
+	DatabaseDDBJ: {mirrorDDBJ, mirrorNCBI, mirrorENA},
+	DatabaseGSA: {
+		{Name: "NGDC GSA", Region: "china", URL: "https://ngdc.cncb.ac.cn/search/all?q=%s"},
+	},
+	DatabaseGEO: {
+		{Name: "NCBI GEO", Region: "usa", URL: "https://www.ncbi.nlm.nih.gov/geo/query/acc.cgi?acc=%s"},
+	},
+	DatabaseBioSample: {
+		{Name: "NCBI BioSample", Region: "usa", URL: "https://www.ncbi.nlm.nih.gov/biosample/%s"},
+		{Name: "EBI BioSamples", Region: "europe", URL: "https://www.ebi.ac.uk/biosamples/samples/%s"},
+	},
+	DatabaseBioProject: {
+		{Name: "NCBI BioProject", Region: "usa", URL: "https://www.ncbi.nlm.nih.gov/bioproject/%s"},
+		{Name: "EBI ENA", Region: "europe", URL: "https://www.ebi.ac.uk/ena/browser/view/%s"},
+	},
+}
+
+// GetRegionalMirrors returns the archives serving db, the home archive first.
+func GetRegionalMirrors(db Database) []Mirror {
+	return append([]Mirror(nil), mirrors[db]...)
+}
+
+// archiveRegions maps Accession.Archive to Mirror.Region.
+var archiveRegions = map[string]string{"ncbi": "usa", "ebi": "europe", "ddbj": "japan", "ngdc": "china"}
+
+// URLs returns browse URLs for a, starting with the archive that issued it
+// (so PRJEB… opens at ENA even though BioProject lists NCBI first).
+func (a Accession) URLs() []string {
+	ms := mirrors[a.Database]
+	home := archiveRegions[a.Archive]
+	out := make([]string, 0, len(ms))
+	for _, m := range ms {
+		if m.Region == home {
+			out = append(out, fmt.Sprintf(m.URL, a.ID))
+		}
+	}
+	for _, m := range ms {
+		if m.Region != home {
+			out = append(out, fmt.Sprintf(m.URL, a.ID))
+		}
+	}
+	return out
+}
+
+// PrimaryURL returns the home archive's browse URL for a, or "".
+func (a Accession) PrimaryURL() string {
+	if urls := a.URLs(); len(urls) > 0 {
+		return urls[0]
+	}
+	return ""
+}
@@ -0,0 +1,220 @@
+// Package accessions recognises biological database accessions (SRA, ENA,
+// DDBJ, GSA, GEO, BioSample and BioProject) and classifies them by database,
+// hierarchy level and canonical form. It is purely syntactic: nothing here
+// talks to the network.
+package accessions
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Database identifies the archive namespace an accession belongs to.
+type Database string
+
+// Databases covered by the patterns below.
+const (
+	DatabaseSRA        Database = "sra"
+	DatabaseENA        Database = "ena"
+	DatabaseDDBJ       Database = "ddbj"
+	DatabaseGSA        Database = "gsa"
+	DatabaseGEO        Database = "geo"
+	DatabaseBioSample  Database = "biosample"
+	DatabaseBioProject Database = "bioproject"
+)
+
+// Level is the position of an accession in the project → run hierarchy.
+type Level string
+
+// Hierarchy levels, from the broadest to the data-bearing one.
+const (
+	LevelProject    Level = "project"
+	LevelStudy      Level = "study"
+	LevelSample     Level = "sample"
+	LevelExperiment Level = "experiment"
+	LevelRun        Level = "run"
+)
+
+// AccessionType names one family of accessions, e.g. all SRA-style runs.
+type AccessionType string
+
+// Accession types. The SRA types cover the three INSDC archives (SRA, ENA,
+// DDBJ), which share one numbering scheme distinguished by the first letter.
+const (
+	ProjectBioProject AccessionType = "bioproject"
+	ProjectGSA        AccessionType = "gsa_project"
+	SeriesGEO         AccessionType = "geo_series"
+	StudySRA          AccessionType = "sra_study"
+	StudyGSA          AccessionType = "gsa_study"
+	SampleSRA         AccessionType = "sra_sample"
+	SampleGEO         AccessionType = "geo_sample"
+	SampleBioSample   AccessionType = "biosample"
+	ExperimentSRA     AccessionType = "sra_experiment"
+	ExperimentGSA     AccessionType = "gsa_experiment"
+	RunSRA            AccessionType = "sra_run"
+	RunGSA            AccessionType = "gsa_run"
+)
+
+// AccessionPattern describes one accession prefix.
+type AccessionPattern struct {
+	Pattern     *regexp.Regexp
+	Type        AccessionType
+	Database    Database
+	Level       Level
+	Description string
+	// Archive is the hosting institution: ncbi, ebi, ddbj or ngdc.
+	Archive string
+	// Priority orders patterns when several could match; higher wins.
+	Priority int
+}
+
+// Accession is the classification of a single identifier.
+type Accession struct {
+	// ID is the canonical form: trimmed and upper-cased.
+	ID       string        `json:"id"`
+	Type     AccessionType `json:"type"`
+	Database Database      `json:"database"`
+	Level    Level         `json:"level"`
+	Archive  string        `json:"archive"`
+	// Description is a short human label such as "GSA project".
+	Description string `json:"description"`
+}
+
+// patterns is sorted by descending Priority in init.
+var patterns = []AccessionPattern{
+	{Pattern: regexp.MustCompile(`^PRJNA\d+$`), Type: ProjectBioProject, Database: DatabaseBioProject, Level: LevelProject, Archive: "ncbi", Description: "NCBI BioProject", Priority: 100},
+	{Pattern: regexp.MustCompile(`^PRJEB\d+$`), Type: ProjectBioProject, Database: DatabaseBioProject, Level: LevelProject, Archive: "ebi", Description: "EBI BioProject", Priority: 100},
+	{Pattern: regexp.MustCompile(`^PRJDB\d+$`), Type: ProjectBioProject, Database: DatabaseBioProject, Level: LevelProject, Archive: "ddbj", Description: "DDBJ BioProject", Priority: 100},
+	{Pattern: regexp.MustCompile(`^PRJCA\d+$`), Type: ProjectGSA, Database: DatabaseGSA, Level: LevelProject, Archive: "ngdc", Description: "GSA project", Priority: 100},
+	{Pattern: regexp.MustCompile(`^GSE\d+$`), Type: SeriesGEO, Database: DatabaseGEO, Level: LevelProject, Archive: "ncbi", Description: "GEO series", Priority: 95},
+
+	{Pattern: regexp.MustCompile(`^SRP\d{6,}$`), Type: StudySRA, Database: DatabaseSRA, Level: LevelStudy, Archive: "ncbi", Description: "SRA study", Priority: 90},
+	{Pattern: regexp.MustCompile(`^ERP\d{6,}$`), Type: StudySRA, Database: DatabaseENA, Level: LevelStudy, Archive: "ebi", Description: "ENA study", Priority: 90},
+	{Pattern: regexp.MustCompile(`^DRP\d{6,}$`), Type: StudySRA, Database: DatabaseDDBJ, Level: LevelStudy, Archive: "ddbj", Description: "DDBJ study", Priority: 90},
+	{Pattern: regexp.MustCompile(`^CRA\d{6,}$`), Type: StudyGSA, Database: DatabaseGSA, Level: LevelStudy, Archive: "ngdc", Description: "GSA study", Priority: 90},
+	{Pattern: regexp.MustCompile(`^HRA\d{6,}$`), Type: StudyGSA, Database: DatabaseGSA, Level: LevelStudy, Archive: "ngdc", Description: "GSA-Human study", Priority: 90},
+
+	{Pattern: regexp.MustCompile(`^SAMN\d{8,}$`), Type: SampleBioSample, Database: DatabaseBioSample, Level: LevelSample, Archive: "ncbi", Description: "NCBI BioSample", Priority: 85},
+	{Pattern: regexp.MustCompile(`^SAMEA?\d{6,}$`), Type: SampleBioSample, Database: DatabaseBioSample, Level: LevelSample, Archive: "ebi", Description: "EBI BioSample", Priority: 85},
+	{Pattern: regexp.MustCompile(`^SAMD\d{8,}$`), Type: SampleBioSample, Database: DatabaseBioSample, Level: LevelSample, Archive: "ddbj", Description: "DDBJ BioSample", Priority: 85},
+	{Pattern: regexp.MustCompile(`^SAMC\d{6,}$`), Type: SampleBioSample, Database: DatabaseBioSample, Level: LevelSample, Archive: "ngdc", Description: "GSA BioSample", Priority: 85},
+
+	{Pattern: regexp.MustCompile(`^SRS\d{6,}$`), Type: SampleSRA, Database: DatabaseSRA, Level: LevelSample, Archive: "ncbi", Description: "SRA sample", Priority: 80},
+	{Pattern: regexp.MustCompile(`^ERS\d{6,}$`), Type: SampleSRA, Database: DatabaseENA, Level: LevelSample, Archive: "ebi", Description: "ENA sample", Priority: 80},
+	{Pattern: regexp.MustCompile(`^DRS\d{6,}$`), Type: SampleSRA, Database: DatabaseDDBJ, Level: LevelSample, Archive: "ddbj", Description: "DDBJ sample", Priority: 80},
+	{Pattern: regexp.MustCompile(`^GSM\d+$`), Type: SampleGEO, Database: DatabaseGEO, Level: LevelSample, Archive: "ncbi", Description: "GEO sample", Priority: 80},
+
+	{Pattern: regexp.MustCompile(`^SRX\d{6,}$`), Type: ExperimentSRA, Database: DatabaseSRA, Level: LevelExperiment, Archive: "ncbi", Description: "SRA experiment", Priority: 70},
+	{Pattern: regexp.MustCompile(`^ERX\d{6,}$`), Type: ExperimentSRA, Database: DatabaseENA, Level: LevelExperiment, Archive: "ebi", Description: "ENA experiment", Priority: 70},
+	{Pattern: regexp.MustCompile(`^DRX\d{6,}$`), Type: ExperimentSRA, Database: DatabaseDDBJ, Level: LevelExperiment, Archive: "ddbj", Description: "DDBJ experiment", Priority: 70},
+	{Pattern: regexp.MustCompile(`^[CH]RX\d{6,}$`), Type: ExperimentGSA, Database: DatabaseGSA, Level: LevelExperiment, Archive: "ngdc", Description: "GSA experiment", Priority: 70},
+
+	{Pattern: regexp.MustCompile(`^SRR\d{6,}$`), Type: RunSRA, Database: DatabaseSRA, Level: LevelRun, Archive: "ncbi", Description: "SRA run", Priority: 60},
+	{Pattern: regexp.MustCompile(`^ERR\d{6,}$`), Type: RunSRA, Database: DatabaseENA, Level: LevelRun, Archive: "ebi", Description: "ENA run", Priority: 60},
+	{Pattern: regexp.MustCompile(`^DRR\d{6,}$`), Type: RunSRA, Database: DatabaseDDBJ, Level: LevelRun, Archive: "ddbj", Description: "DDBJ run", Priority: 60},
+	{Pattern: regexp.MustCompile(`^[CH]RR\d{6,}$`), Type: RunGSA, Database: DatabaseGSA, Level: LevelRun, Archive: "ngdc", Description: "GSA run", Priority: 60},
+}
+
+func init() {
+	sort.SliceStable(patterns, func(i, j int) bool { return patterns[i].Priority > patterns[j].Priority })
+}
+
+// Canonicalize trims whitespace and upper-cases s.
+func Canonicalize(s string) string {
+	return strings.ToUpper(strings.TrimSpace(s))
+}
+
+// Classify returns the database, level and canonical form of s. ok is false
+// when s does not look like any supported accession.
+func Classify(s string) (Accession, bool) {
+	id := Canonicalize(s)
+	p, ok := MatchAccession(id)
+	if !ok {
+		return Accession{}, false
+	}
+	return Accession{
+		ID:          id,
+		Type:        p.Type,
+		Database:    p.Database,
+		Level:       p.Level,
+		Archive:     p.Archive,
+		Description: p.Description,
+	}, true
+}
+
+// MatchAccession returns the highest-priority pattern matching s (after
+// canonicalisation).
+func MatchAccession(s string) (AccessionPattern, bool) {
+	id := Canonicalize(s)
+	for _, p := range patterns {
+		if p.Pattern.MatchString(id) {
+			return p, true
+		}
+	}
+	return AccessionPattern{}, false
+}
+
+// MatchAllAccessions returns every pattern matching s, highest priority first.
+func MatchAllAccessions(s string) []AccessionPattern {
+	id := Canonicalize(s)
+	var out []AccessionPattern
+	for _, p := range patterns {
+		if p.Pattern.MatchString(id) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// tokenPattern finds accession-shaped words in free text.
+var tokenPattern = regexp.MustCompile(`\b[A-Za-z]{3,5}[0-9]{3,}\b`)
+
+// ExtractAccessionFromText returns the distinct accessions mentioned in text,
+// canonicalised, in order of first appearance.
+func ExtractAccessionFromText(text string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, tok := range tokenPattern.FindAllString(text, -1) {
+		id := Canonicalize(tok)
+		if seen[id] {
+			continue
+		}
+		if _, ok := MatchAccession(id); ok {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// ValidateAccessionFormat checks s against the supported patterns and, when
+// it does not match, explains why.
+func ValidateAccessionFormat(s string) (bool, []string) {
+	trimmed := strings.TrimSpace(s)
+	if _, ok := MatchAccession(trimmed); ok && trimmed == strings.ToUpper(trimmed) {
+		return true, nil
+	}
+
+	var issues []string
+	if trimmed == "" {
+		return false, []string{"accession is empty"}
+	}
+	if trimmed != strings.ToUpper(trimmed) {
+		issues = append(issues, "accession should be uppercase")
+	}
+	if len(trimmed) < 6 {
+		issues = append(issues, "accession too short (minimum 6 characters)")
+	}
+	for _, r := range trimmed {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			issues = append(issues, "accession contains invalid characters")
+			break
+		}
+	}
+	if _, ok := MatchAccession(trimmed); !ok {
+		issues = append(issues, "unrecognised accession prefix or number of digits")
+	}
+	return false, issues
+}
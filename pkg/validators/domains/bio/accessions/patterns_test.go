@@ -0,0 +1,121 @@
+package accessions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		in       string
+		id       string
+		typ      AccessionType
+		database Database
+		level    Level
+		archive  string
+	}{
+		{"PRJNA123456", "PRJNA123456", ProjectBioProject, DatabaseBioProject, LevelProject, "ncbi"},
+		{"PRJEB123456", "PRJEB123456", ProjectBioProject, DatabaseBioProject, LevelProject, "ebi"},
+		{"PRJDB123456", "PRJDB123456", ProjectBioProject, DatabaseBioProject, LevelProject, "ddbj"},
+		{" prjca123456\n", "PRJCA123456", ProjectGSA, DatabaseGSA, LevelProject, "ngdc"},
+		{"GSE123456", "GSE123456", SeriesGEO, DatabaseGEO, LevelProject, "ncbi"},
+		{"SRP123456", "SRP123456", StudySRA, DatabaseSRA, LevelStudy, "ncbi"},
+		{"ERP123456", "ERP123456", StudySRA, DatabaseENA, LevelStudy, "ebi"},
+		{"DRP123456", "DRP123456", StudySRA, DatabaseDDBJ, LevelStudy, "ddbj"},
+		{"CRA123456", "CRA123456", StudyGSA, DatabaseGSA, LevelStudy, "ngdc"},
+		{"HRA000123", "HRA000123", StudyGSA, DatabaseGSA, LevelStudy, "ngdc"},
+		{"SRS123456", "SRS123456", SampleSRA, DatabaseSRA, LevelSample, "ncbi"},
+		{"GSM123456", "GSM123456", SampleGEO, DatabaseGEO, LevelSample, "ncbi"},
+		{"SAMN12345678", "SAMN12345678", SampleBioSample, DatabaseBioSample, LevelSample, "ncbi"},
+		{"SAMEA1234567", "SAMEA1234567", SampleBioSample, DatabaseBioSample, LevelSample, "ebi"},
+		{"SAMD12345678", "SAMD12345678", SampleBioSample, DatabaseBioSample, LevelSample, "ddbj"},
+		{"SAMC123456", "SAMC123456", SampleBioSample, DatabaseBioSample, LevelSample, "ngdc"},
+		{"SRX123456", "SRX123456", ExperimentSRA, DatabaseSRA, LevelExperiment, "ncbi"},
+		{"CRX123456", "CRX123456", ExperimentGSA, DatabaseGSA, LevelExperiment, "ngdc"},
+		{"srr123456", "SRR123456", RunSRA, DatabaseSRA, LevelRun, "ncbi"},
+		{"ERR123456", "ERR123456", RunSRA, DatabaseENA, LevelRun, "ebi"},
+		{"DRR123456", "DRR123456", RunSRA, DatabaseDDBJ, LevelRun, "ddbj"},
+		{"CRR123456", "CRR123456", RunGSA, DatabaseGSA, LevelRun, "ngdc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			got, ok := Classify(tt.in)
+			if !ok {
+				t.Fatalf("Classify(%q) not recognised", tt.in)
+			}
+			if got.ID != tt.id || got.Type != tt.typ || got.Database != tt.database ||
+				got.Level != tt.level || got.Archive != tt.archive {
+				t.Errorf("Classify(%q) = %+v", tt.in, got)
+			}
+		})
+	}
+}
+
+func TestClassifyRejects(t *testing.T) {
+	for _, in := range []string{"", "SRR12", "GSEabc", "10.5281/zenodo.123", "XRR123456", "PRJXX1"} {
+		if got, ok := Classify(in); ok {
+			t.Errorf("Classify(%q) = %+v, want not recognised", in, got)
+		}
+	}
+}
+
+func TestExtractAccessionFromText(t *testing.T) {
+	text := "Data from SRR123456, ERX789012 and GSE456789 (see also srr123456 and PRJCA000123)."
+	want := []string{"SRR123456", "ERX789012", "GSE456789", "PRJCA000123"}
+	if got := ExtractAccessionFromText(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractAccessionFromText = %v, want %v", got, want)
+	}
+}
+
+func TestValidateAccessionFormat(t *testing.T) {
+	if ok, issues := ValidateAccessionFormat("SRR123456"); !ok || len(issues) != 0 {
+		t.Errorf("SRR123456: ok=%v issues=%v", ok, issues)
+	}
+	ok, issues := ValidateAccessionFormat("srr123")
+	if ok {
+		t.Fatal("srr123 accepted")
+	}
+	want := []string{
+		"accession should be uppercase",
+		"unrecognised accession prefix or number of digits",
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("issues = %v, want %v", issues, want)
+	}
+}
+
+func TestGetAccessionHierarchy(t *testing.T) {
+	tests := []struct {
+		in   AccessionType
+		want []AccessionType
+	}{
+		{RunSRA, []AccessionType{ProjectBioProject, StudySRA, ExperimentSRA, RunSRA}},
+		{RunGSA, []AccessionType{ProjectGSA, StudyGSA, ExperimentGSA, RunGSA}},
+		{SampleSRA, []AccessionType{ProjectBioProject, StudySRA, SampleSRA}},
+		{SampleGEO, []AccessionType{SeriesGEO, SampleGEO}},
+		{SampleBioSample, []AccessionType{SampleBioSample}},
+		{AccessionType("nope"), nil},
+	}
+	for _, tt := range tests {
+		if got := GetAccessionHierarchy(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetAccessionHierarchy(%s) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	if !IsDataLevel(RunSRA) || IsDataLevel(StudySRA) {
+		t.Error("IsDataLevel: runs are data, studies are not")
+	}
+}
+
+func TestAccessionURLs(t *testing.T) {
+	a, _ := Classify("PRJEB123456")
+	if got := a.PrimaryURL(); got != "https://www.ebi.ac.uk/ena/browser/view/PRJEB123456" {
+		t.Errorf("PRJEB PrimaryURL = %s, want the ENA page", got)
+	}
+	a, _ = Classify("ERR123456")
+	if urls := a.URLs(); len(urls) != 3 || urls[0] != "https://www.ebi.ac.uk/ena/browser/view/ERR123456" {
+		t.Errorf("ERR URLs = %v, want ENA first and all three INSDC mirrors", urls)
+	}
+	if len(GetRegionalMirrors(DatabaseGSA)) != 1 {
+		t.Error("GSA should have exactly one mirror")
+	}
+}